@@ -3,23 +3,49 @@ package main
 
 import (
 	"context"
-	"log"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/soheilhy/cmux"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 
 	"dfs"
+	"dfs/internal/auth"
 	"dfs/internal/config"
+	"dfs/internal/envelope"
 	dfsfs "dfs/internal/fusefs"
+	"dfs/internal/join"
+	"dfs/internal/logging"
 	"dfs/internal/node"
 	"dfs/internal/server"
+	"dfs/internal/tlsutil"
 	pb "dfs/proto"
 )
 
+const (
+	// cmdRecover runs the offline recovery instead of starting the node.
+	cmdRecover = "recover"
+	// cmdConfig with subConfigPrint prints the effective configuration.
+	cmdConfig      = "config"
+	subConfigPrint = "print"
+)
+
 // withDefaultPort ensures the address has a port. If missing, defaultPort
 // is appended. An empty host is allowed and results in ":port".
 func withDefaultPort(addr string, defaultPort int) string {
@@ -29,69 +55,452 @@ func withDefaultPort(addr string, defaultPort int) string {
 	return net.JoinHostPort(addr, strconv.Itoa(defaultPort))
 }
 
+// advertiseAddr returns addr with an empty or unspecified host replaced
+// by the host name, so peers can reach the node at the returned address.
+func advertiseAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	if ip := net.ParseIP(host); host != "" && (ip == nil || !ip.IsUnspecified()) {
+		return addr
+	}
+	name, err := os.Hostname()
+	if err != nil {
+		return addr
+	}
+	return net.JoinHostPort(name, port)
+}
+
+// joinCluster adds the node to the cluster its seeds belong to.
+func joinCluster(cfg config.Config, self string, seeds []string, dial []grpc.DialOption) {
+	if err := join.Join(context.Background(), seeds, cfg.ID, self, cfg.Nonvoter, dial...); err != nil {
+		slog.Error("join failed", logging.Err(err))
+		return
+	}
+	slog.Info("joined the cluster", "address", self)
+}
+
+// bootstrapExpect waits for cfg.BootstrapExpect servers. The one with the
+// lowest id bootstraps them, the others in the list wait to be contacted
+// by the new leader and nodes left out of it join the cluster. If a seed
+// already belongs to a cluster the node joins it instead, so a node whose
+// data was lost cannot start a second cluster.
+func bootstrapExpect(n *node.Node, cfg config.Config, self string, seeds []string, dial []grpc.DialOption) {
+	slog.Info("waiting for servers to bootstrap", "expect", cfg.BootstrapExpect)
+	members, err := join.Discover(context.Background(), cfg.ID, self, seeds, cfg.BootstrapExpect, dial...)
+	switch {
+	case errors.Is(err, join.ErrClusterExists):
+		slog.Info("cluster exists; joining it", logging.Err(err))
+		joinCluster(cfg, self, seeds, dial)
+	case err != nil:
+		fatal("bootstrap failed", logging.Err(err))
+	case !slices.ContainsFunc(members, func(m node.Member) bool { return m.ID == cfg.ID }):
+		slog.Info("left out of the bootstrap; joining the cluster", "server", members[0].ID)
+		joinCluster(cfg, self, seeds, dial)
+	case members[0].ID != cfg.ID:
+		slog.Info("waiting for another server to bootstrap the cluster", "server", members[0].ID)
+	default:
+		if err := n.Bootstrap(members); err != nil {
+			fatal("bootstrap failed", logging.Err(err))
+		}
+		slog.Info("bootstrapped the cluster", "servers", len(members))
+	}
+}
+
+// startAutopilot runs the autopilot with cfg's settings when enabled.
+// Servers are asked for their status with fetchStatus; one that refuses
+// the request still counts as reachable.
+func startAutopilot(n *node.Node, cfg config.Autopilot, dial []grpc.DialOption) {
+	if !cfg.Enabled {
+		return
+	}
+	ap := node.DefaultAutopilotConfig()
+	ap.CleanupDeadServers = cfg.CleanupDeadServers
+	if cfg.MinQuorum > 0 {
+		ap.MinQuorum = cfg.MinQuorum
+	}
+	if cfg.LastContactThreshold > 0 {
+		ap.LastContactThreshold = cfg.LastContactThreshold
+	}
+	if cfg.MaxTrailingLogs > 0 {
+		ap.MaxTrailingLogs = cfg.MaxTrailingLogs
+	}
+	if cfg.StabilizationTime > 0 {
+		ap.StabilizationTime = cfg.StabilizationTime
+	}
+	if cfg.DeadServerThreshold > 0 {
+		ap.DeadServerThreshold = cfg.DeadServerThreshold
+	}
+	n.StartAutopilot(ap, fetchStatus(dial))
+}
+
+// fetchStatus returns a function asking a server for its status over gRPC
+// on its Raft address. A server that refuses the request is reported with
+// node.ErrStatusDenied.
+func fetchStatus(dial []grpc.DialOption) node.StatsFunc {
+	return func(ctx context.Context, m node.Member) (node.Status, error) {
+		conn, err := grpc.DialContext(ctx, m.Address, dial...)
+		if err != nil {
+			return node.Status{}, err
+		}
+		defer conn.Close()
+		st, err := pb.NewFileServiceClient(conn).ClusterStatus(ctx, &pb.ClusterStatusRequest{})
+		switch status.Code(err) {
+		case codes.OK:
+		case codes.Unauthenticated, codes.PermissionDenied:
+			return node.Status{}, fmt.Errorf("%s: %w: %v", m.ID, node.ErrStatusDenied, err)
+		default:
+			return node.Status{}, err
+		}
+		return node.Status{
+			ID:           st.Id,
+			State:        st.State,
+			Term:         st.Term,
+			LastLogIndex: st.LastLogIndex,
+			CommitIndex:  st.CommitIndex,
+			AppliedIndex: st.AppliedIndex,
+			KeyIDs:       st.KeyIds,
+		}, nil
+	}
+}
+
+// registerAPI keeps addr recorded in the cluster as the node's API
+// address, retrying while there is no leader and after the node is added
+// again, until ctx is canceled.
+func registerAPI(ctx context.Context, n *node.Node, addr string, dial []grpc.DialOption) {
+	const interval = 10 * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		rctx, cancel := context.WithTimeout(ctx, interval)
+		err := join.RegisterAPI(rctx, n, addr, dial...)
+		cancel()
+		if err != nil && ctx.Err() == nil && n.Leader() != "" {
+			slog.Warn("register api address failed", logging.Err(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// shutdown stops the node within cfg.ShutdownTimeout: the gRPC server
+// stops accepting RPCs and finishes those in flight, the node hands off
+// leadership and closes its stores, the listeners close and the
+// filesystem is unmounted. RPCs still running at the deadline are
+// cancelled. Buffered spans are flushed once the node is down, and the
+// metrics endpoint, if any, stays up until the end.
+func shutdown(cfg config.Config, s *grpc.Server, n *node.Node, mux cmux.CMux, flushTraces func(context.Context) error, metrics *http.Server) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.Stop()
+	}
+	var errs []error
+	if err := n.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("node: %w", err))
+	}
+	mux.Close()
+	if err := dfsfs.Unmount(cfg.MountPoint); err != nil {
+		errs = append(errs, fmt.Errorf("unmount: %w", err))
+	}
+	if err := flushTraces(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("tracing: %w", err))
+	}
+	if metrics != nil {
+		if err := metrics.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("metrics: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sealerOptions returns the node option for encryption at rest, if any.
+func sealerOptions(cfg config.Config) []node.Option {
+	if cfg.KeyFile == "" {
+		return nil
+	}
+	keys, err := envelope.LoadKeyFile(cfg.KeyFile)
+	if err != nil {
+		fatal("load keys failed", logging.Err(err))
+	}
+	return []node.Option{node.WithSealer(envelope.New(keys))}
+}
+
+// recoverCluster rewrites the stored cluster configuration of a stopped
+// node from the peers.json file in its data directory.
+func recoverCluster(cfg config.Config, logger *slog.Logger) {
+	opts := append(sealerOptions(cfg), node.WithTuning(node.Tuning(cfg.Tuning)), node.WithLogger(logger))
+	sum, err := node.Recover(cfg.ID, cfg.Data, opts...)
+	if err != nil {
+		fatal("recover failed", logging.Err(err))
+	}
+	fmt.Printf("before: snapshot index %d, last log index %d, servers %s\n", sum.SnapshotIndex, sum.LastLogIndex, memberList(sum.Previous))
+	fmt.Printf("after:  applied index %d, servers %s\n", sum.AppliedIndex, memberList(sum.Recovered))
+}
+
+func memberList(members []node.Member) string {
+	var parts []string
+	for _, m := range members {
+		parts = append(parts, fmt.Sprintf("%s=%s (%s)", m.ID, m.Address, m.Suffrage))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// componentMain tags the records logged by the program itself.
+const componentMain = "main"
+
+// fatal logs msg with args at error level and exits.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// usage describes the command line.
+const usage = "usage: %s [recover|config print] [flags]"
+
 func main() {
-	const (
-		defaultPort   = 13000
-		listenNet     = "tcp"
-		mountPoint    = "/mnt/dfs"
-		cacheDir      = "/mnt/hostfs"
-		checkInterval = time.Minute
-	)
-
-	cfg, err := config.Load()
+	const listenNet = "tcp"
+
+	// A leading subcommand precedes the flags.
+	cmd, args := "", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+		if cmd == cmdConfig && len(args) > 0 {
+			cmd, args = cmd+" "+args[0], args[1:]
+		}
+	}
+	cfg, err := config.Parse(os.Args[0], args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		log.Fatalf("config: %v", err)
+		fatal("invalid configuration", logging.Err(err))
+	}
+	// Every component logs through one logger; its level follows the
+	// configuration across reloads.
+	level := new(slog.LevelVar)
+	level.Set(cfg.LogLevel)
+	logger := logging.New(os.Stderr, cfg.LogFormat == config.LogFormatJSON, level).With(logging.KeyNode, cfg.ID)
+	slog.SetDefault(logging.Component(logger, componentMain))
+	dfsfs.SetLogger(logger)
+	switch cmd {
+	case "":
+	case cmdRecover:
+		recoverCluster(cfg, logger)
+		return
+	case cmdConfig + " " + subConfigPrint:
+		if err := cfg.Print(os.Stdout); err != nil {
+			fatal("print configuration failed", logging.Err(err))
+		}
+		return
+	default:
+		fatal("unknown command", "command", cmd, "usage", fmt.Sprintf(usage, os.Args[0]))
+	}
+	flushTraces := func(context.Context) error { return nil }
+	if cfg.Tracing() {
+		if flushTraces, err = startTracing(context.Background(), cfg); err != nil {
+			fatal("start tracing failed", logging.Err(err))
+		}
 	}
 
-	addr := withDefaultPort(cfg.GRPC, defaultPort)
+	// The Raft listener serves Raft and, multiplexed on it, gRPC between
+	// servers. The API shares it unless -grpc names another address.
+	addr := withDefaultPort(cfg.GRPC, cfg.Port)
+	apiAddr := ""
 	if cfg.Raft != "" {
-		addr = withDefaultPort(cfg.Raft, defaultPort)
+		addr = withDefaultPort(cfg.Raft, cfg.Port)
+		if cfg.GRPC != "" && withDefaultPort(cfg.GRPC, cfg.Port) != addr {
+			apiAddr = withDefaultPort(cfg.GRPC, cfg.Port)
+		}
+	}
+	self := cfg.AdvertiseRaft
+	if self == "" {
+		self = advertiseAddr(addr)
+	}
+	apiSelf := cfg.AdvertiseGRPC
+	switch {
+	case apiSelf != "":
+	case apiAddr != "":
+		apiSelf = advertiseAddr(apiAddr)
+	default:
+		apiSelf = self
 	}
-	peerStr := ""
-	if len(cfg.Peers) > 0 {
-		var ps []string
-		for _, p := range cfg.Peers {
-			ps = append(ps, withDefaultPort(p, defaultPort))
+	// Peers are id=address or a bare address used as the id; seeds are
+	// their addresses, which also serve gRPC.
+	var peers, seeds []string
+	for _, p := range cfg.Peers {
+		id, peerAddr := node.SplitPeer(p)
+		peerAddr = withDefaultPort(peerAddr, cfg.Port)
+		if id == p {
+			id = peerAddr
 		}
-		peerStr = strings.Join(ps, ",")
+		peers = append(peers, id+"="+peerAddr)
+		seeds = append(seeds, peerAddr)
 	}
+	peerStr := strings.Join(peers, ",")
 
 	lis, err := net.Listen(listenNet, addr)
 	if err != nil {
-		log.Fatalf("listen: %v", err)
+		fatal("listen failed", "address", addr, logging.Err(err))
+	}
+	var apiLis net.Listener
+	if apiAddr != "" {
+		if apiLis, err = net.Listen(listenNet, apiAddr); err != nil {
+			fatal("listen failed", "address", apiAddr, logging.Err(err))
+		}
+	}
+	opts := []node.Option{
+		node.WithCompression(cfg.Compress),
+		node.WithAdmins(cfg.Admins...),
+		node.WithTuning(node.Tuning(cfg.Tuning)),
+		node.WithAdvertise(self),
+		node.WithLogger(logger),
+	}
+	creds := insecure.NewCredentials()
+	joinCreds := insecure.NewCredentials()
+	var certs *tlsutil.Reloader
+	if cfg.TLS() {
+		if certs, err = tlsutil.NewReloader(cfg.TLSCert, cfg.TLSKey, cfg.TLSCA); err != nil {
+			fatal("load certificates failed", logging.Err(err))
+		}
+		// Terminate TLS before cmux so both protocols are matched on the
+		// decrypted stream; Raft peers must present a client certificate.
+		lis = tls.NewListener(lis, certs.ServerConfig())
+		if apiLis != nil {
+			apiLis = tls.NewListener(apiLis, certs.ServerConfig())
+		}
+		creds = tlsutil.Terminated()
+		joinCreds = certs.ClientCredentials("")
+		opts = append(opts, node.WithTLS(func() *tls.Config {
+			return certs.ClientConfig("", tlsutil.ProtoRaft)
+		}))
 	}
 	mux := cmux.New(lis)
 	grpcL := mux.Match(cmux.HTTP2())
 	raftL := mux.Match(cmux.Any())
+	if cfg.TLS() {
+		raftL = tlsutil.RequirePeerCert(raftL)
+	}
 
-	n, err := node.NewWithListener(cfg.ID, raftL, cfg.Data, peerStr, !cfg.Join)
+	opts = append(opts, sealerOptions(cfg)...)
+	expect := cfg.BootstrapExpect > 0 && !cfg.Join
+	n, err := node.NewWithListener(cfg.ID, raftL, cfg.Data, peerStr, !cfg.Join && !expect, opts...)
+	if err != nil {
+		fatal("start node failed", logging.Err(err))
+	}
+	nodeTok, err := loadNodeToken(cfg.NodeTokenFile)
 	if err != nil {
-		log.Fatalf("node: %v", err)
+		fatal("load node token failed", logging.Err(err))
+	}
+	token := newNodeToken(nodeTok, cfg.TLS())
+	dial := []grpc.DialOption{grpc.WithTransportCredentials(joinCreds), grpc.WithPerRPCCredentials(token)}
+	if cfg.Tracing() {
+		dial = append(dial, grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+	}
+	switch {
+	case n.IsMember():
+		// Bootstrapped, or restarted with stored state.
+	case expect:
+		go bootstrapExpect(n, cfg, self, seeds, dial)
+	case len(seeds) == 0:
+		slog.Warn("no peers to join; add this node with dfsctl add", "id", cfg.ID, "address", self)
+	default:
+		go joinCluster(cfg, self, seeds, dial)
 	}
 	dfs.SetNode(n)
+	dfs.SetIdentity(cfg.FUSEIdentity)
+	n.StartGC(cfg.GCInterval)
+	n.StartKeyRotation(cfg.KeyInterval, fetchStatus(dial))
+	startAutopilot(n, cfg.Autopilot, dial)
+	// ctx is cancelled on shutdown to stop the background goroutines.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go registerAPI(ctx, n, apiSelf, dial)
 
 	// Start FUSE filesystem, cache watcher and consistency checker.
 	go func() {
-		if err := dfsfs.Mount(mountPoint, cacheDir); err != nil {
-			log.Fatalf("mount: %v", err)
+		if err := dfsfs.Mount(cfg.MountPoint, cfg.CacheDir); err != nil {
+			fatal("mount failed", "mount_point", cfg.MountPoint, logging.Err(err))
 		}
 	}()
 	go func() {
-		if err := dfsfs.Watch(context.Background(), cacheDir); err != nil {
-			log.Fatalf("watch: %v", err)
+		if err := dfsfs.Watch(ctx, cfg.CacheDir); err != nil && ctx.Err() == nil {
+			fatal("watch cache failed", logging.Err(err))
 		}
 	}()
-	go dfsfs.Check(context.Background(), cacheDir, checkInterval)
+	r := &reloader{args: args, cfg: cfg, n: n, node: token, certs: certs, level: level, ctx: ctx}
+	r.startChecker()
 
-	s := grpc.NewServer()
-	pb.RegisterFileServiceServer(s, server.New(n))
+	srvOpts := []server.Option{server.WithReload(r.reload), server.WithLogger(logger)}
+	if cfg.Auth {
+		if r.tokens, err = loadTokens(cfg.AuthTokens); err != nil {
+			fatal("load tokens failed", logging.Err(err))
+		}
+		r.authn = auth.NewAuthenticator(r.tokens)
+		srvOpts = append(srvOpts, server.WithAuth(r.authn))
+	}
+	srv := server.New(n, srvOpts...)
+	grpcOpts := []grpc.ServerOption{
+		grpc.Creds(creds),
+		grpc.ChainUnaryInterceptor(srv.Observe, srv.Authenticate),
+		grpc.ChainStreamInterceptor(srv.ObserveStream, srv.AuthenticateStream),
+	}
+	if cfg.Tracing() {
+		grpcOpts = append(grpcOpts, grpc.StatsHandler(otelgrpc.NewServerHandler()))
+	}
+	s := grpc.NewServer(grpcOpts...)
+	pb.RegisterFileServiceServer(s, srv)
 	go func() {
-		log.Printf("gRPC listening on %s", addr)
+		slog.Info("gRPC listening", "address", addr)
 		if err := s.Serve(grpcL); err != nil {
-			log.Fatalf("grpc: %v", err)
+			fatal("serve gRPC failed", logging.Err(err))
+		}
+	}()
+	if apiLis != nil {
+		go func() {
+			slog.Info("gRPC API listening", "address", apiAddr)
+			if err := s.Serve(apiLis); err != nil {
+				fatal("serve gRPC API failed", logging.Err(err))
+			}
+		}()
+	}
+	go func() {
+		if err := mux.Serve(); err != nil && !errors.Is(err, net.ErrClosed) {
+			fatal("serve listener failed", logging.Err(err))
+		}
+	}()
+	var metrics *http.Server
+	if cfg.Metrics != "" {
+		if metrics, err = serveMetrics(cfg.Metrics, n.Collector(), srv.Collector(), dfsfs.Collector()); err != nil {
+			fatal("serve metrics failed", logging.Err(err))
 		}
+	}
+
+	// SIGHUP reloads the configuration. The first SIGINT or SIGTERM starts
+	// an orderly shutdown, a second one forces exit.
+	hups := make(chan os.Signal, 1)
+	signal.Notify(hups, syscall.SIGHUP)
+	go r.reloadOnSignal(hups)
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	slog.Info("shutting down", "signal", (<-sigs).String())
+	go func() {
+		fatal("exiting now", "signal", (<-sigs).String())
 	}()
-	if err := mux.Serve(); err != nil {
-		log.Fatalf("mux: %v", err)
+	cancel()
+	if err := shutdown(cfg, s, n, mux, flushTraces, metrics); err != nil {
+		fatal("shutdown failed", logging.Err(err))
 	}
+	slog.Info("shutdown complete")
 }
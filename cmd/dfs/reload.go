@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"dfs"
+	"dfs/internal/auth"
+	"dfs/internal/config"
+	dfsfs "dfs/internal/fusefs"
+	"dfs/internal/logging"
+	"dfs/internal/node"
+	"dfs/internal/tlsutil"
+)
+
+// reloader applies the reloadable settings of the configuration sources
+// read at startup to the running node, on SIGHUP or the Reload RPC.
+type reloader struct {
+	mu     sync.Mutex
+	args   []string
+	cfg    config.Config
+	n      *node.Node
+	authn  *auth.Authenticator // nil without authentication
+	tokens map[string]string
+	node   *nodeToken
+	certs  *tlsutil.Reloader // nil without TLS
+	level  *slog.LevelVar
+	// ctx bounds the cache checker, which is restarted when its interval
+	// changes.
+	ctx         context.Context
+	stopChecker context.CancelFunc
+}
+
+// startChecker starts the cache consistency checker, stopping the one
+// running before.
+func (r *reloader) startChecker() {
+	if r.stopChecker != nil {
+		r.stopChecker()
+	}
+	ctx, cancel := context.WithCancel(r.ctx)
+	r.stopChecker = cancel
+	go dfsfs.Check(ctx, r.cfg.CacheDir, r.cfg.CheckInterval)
+}
+
+// reload reads the configuration again and applies its reloadable
+// settings. It returns the settings that changed, including token and
+// certificate files whose contents changed. Every file is read and the
+// Raft tuning applied, which validates it, before anything else takes
+// effect, so nothing is applied when the configuration or one of the
+// files cannot be read or the tuning is invalid.
+func (r *reloader) reload() ([]config.Change, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	next, err := config.Parse(os.Args[0], r.args)
+	if err != nil {
+		return nil, err
+	}
+	cfg, changes, err := r.cfg.Reload(next)
+	if err != nil {
+		return nil, err
+	}
+	tokens := r.tokens
+	if r.authn != nil {
+		if tokens, err = loadTokens(cfg.AuthTokens); err != nil {
+			return nil, err
+		}
+	}
+	nodeTok, err := loadNodeToken(cfg.NodeTokenFile)
+	if err != nil {
+		return nil, err
+	}
+	var certs *tlsutil.Pending
+	if r.certs != nil {
+		if certs, err = r.certs.Check(); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.n.ReloadTuning(node.Tuning(cfg.Tuning)); err != nil {
+		return nil, err
+	}
+	if certs != nil {
+		certs.Apply()
+		changes = append(changes, config.Change{Key: config.FileKey(config.EnvTLSCert), Old: cfg.TLSCert, New: cfg.TLSCert, Applied: true})
+	}
+	if r.authn != nil && !maps.Equal(tokens, r.tokens) {
+		r.authn.SetTokens(tokens)
+		if cfg.AuthTokens == r.cfg.AuthTokens {
+			changes = append(changes, config.Change{Key: config.FileKey(config.EnvAuthTokens), Old: cfg.AuthTokens, New: cfg.AuthTokens, Applied: true})
+		}
+		r.tokens = tokens
+	}
+	if nodeTok != r.node.get() {
+		r.node.set(nodeTok)
+		if cfg.NodeTokenFile == r.cfg.NodeTokenFile {
+			changes = append(changes, config.Change{Key: config.FileKey(config.EnvNodeTokenFile), Old: cfg.NodeTokenFile, New: cfg.NodeTokenFile, Applied: true})
+		}
+	}
+	r.n.SetAdmins(cfg.Admins...)
+	dfs.SetIdentity(cfg.FUSEIdentity)
+	r.n.SetGCInterval(cfg.GCInterval)
+	r.n.SetKeyInterval(cfg.KeyInterval)
+	r.level.Set(cfg.LogLevel)
+	restartChecker := cfg.CheckInterval != r.cfg.CheckInterval
+	r.cfg = cfg
+	if restartChecker {
+		r.startChecker()
+	}
+	return changes, nil
+}
+
+// reloadOnSignal reloads the configuration for every value received on
+// sigs and logs the outcome.
+func (r *reloader) reloadOnSignal(sigs <-chan os.Signal) {
+	for range sigs {
+		changes, err := r.reload()
+		if err != nil {
+			slog.Error("reload failed", logging.Err(err))
+			continue
+		}
+		if len(changes) == 0 {
+			slog.Info("reload: no changes")
+		}
+		for _, c := range changes {
+			slog.Info("reload", "key", c.Key, "old", c.Old, "new", c.New, "applied", c.Applied)
+		}
+	}
+}
+
+// loadTokens reads the token file at path; an empty path accepts no
+// tokens.
+func loadTokens(path string) (map[string]string, error) {
+	if path == "" {
+		return map[string]string{}, nil
+	}
+	return auth.LoadTokens(path)
+}
+
+// loadNodeToken reads the token in the node token file at path; an empty
+// path sends none.
+func loadNodeToken(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	tok := strings.TrimSpace(string(b))
+	if tok == "" {
+		return "", fmt.Errorf("%s: no token", path)
+	}
+	return tok, nil
+}
+
+// nodeToken sends the node's bearer token on calls to other servers, so
+// they can authorize cluster operations when nodes have no client
+// certificates. Reloading the configuration replaces the token.
+type nodeToken struct {
+	token  atomic.Pointer[string]
+	secure bool
+}
+
+func newNodeToken(token string, secure bool) *nodeToken {
+	t := &nodeToken{secure: secure}
+	t.set(token)
+	return t
+}
+
+func (t *nodeToken) get() string  { return *t.token.Load() }
+func (t *nodeToken) set(v string) { t.token.Store(&v) }
+
+func (t *nodeToken) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	tok := t.get()
+	if tok == "" {
+		return nil, nil
+	}
+	return auth.Token(tok, t.secure).GetRequestMetadata(ctx, uri...)
+}
+
+func (t *nodeToken) RequireTransportSecurity() bool { return t.secure }
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"dfs/internal/config"
+	"dfs/internal/logging"
+)
+
+const serviceName = "dfs"
+
+// startTracing installs the global tracer provider exporting the spans of
+// sampled traces to cfg's trace file and OTLP collector, and the W3C
+// propagator carrying trace context in gRPC metadata. The returned
+// function flushes the spans still buffered and closes the exporters.
+func startTracing(ctx context.Context, cfg config.Config) (func(context.Context) error, error) {
+	const traceFilePerm = 0o644
+	var (
+		opts    []sdktrace.TracerProviderOption
+		closers []func() error
+	)
+	if cfg.TraceFile != "" {
+		f, err := os.OpenFile(cfg.TraceFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, traceFilePerm)
+		if err != nil {
+			return nil, err
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+		closers = append(closers, f.Close)
+	}
+	if cfg.TraceOTLP != "" {
+		exp, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(cfg.TraceOTLP))
+		if err != nil {
+			return nil, errors.Join(err, closeAll(closers))
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", serviceName),
+		attribute.String("service.instance.id", cfg.ID),
+	))
+	if err != nil {
+		return nil, errors.Join(err, closeAll(closers))
+	}
+	opts = append(opts,
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.TraceSample))),
+	)
+	tp := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		slog.Warn("tracing", logging.Err(err))
+	}))
+	return func(ctx context.Context) error {
+		return errors.Join(tp.Shutdown(ctx), closeAll(closers))
+	}, nil
+}
+
+func closeAll(closers []func() error) error {
+	var errs []error
+	for _, c := range closers {
+		errs = append(errs, c())
+	}
+	return errors.Join(errs...)
+}
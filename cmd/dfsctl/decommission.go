@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+
+	pb "dfs/proto"
+)
+
+const (
+	stateLeader  = "Leader"
+	pollInterval = 200 * time.Millisecond
+	noSuffrage   = "-"
+)
+
+// cluster holds a client for each reachable node, keyed by node id.
+type cluster struct {
+	clients map[string]pb.FileServiceClient
+	conns   []*grpc.ClientConn
+}
+
+func dialCluster(ctx context.Context, addrs []string, opts []grpc.DialOption) (*cluster, error) {
+	c := &cluster{clients: map[string]pb.FileServiceClient{}}
+	for _, addr := range addrs {
+		conn, err := grpc.DialContext(ctx, addr, opts...)
+		if err != nil {
+			fmt.Printf("%s: %v\n", addr, err)
+			continue
+		}
+		client := pb.NewFileServiceClient(conn)
+		st, err := client.ClusterStatus(ctx, &pb.ClusterStatusRequest{})
+		if err != nil {
+			fmt.Printf("%s: %v\n", addr, err)
+			conn.Close()
+			continue
+		}
+		c.conns = append(c.conns, conn)
+		c.clients[st.Id] = client
+	}
+	if len(c.clients) == 0 {
+		return nil, errors.New("no node reachable")
+	}
+	return c, nil
+}
+
+func (c *cluster) Close() {
+	for _, conn := range c.conns {
+		conn.Close()
+	}
+}
+
+// leader polls the nodes until one reports itself leader and is not
+// excluded.
+func (c *cluster) leader(ctx context.Context, exclude string) (string, *pb.ClusterStatusResponse, error) {
+	for {
+		for id, client := range c.clients {
+			if id == exclude {
+				continue
+			}
+			st, err := client.ClusterStatus(ctx, &pb.ClusterStatusRequest{})
+			if err == nil && st.State == stateLeader {
+				return id, st, nil
+			}
+		}
+		if err := sleep(ctx); err != nil {
+			return "", nil, errors.New("no leader among the given nodes")
+		}
+	}
+}
+
+// decommission removes node id from the cluster: it moves leadership away
+// from the node if needed, waits until the node has applied everything
+// the leader had committed and then removes it. addrs must include the
+// leader's gRPC address.
+func decommission(ctx context.Context, id string, addrs []string, opts []grpc.DialOption) error {
+	c, err := dialCluster(ctx, addrs, opts)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	leaderID, st, err := c.leader(ctx, "")
+	if err != nil {
+		return err
+	}
+	if suffrage(st, id) == noSuffrage {
+		return fmt.Errorf("%s is not a member of the cluster", id)
+	}
+	if leaderID == id {
+		fmt.Printf("transferring leadership away from %s\n", id)
+		if _, err := c.clients[id].TransferLeadership(ctx, &pb.TransferLeadershipRequest{}); err != nil {
+			return fmt.Errorf("transfer leadership: %w", err)
+		}
+		if leaderID, st, err = c.leader(ctx, id); err != nil {
+			return err
+		}
+		fmt.Printf("%s is the new leader\n", leaderID)
+	}
+	if target, ok := c.clients[id]; ok {
+		fmt.Printf("waiting for %s to apply index %d\n", id, st.CommitIndex)
+		for {
+			tst, err := target.ClusterStatus(ctx, &pb.ClusterStatusRequest{})
+			if err == nil && tst.AppliedIndex >= st.CommitIndex {
+				break
+			}
+			if err := sleep(ctx); err != nil {
+				return fmt.Errorf("%s did not catch up: %w", id, err)
+			}
+		}
+	} else {
+		fmt.Printf("%s is not reachable; skipping catch-up\n", id)
+	}
+	fmt.Printf("removing %s\n", id)
+	if _, err := c.clients[leaderID].RemovePeer(ctx, &pb.RemovePeerRequest{Id: id}); err != nil {
+		return fmt.Errorf("remove: %w", err)
+	}
+	fmt.Printf("%s decommissioned\n", id)
+	return nil
+}
+
+// sleep waits for the poll interval or until ctx is done.
+func sleep(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(pollInterval):
+		return nil
+	}
+}
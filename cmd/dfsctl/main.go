@@ -2,14 +2,22 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 
+	"dfs/internal/auth"
+	"dfs/internal/config"
 	pb "dfs/proto"
 )
 
@@ -17,29 +25,105 @@ const (
 	cmdAdd      = "add"
 	cmdRemove   = "remove"
 	cmdDelete   = "delete"
+	cmdGrant    = "grant"
+	cmdRevoke   = "revoke"
+	cmdACL      = "acl"
+	cmdGC       = "gc"
+	cmdNS       = "ns"
+	subCreate   = "create"
+	subDelete   = "delete"
+	subList     = "list"
+	cmdMembers  = "members"
+	cmdStatus   = "status"
+	cmdTransfer = "transfer"
+	cmdPromote  = "promote"
+	cmdDemote   = "demote"
+	cmdDecomm   = "decommission"
+	cmdQuota    = "quota"
+	subSet      = "set"
+	subUsage    = "usage"
+	cmdSnapshot = "snapshot"
+	subExport   = "export"
+	cmdReload   = "reload"
 	flagGRPC    = "grpc"
 	flagID      = "id"
 	flagAddr    = "address"
 	flagKey     = "key"
+	flagCA      = "ca"
+	flagCert    = "cert"
+	flagTLSKey  = "tls-key"
+	flagServer  = "server-name"
+	flagToken   = "token"
+	flagIdent   = "identity"
+	flagPrefix  = "prefix"
+	flagPerm    = "perm"
+	flagNS      = "namespace"
+	flagBytes   = "max-bytes"
+	flagObjects = "max-objects"
+	flagWait    = "wait"
+	flagNonvote = "nonvoter"
+	flagCompact = "compact"
+	flagOut     = "out"
 	defaultGRPC = ":13000"
 	timeoutSec  = 5
+	envToken    = "DFS_TOKEN"
 )
 
 func main() {
 	if len(os.Args) < 2 {
-		log.Fatalf("usage: %s [add|remove|delete] [flags]", os.Args[0])
+		log.Fatalf("usage: %s [add|remove|delete|grant|revoke|acl|gc|members|status|transfer|decommission|promote|demote|ns create|ns delete|ns list|quota set|quota usage|snapshot create|snapshot list|snapshot export|reload] [flags] [name]", os.Args[0])
+	}
+	cmd, args := os.Args[1], os.Args[2:]
+	if (cmd == cmdNS || cmd == cmdQuota || cmd == cmdSnapshot) && len(args) > 0 {
+		cmd, args = cmd+" "+args[0], args[1:]
 	}
-	cmd := os.Args[1]
 	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
-	grpcAddr := fs.String(flagGRPC, defaultGRPC, "gRPC address")
-	id := fs.String(flagID, "", "node id")
+	grpcAddr := fs.String(flagGRPC, defaultGRPC, "gRPC address; status and decommission accept a comma separated list")
+	id := fs.String(flagID, "", "node id; for transfer the new leader, empty to let Raft pick; for snapshot export the snapshot, empty for the newest")
 	addr := fs.String(flagAddr, "", "raft address")
+	nonvoter := fs.Bool(flagNonvote, false, "add the node as a nonvoting read replica")
 	key := fs.String(flagKey, "", "file key")
-	fs.Parse(os.Args[2:])
+	ca := fs.String(flagCA, "", "CA certificate file; enables TLS")
+	cert := fs.String(flagCert, "", "client certificate file for mutual TLS")
+	tlsKey := fs.String(flagTLSKey, "", "client private key file for mutual TLS")
+	serverName := fs.String(flagServer, "", "expected server name in the TLS certificate")
+	token := fs.String(flagToken, os.Getenv(envToken), "bearer token (default $"+envToken+")")
+	identity := fs.String(flagIdent, "", "ACL identity, or * for any caller")
+	prefix := fs.String(flagPrefix, "", "ACL or quota path prefix")
+	perm := fs.String(flagPerm, "read", "ACL permission: read, write or admin")
+	ns := fs.String(flagNS, "", "namespace; empty for the default namespace")
+	maxBytes := fs.Int64(flagBytes, 0, "quota byte limit; 0 is unlimited")
+	maxObjects := fs.Int64(flagObjects, 0, "quota object limit; 0 is unlimited")
+	wait := fs.Duration(flagWait, time.Minute, "how long decommission or snapshot export may take")
+	compact := fs.Bool(flagCompact, false, "drop the trailing log entries kept after the snapshot")
+	out := fs.String(flagOut, "", "file to export the snapshot to; empty for standard output")
+	fs.Parse(args)
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeoutSec*time.Second)
+	creds, err := transportCreds(*ca, *cert, *tlsKey, *serverName)
+	if err != nil {
+		log.Fatalf("tls: %v", err)
+	}
+	limit := timeoutSec * time.Second
+	if cmd == cmdDecomm || cmd == cmdSnapshot+" "+subExport {
+		limit = *wait
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), limit)
 	defer cancel()
-	conn, err := grpc.DialContext(ctx, *grpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if *token != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(auth.Token(*token, *ca != "")))
+	}
+	if cmd == cmdStatus {
+		printStatus(ctx, strings.Split(*grpcAddr, ","), dialOpts)
+		return
+	}
+	if cmd == cmdDecomm {
+		if err := decommission(ctx, firstArg(fs, *id), strings.Split(*grpcAddr, ","), dialOpts); err != nil {
+			log.Fatalf("decommission: %v", err)
+		}
+		return
+	}
+	conn, err := grpc.DialContext(ctx, *grpcAddr, dialOpts...)
 	if err != nil {
 		log.Fatalf("dial: %v", err)
 	}
@@ -47,7 +131,7 @@ func main() {
 	client := pb.NewFileServiceClient(conn)
 	switch cmd {
 	case cmdAdd:
-		if _, err := client.AddPeer(ctx, &pb.AddPeerRequest{Id: *id, Address: *addr}); err != nil {
+		if _, err := client.AddPeer(ctx, &pb.AddPeerRequest{Id: *id, Address: *addr, Nonvoter: *nonvoter}); err != nil {
 			log.Fatalf("add: %v", err)
 		}
 	case cmdRemove:
@@ -55,10 +139,201 @@ func main() {
 			log.Fatalf("remove: %v", err)
 		}
 	case cmdDelete:
-		if _, err := client.Delete(ctx, &pb.DeleteRequest{Key: *key}); err != nil {
+		if _, err := client.Delete(ctx, &pb.DeleteRequest{Key: *key, Namespace: *ns}); err != nil {
 			log.Fatalf("delete: %v", err)
 		}
+	case cmdGrant, cmdRevoke:
+		p, err := auth.ParsePerm(*perm)
+		if err != nil {
+			log.Fatalf("%s: %v", cmd, err)
+		}
+		rule := &pb.ACLRule{Identity: *identity, Namespace: *ns, Prefix: *prefix, Perm: pb.Permission(p)}
+		if cmd == cmdGrant {
+			_, err = client.Grant(ctx, &pb.GrantRequest{Rule: rule})
+		} else {
+			_, err = client.Revoke(ctx, &pb.RevokeRequest{Rule: rule})
+		}
+		if err != nil {
+			log.Fatalf("%s: %v", cmd, err)
+		}
+	case cmdACL:
+		resp, err := client.ListACL(ctx, &pb.ListACLRequest{Namespace: *ns})
+		if err != nil {
+			log.Fatalf("acl: %v", err)
+		}
+		for _, r := range resp.Rules {
+			fmt.Printf("%s\t%q\t%s\n", r.Identity, r.Prefix, auth.Perm(r.Perm))
+		}
+	case cmdGC:
+		if _, err := client.GC(ctx, &pb.GCRequest{}); err != nil {
+			log.Fatalf("gc: %v", err)
+		}
+	case cmdMembers:
+		st, err := client.ClusterStatus(ctx, &pb.ClusterStatusRequest{})
+		if err != nil {
+			log.Fatalf("members: %v", err)
+		}
+		// Health is only reported by a leader running autopilot.
+		health := map[string]*pb.ServerHealth{}
+		for _, h := range st.Health {
+			health[h.Id] = h
+		}
+		for _, m := range st.Members {
+			role := m.Suffrage
+			if m.Id == st.LeaderId {
+				role += " (leader)"
+			}
+			// A server without a separate API listener serves gRPC on
+			// its Raft address.
+			api := m.ApiAddress
+			if api == "" {
+				api = m.Address
+			}
+			line := fmt.Sprintf("%s\t%s\t%s\t%s", m.Id, m.Address, api, role)
+			if h, ok := health[m.Id]; ok {
+				state := "healthy"
+				if !h.Healthy {
+					state = "unhealthy"
+				}
+				line += fmt.Sprintf("\t%s (last contact %s, %d trailing logs)", state, h.LastContact, h.TrailingLogs)
+			}
+			fmt.Println(line)
+		}
+	case cmdPromote:
+		if _, err := client.PromotePeer(ctx, &pb.PromotePeerRequest{Id: *id}); err != nil {
+			log.Fatalf("promote: %v", err)
+		}
+	case cmdDemote:
+		if _, err := client.DemotePeer(ctx, &pb.DemotePeerRequest{Id: *id}); err != nil {
+			log.Fatalf("demote: %v", err)
+		}
+	case cmdTransfer:
+		if _, err := client.TransferLeadership(ctx, &pb.TransferLeadershipRequest{Id: *id}); err != nil {
+			log.Fatalf("transfer: %v", err)
+		}
+	case cmdNS + " " + subCreate:
+		if _, err := client.CreateNamespace(ctx, &pb.CreateNamespaceRequest{Name: firstArg(fs, *ns)}); err != nil {
+			log.Fatalf("%s: %v", cmd, err)
+		}
+	case cmdNS + " " + subDelete:
+		if _, err := client.DeleteNamespace(ctx, &pb.DeleteNamespaceRequest{Name: firstArg(fs, *ns)}); err != nil {
+			log.Fatalf("%s: %v", cmd, err)
+		}
+	case cmdNS + " " + subList:
+		resp, err := client.ListNamespaces(ctx, &pb.ListNamespacesRequest{})
+		if err != nil {
+			log.Fatalf("%s: %v", cmd, err)
+		}
+		for _, name := range resp.Names {
+			fmt.Println(name)
+		}
+	case cmdQuota + " " + subSet:
+		q := &pb.Quota{Namespace: *ns, Prefix: *prefix, MaxBytes: *maxBytes, MaxObjects: *maxObjects}
+		if _, err := client.SetQuota(ctx, &pb.SetQuotaRequest{Quota: q}); err != nil {
+			log.Fatalf("%s: %v", cmd, err)
+		}
+	case cmdQuota + " " + subUsage:
+		resp, err := client.GetUsage(ctx, &pb.GetUsageRequest{Namespace: *ns})
+		if err != nil {
+			log.Fatalf("%s: %v", cmd, err)
+		}
+		for _, u := range resp.Usage {
+			fmt.Printf("%q\t%d/%d bytes\t%d/%d objects\n", u.Quota.Prefix, u.Bytes, u.Quota.MaxBytes, u.Objects, u.Quota.MaxObjects)
+		}
+	case cmdSnapshot + " " + subCreate:
+		resp, err := client.Snapshot(ctx, &pb.SnapshotRequest{Compact: *compact})
+		if err != nil {
+			log.Fatalf("%s: %v", cmd, err)
+		}
+		fmt.Printf("created %s\n", resp.Snapshot.Id)
+		printSnapshots(resp.Snapshots)
+	case cmdSnapshot + " " + subList:
+		resp, err := client.ListSnapshots(ctx, &pb.ListSnapshotsRequest{})
+		if err != nil {
+			log.Fatalf("%s: %v", cmd, err)
+		}
+		printSnapshots(resp.Snapshots)
+	case cmdSnapshot + " " + subExport:
+		if err := exportSnapshot(ctx, client, *id, *out); err != nil {
+			log.Fatalf("%s: %v", cmd, err)
+		}
+	case cmdReload:
+		resp, err := client.Reload(ctx, &pb.ReloadRequest{})
+		if err != nil {
+			log.Fatalf("reload: %v", err)
+		}
+		if len(resp.Changes) == 0 {
+			fmt.Println("no changes")
+		}
+		for _, c := range resp.Changes {
+			fmt.Println(config.Change{Key: c.Key, Old: c.OldValue, New: c.NewValue, Applied: c.Applied})
+		}
 	default:
 		log.Fatalf("unknown command %s", cmd)
 	}
 }
+
+// printStatus prints one line per node with its Raft state and log
+// positions. Unreachable nodes are reported and skipped.
+func printStatus(ctx context.Context, addrs []string, opts []grpc.DialOption) {
+	fmt.Println("ADDRESS\tID\tSTATE\tSUFFRAGE\tTERM\tLAST\tCOMMIT\tAPPLIED\tAPPLY_LAG\tCONTACT\tLEADER")
+	for _, addr := range addrs {
+		conn, err := grpc.DialContext(ctx, addr, opts...)
+		if err != nil {
+			fmt.Printf("%s\terror: %v\n", addr, err)
+			continue
+		}
+		st, err := pb.NewFileServiceClient(conn).ClusterStatus(ctx, &pb.ClusterStatusRequest{})
+		conn.Close()
+		if err != nil {
+			fmt.Printf("%s\terror: %v\n", addr, err)
+			continue
+		}
+		fmt.Printf("%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%s\t%s\n", addr, st.Id, st.State, suffrage(st, st.Id), st.Term,
+			st.LastLogIndex, st.CommitIndex, st.AppliedIndex, st.ApplyLag, st.LastContact, st.LeaderId)
+	}
+}
+
+// suffrage returns the suffrage of id in the configuration reported by st,
+// or noSuffrage when id is not a member.
+func suffrage(st *pb.ClusterStatusResponse, id string) string {
+	for _, m := range st.Members {
+		if m.Id == id {
+			return m.Suffrage
+		}
+	}
+	return noSuffrage
+}
+
+// firstArg returns the first positional argument, falling back to the
+// value of a flag.
+func firstArg(fs *flag.FlagSet, flagValue string) string {
+	if fs.NArg() > 0 {
+		return fs.Arg(0)
+	}
+	return flagValue
+}
+
+// transportCreds returns plaintext credentials unless a CA file is given.
+func transportCreds(ca, cert, key, serverName string) (credentials.TransportCredentials, error) {
+	if ca == "" {
+		return insecure.NewCredentials(), nil
+	}
+	pem, err := os.ReadFile(ca)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificates in " + ca)
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: pool, ServerName: serverName}
+	if cert != "" || key != "" {
+		pair, err := tls.LoadX509KeyPair(cert, key)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{pair}
+	}
+	return credentials.NewTLS(cfg), nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	pb "dfs/proto"
+)
+
+// printSnapshots prints one line per snapshot, newest first.
+func printSnapshots(list []*pb.SnapshotMeta) {
+	fmt.Println("ID\tINDEX\tTERM\tSIZE")
+	for _, m := range list {
+		fmt.Printf("%s\t%d\t%d\t%d\n", m.Id, m.Index, m.Term, m.Size)
+	}
+}
+
+// exportSnapshot writes snapshot id, or the newest one when id is empty,
+// to the file path or to standard output when path is empty. A partly
+// written file is removed on failure.
+func exportSnapshot(ctx context.Context, client pb.FileServiceClient, id, path string) (err error) {
+	stream, err := client.ExportSnapshot(ctx, &pb.ExportSnapshotRequest{Id: id})
+	if err != nil {
+		return err
+	}
+	w := io.Writer(os.Stdout)
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(path)
+			}
+		}()
+		w = f
+	}
+	var meta *pb.SnapshotMeta
+	var written int64
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if chunk.Meta != nil {
+			meta = chunk.Meta
+		}
+		if _, err := w.Write(chunk.Data); err != nil {
+			return err
+		}
+		written += int64(len(chunk.Data))
+	}
+	if meta == nil || written != meta.Size {
+		return fmt.Errorf("snapshot truncated: got %d bytes", written)
+	}
+	if path != "" {
+		fmt.Fprintf(os.Stderr, "exported %s (index %d, term %d, %d bytes)\n", meta.Id, meta.Index, meta.Term, written)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package dfs
 
 import (
+	"context"
 	"crypto/sha256"
 	"errors"
 	"os"
@@ -8,18 +9,34 @@ import (
 	"strings"
 	"sync/atomic"
 
+	"dfs/internal/auth"
 	"dfs/internal/metastore"
 	"dfs/internal/node"
 )
 
 var (
 	nodePtr               atomic.Pointer[node.Node]            // active DFS node
+	identity              atomic.Pointer[string]               // ACL identity for file operations
 	errNodeNotInitialized = errors.New("node not initialized") // SetNode has not been called
 )
 
 // SetNode registers the active DFS node.
 func SetNode(nd *node.Node) { nodePtr.Store(nd) }
 
+// SetIdentity makes file operations run as id and be checked against the
+// node's ACL. An empty id disables the checks.
+func SetIdentity(id string) { identity.Store(&id) }
+
+// authorize returns os.ErrPermission if the configured identity lacks p
+// on path.
+func authorize(nd *node.Node, path string, p auth.Perm) error {
+	id := identity.Load()
+	if id == nil || *id == "" || nd.Authorize(*id, path, p) {
+		return nil
+	}
+	return os.ErrPermission
+}
+
 // GetFile returns the file contents for the given path.
 func cleanPath(p string) (string, error) {
 	const empty = ""
@@ -43,7 +60,13 @@ func GetFile(path string) ([]byte, error) {
 	if nd == nil {
 		return nil, errNodeNotInitialized
 	}
-	data, ok := nd.Get(p)
+	if err := authorize(nd, p, auth.Read); err != nil {
+		return nil, err
+	}
+	data, ok, err := nd.Lookup(p)
+	if err != nil {
+		return nil, err
+	}
 	if !ok {
 		return nil, os.ErrNotExist
 	}
@@ -51,7 +74,14 @@ func GetFile(path string) ([]byte, error) {
 }
 
 // PutFile stores the file contents for the given path through the active node.
+// Content the cluster already holds is referenced by hash and not resent.
 func PutFile(path string, data []byte) error {
+	return PutFileContext(context.Background(), path, data)
+}
+
+// PutFileContext is PutFile with its writes traced as children of the span
+// in ctx, if any.
+func PutFileContext(ctx context.Context, path string, data []byte) error {
 	p, err := cleanPath(path)
 	if err != nil {
 		return err
@@ -60,7 +90,11 @@ func PutFile(path string, data []byte) error {
 	if nd == nil {
 		return errNodeNotInitialized
 	}
-	if err := nd.Put(p, data); err != nil {
+	if err := authorize(nd, p, auth.Write); err != nil {
+		return err
+	}
+	hash := sha256.Sum256(data)
+	if err := nd.PutContentContext(ctx, p, hash, data); err != nil {
 		return err
 	}
 	var ver uint64
@@ -68,12 +102,17 @@ func PutFile(path string, data []byte) error {
 		ver = e.Version
 	}
 	ver++
-	hash := sha256.Sum256(data)
-	return nd.SyncMeta(&metastore.Entry{Path: p, Version: ver, Hash: hash})
+	return nd.SyncMetaContext(ctx, &metastore.Entry{Path: p, Version: ver, Hash: hash, Codec: nd.Codec(hash)})
 }
 
 // DeleteFile removes path from the store and marks its metadata deleted.
 func DeleteFile(path string) error {
+	return DeleteFileContext(context.Background(), path)
+}
+
+// DeleteFileContext is DeleteFile with its writes traced as children of the
+// span in ctx, if any.
+func DeleteFileContext(ctx context.Context, path string) error {
 	p, err := cleanPath(path)
 	if err != nil {
 		return err
@@ -82,15 +121,18 @@ func DeleteFile(path string) error {
 	if nd == nil {
 		return errNodeNotInitialized
 	}
+	if err := authorize(nd, p, auth.Write); err != nil {
+		return err
+	}
 	var ver uint64
 	if e, ok := nd.Meta.Get(p); ok {
 		ver = e.Version
 	}
 	ver++
-	if err := nd.Delete(p); err != nil {
+	if err := nd.DeleteContext(ctx, p); err != nil {
 		return err
 	}
-	return nd.SyncMeta(&metastore.Entry{Path: p, Version: ver, Deleted: true})
+	return nd.SyncMetaContext(ctx, &metastore.Entry{Path: p, Version: ver, Deleted: true})
 }
 
 // GetMetadata returns metadata for path.
@@ -103,6 +145,9 @@ func GetMetadata(path string) (metastore.Entry, error) {
 	if nd == nil {
 		return metastore.Entry{}, errNodeNotInitialized
 	}
+	if err := authorize(nd, p, auth.Read); err != nil {
+		return metastore.Entry{}, err
+	}
 	e, ok := nd.Meta.Get(p)
 	if !ok {
 		return metastore.Entry{}, os.ErrNotExist
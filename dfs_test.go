@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"dfs/internal/auth"
 	"dfs/internal/node"
 )
 
@@ -261,3 +262,25 @@ func TestPutGetLargeFile(t *testing.T) {
 		t.Fatalf("hash mismatch")
 	}
 }
+
+func TestSetIdentity(t *testing.T) {
+	n := node.NewInmem()
+	SetNode(n)
+	SetIdentity(sampleID)
+	t.Cleanup(func() {
+		SetNode(nil)
+		SetIdentity(emptyString)
+	})
+	if err := PutFile(sampleKey, []byte(sampleVal)); !errors.Is(err, os.ErrPermission) {
+		t.Fatalf("expected permission error, got %v", err)
+	}
+	if err := n.Grant(auth.Rule{Identity: sampleID, Prefix: sampleKey, Perm: auth.Write}); err != nil {
+		t.Fatalf("grant: %v", err)
+	}
+	if err := PutFile(sampleKey, []byte(sampleVal)); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if data, err := GetFile(sampleKey); err != nil || string(data) != sampleVal {
+		t.Fatalf("get: %v %q", err, data)
+	}
+}
@@ -0,0 +1,183 @@
+// Package auth resolves caller identities and evaluates path ACLs.
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"dfs/internal/namespace"
+)
+
+// Perm is an access level. Higher levels imply the lower ones.
+type Perm uint8
+
+const (
+	None Perm = iota
+	Read
+	Write
+	Admin
+)
+
+const (
+	// Anyone matches every authenticated identity in a Rule.
+	Anyone = "*"
+
+	headerAuth    = "authorization"
+	bearerPrefix  = "Bearer "
+	commentPrefix = "#"
+)
+
+var permNames = map[Perm]string{None: "none", Read: "read", Write: "write", Admin: "admin"}
+
+func (p Perm) String() string { return permNames[p] }
+
+// ParsePerm parses read, write or admin.
+func ParsePerm(s string) (Perm, error) {
+	for p, name := range permNames {
+		if p != None && name == s {
+			return p, nil
+		}
+	}
+	return None, fmt.Errorf("auth: unknown permission %q", s)
+}
+
+// Rule grants Perm on every path of Namespace starting with Prefix to
+// Identity. A rule with an empty prefix covers the whole namespace; admin
+// on the empty prefix of a namespace allows administering it, and admin on
+// the empty prefix of the default namespace allows cluster administration
+// and covers every namespace.
+type Rule struct {
+	Identity  string `json:"identity"`
+	Namespace string `json:"namespace,omitempty"`
+	Prefix    string `json:"prefix"`
+	Perm      Perm   `json:"perm"`
+}
+
+// Allowed reports whether rules grant id at least p on path, a storage key
+// as produced by namespace.Key.
+func Allowed(rules []Rule, id, path string, p Perm) bool {
+	ns, key := namespace.Split(path)
+	for _, r := range rules {
+		if r.Identity != id && r.Identity != Anyone || r.Perm < p {
+			continue
+		}
+		if r.Namespace == ns && strings.HasPrefix(key, r.Prefix) || r.clusterAdmin() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) clusterAdmin() bool {
+	return r.Namespace == namespace.Default && r.Prefix == "" && r.Perm == Admin
+}
+
+type ctxKey struct{}
+
+// WithIdentity returns ctx carrying the authenticated identity id.
+func WithIdentity(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// IdentityFrom returns the identity stored by WithIdentity.
+func IdentityFrom(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(ctxKey{}).(string)
+	return id, ok
+}
+
+// Authenticator maps bearer tokens and client certificates to identities.
+type Authenticator struct {
+	mu     sync.RWMutex
+	tokens map[string]string // token -> identity
+}
+
+// NewAuthenticator returns an Authenticator accepting the given tokens.
+func NewAuthenticator(tokens map[string]string) *Authenticator {
+	return &Authenticator{tokens: tokens}
+}
+
+// SetTokens replaces the accepted tokens.
+func (a *Authenticator) SetTokens(tokens map[string]string) {
+	a.mu.Lock()
+	a.tokens = tokens
+	a.mu.Unlock()
+}
+
+// LoadTokens reads a token file. Each non-empty line holds a token and the
+// identity it authenticates separated by whitespace; # starts a comment.
+func LoadTokens(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make(map[string]string)
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, commentPrefix) {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("auth: %s:%d: want \"token identity\"", path, line)
+		}
+		tokens[fields[0]] = fields[1]
+	}
+	return tokens, nil
+}
+
+// Identify returns the caller identity from a bearer token in the request
+// metadata or, failing that, the common name of a verified TLS client
+// certificate.
+func (a *Authenticator) Identify(ctx context.Context) (string, bool) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, v := range md.Get(headerAuth) {
+			if tok, ok := strings.CutPrefix(v, bearerPrefix); ok {
+				a.mu.RLock()
+				id, ok := a.tokens[tok]
+				a.mu.RUnlock()
+				if ok {
+					return id, true
+				}
+				return "", false
+			}
+		}
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	cn := info.State.VerifiedChains[0][0].Subject.CommonName
+	return cn, cn != ""
+}
+
+// Token returns per-RPC credentials sending token as a bearer token.
+// secure states whether the connection is encrypted; tokens are only
+// required to travel over TLS when it is.
+func Token(token string, secure bool) credentials.PerRPCCredentials {
+	return tokenCreds{token: token, secure: secure}
+}
+
+type tokenCreds struct {
+	token  string
+	secure bool
+}
+
+func (t tokenCreds) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{headerAuth: bearerPrefix + t.token}, nil
+}
+
+func (t tokenCreds) RequireTransportSecurity() bool { return t.secure }
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+
+	"dfs/internal/namespace"
+)
+
+const (
+	alice = "alice"
+	bob   = "bob"
+	tokA  = "tok-a"
+)
+
+func TestAllowed(t *testing.T) {
+	rules := []Rule{
+		{Identity: alice, Prefix: "logs/", Perm: Write},
+		{Identity: Anyone, Prefix: "pub/", Perm: Read},
+		{Identity: bob, Prefix: "", Perm: Admin},
+		{Identity: alice, Namespace: "team", Prefix: "", Perm: Admin},
+	}
+	cases := []struct {
+		id, path string
+		p        Perm
+		want     bool
+	}{
+		{alice, "logs/a", Read, true},
+		{alice, "logs/a", Write, true},
+		{alice, "logs/a", Admin, false},
+		{alice, "other", Read, false},
+		{alice, "pub/x", Read, true},
+		{alice, "pub/x", Write, false},
+		{bob, "anything", Admin, true},
+		{bob, namespace.Key("team", "x"), Admin, true},
+		{alice, namespace.Key("team", "x"), Admin, true},
+		{alice, namespace.Key("team", "logs/a"), Admin, true},
+		{alice, namespace.Key("other", "logs/a"), Read, false},
+		{"carol", namespace.Key("other", "pub/x"), Read, false},
+	}
+	for _, c := range cases {
+		if got := Allowed(rules, c.id, c.path, c.p); got != c.want {
+			t.Fatalf("%s %s %s: got %v", c.id, c.path, c.p, got)
+		}
+	}
+}
+
+func TestParsePerm(t *testing.T) {
+	for _, p := range []Perm{Read, Write, Admin} {
+		if got, err := ParsePerm(p.String()); err != nil || got != p {
+			t.Fatalf("parse %s: %v %v", p, got, err)
+		}
+	}
+	if _, err := ParsePerm("none"); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestIdentifyToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	if err := os.WriteFile(path, []byte("# tokens\n"+tokA+" "+alice+"\n"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	tokens, err := LoadTokens(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	a := NewAuthenticator(tokens)
+	md, _ := Token(tokA, false).GetRequestMetadata(context.Background())
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.New(md))
+	if id, ok := a.Identify(ctx); !ok || id != alice {
+		t.Fatalf("identify: %q %v", id, ok)
+	}
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(headerAuth, bearerPrefix+"bogus"))
+	if _, ok := a.Identify(ctx); ok {
+		t.Fatalf("unknown token accepted")
+	}
+	if _, ok := a.Identify(context.Background()); ok {
+		t.Fatalf("anonymous caller identified")
+	}
+	a.SetTokens(map[string]string{"bogus": alice})
+	if id, ok := a.Identify(ctx); !ok || id != alice {
+		t.Fatalf("replaced token not accepted: %q %v", id, ok)
+	}
+	if err := os.WriteFile(path, []byte("single-field\n"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := LoadTokens(path); err == nil {
+		t.Fatalf("expected parse error")
+	}
+}
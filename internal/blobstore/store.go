@@ -2,10 +2,13 @@ package blobstore
 
 import (
 	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+
+	"dfs/internal/envelope"
 )
 
 const (
@@ -14,16 +17,24 @@ const (
 	sep       = '@'
 	verPrefix = 'v'
 	emptyPath = ""
+	tmpSuffix = ".tmp"
 )
 
 var errEmptyPath = errors.New("empty path")
 
 // Store persists blobs on disk under root directory.
-type Store struct{ root string }
+type Store struct {
+	root string
+	seal *envelope.Sealer
+}
 
 // New creates a Store rooted at dir.
 func New(dir string) *Store { return &Store{root: dir} }
 
+// NewSealed creates a Store rooted at dir that encrypts blob files with s.
+// Plaintext files written before encryption was enabled remain readable.
+func NewSealed(dir string, s *envelope.Sealer) *Store { return &Store{root: dir, seal: s} }
+
 // Put writes data for path and version.
 func (s *Store) Put(path string, version uint64, data []byte) error {
 	if path == emptyPath {
@@ -33,6 +44,10 @@ func (s *Store) Put(path string, version uint64, data []byte) error {
 	if err := os.MkdirAll(filepath.Dir(p), dirPerm); err != nil {
 		return err
 	}
+	data, err := s.seal.Seal(data)
+	if err != nil {
+		return err
+	}
 	return os.WriteFile(p, data, filePerm)
 }
 
@@ -41,7 +56,55 @@ func (s *Store) Get(path string, version uint64) ([]byte, error) {
 	if path == emptyPath {
 		return nil, errEmptyPath
 	}
-	return os.ReadFile(s.blobPath(path, version))
+	data, err := os.ReadFile(s.blobPath(path, version))
+	if err != nil || !envelope.IsSealed(data) {
+		return data, err
+	}
+	return s.seal.Open(data)
+}
+
+// Reseal re-encrypts blob files that are plaintext or sealed with an old
+// key using the current key. It is meant to run in the background after a
+// key rotation.
+func (s *Store) Reseal() error {
+	if s.seal == nil {
+		return nil
+	}
+	return filepath.WalkDir(s.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil || !s.stale(data) {
+			return err
+		}
+		plain := data
+		if envelope.IsSealed(data) {
+			if plain, err = s.seal.Open(data); err != nil {
+				return err
+			}
+		}
+		sealed, err := s.seal.Seal(plain)
+		if err != nil {
+			return err
+		}
+		tmp := p + tmpSuffix
+		if err := os.WriteFile(tmp, sealed, filePerm); err != nil {
+			return err
+		}
+		return os.Rename(tmp, p)
+	})
+}
+
+// stale reports whether data is plaintext or sealed with a key other than
+// the current one.
+func (s *Store) stale(data []byte) bool {
+	id, err := envelope.KeyID(data)
+	if err != nil {
+		return true
+	}
+	cur, _, err := s.seal.Keys().Current()
+	return err == nil && id != cur
 }
 
 // GC removes blob files not present in keep map.
@@ -2,9 +2,12 @@ package blobstore
 
 import (
 	"bytes"
+	"os"
 	"strconv"
 	"sync"
 	"testing"
+
+	"dfs/internal/envelope"
 )
 
 const (
@@ -59,3 +62,51 @@ func TestConcurrent(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+type staticKeys map[string][]byte
+
+func (k staticKeys) Current() (string, []byte, error) {
+	if _, ok := k["new"]; ok {
+		return "new", k["new"], nil
+	}
+	return "old", k["old"], nil
+}
+
+func (k staticKeys) Key(id string) ([]byte, error) { return k[id], nil }
+
+func TestSealedPutGetReseal(t *testing.T) {
+	dir := t.TempDir()
+	keys := staticKeys{"old": bytes.Repeat([]byte{1}, envelope.KeySize)}
+	s := NewSealed(dir, envelope.New(keys))
+	if err := s.Put(nameA, ver1, []byte(dataA)); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	raw, err := os.ReadFile(s.blobPath(nameA, ver1))
+	if err != nil || bytes.Contains(raw, []byte(dataA)) {
+		t.Fatalf("blob stored in plaintext: %v", err)
+	}
+	// A file written before encryption was enabled stays readable.
+	if err := New(dir).Put(nameB, ver1, []byte(dataA)); err != nil {
+		t.Fatalf("put plain: %v", err)
+	}
+	if got, err := s.Get(nameB, ver1); err != nil || string(got) != dataA {
+		t.Fatalf("get plain: %v %q", err, got)
+	}
+	keys["new"] = bytes.Repeat([]byte{2}, envelope.KeySize)
+	if err := s.Reseal(); err != nil {
+		t.Fatalf("reseal: %v", err)
+	}
+	raw, _ = os.ReadFile(s.blobPath(nameA, ver1))
+	if id, err := envelope.KeyID(raw); err != nil || id != "new" {
+		t.Fatalf("blob not resealed with current key")
+	}
+	raw, _ = os.ReadFile(s.blobPath(nameB, ver1))
+	if !envelope.IsSealed(raw) {
+		t.Fatalf("plaintext blob not sealed")
+	}
+	for _, name := range []string{nameA, nameB} {
+		if got, err := s.Get(name, ver1); err != nil || string(got) != dataA {
+			t.Fatalf("get %s: %v %q", name, err, got)
+		}
+	}
+}
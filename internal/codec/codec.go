@@ -0,0 +1,180 @@
+// Package codec provides the value compression codecs used by the store.
+package codec
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// None stores values uncompressed.
+	None = ""
+	// Gzip compresses values with compress/gzip.
+	Gzip = "gzip"
+	// Flate compresses values with compress/flate.
+	Flate = "flate"
+
+	// MinSize is the smallest value that is worth compressing.
+	MinSize = 256
+)
+
+var errUnknown = errors.New("unknown codec")
+
+// Codec compresses and decompresses values.
+type Codec interface {
+	Encode(data []byte) ([]byte, error)
+	Decode(data []byte) ([]byte, error)
+}
+
+var (
+	mu     sync.RWMutex
+	codecs = map[string]Codec{
+		Gzip:  gzipCodec{},
+		Flate: flateCodec{},
+	}
+)
+
+// Register makes c available under name.
+func Register(name string, c Codec) {
+	mu.Lock()
+	codecs[name] = c
+	mu.Unlock()
+}
+
+func lookup(name string) (Codec, error) {
+	mu.RLock()
+	c, ok := codecs[name]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q", errUnknown, name)
+	}
+	return c, nil
+}
+
+// Valid reports an error if name is not None or a registered codec.
+func Valid(name string) error {
+	if name == None {
+		return nil
+	}
+	_, err := lookup(name)
+	return err
+}
+
+// Compress encodes data with the named codec. Values smaller than MinSize
+// or that do not shrink are returned unchanged with codec None.
+func Compress(name string, data []byte) (string, []byte, error) {
+	if name == None || len(data) < MinSize {
+		return None, data, nil
+	}
+	c, err := lookup(name)
+	if err != nil {
+		return None, nil, err
+	}
+	out, err := c.Encode(data)
+	if err != nil {
+		return None, nil, err
+	}
+	if len(out) >= len(data) {
+		return None, data, nil
+	}
+	return name, out, nil
+}
+
+// Decompress decodes data stored with the named codec.
+func Decompress(name string, data []byte) ([]byte, error) {
+	if name == None {
+		return data, nil
+	}
+	c, err := lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.Decode(data)
+}
+
+// Policy picks a codec for a key by its longest matching prefix.
+type Policy struct {
+	prefixes []string // sorted longest first
+	rules    map[string]string
+}
+
+// NewPolicy builds a Policy from prefix to codec rules. An empty prefix
+// sets the default codec.
+func NewPolicy(rules map[string]string) (*Policy, error) {
+	p := &Policy{rules: make(map[string]string, len(rules))}
+	for prefix, name := range rules {
+		if err := Valid(name); err != nil {
+			return nil, err
+		}
+		p.rules[prefix] = name
+		p.prefixes = append(p.prefixes, prefix)
+	}
+	sort.Slice(p.prefixes, func(i, j int) bool { return len(p.prefixes[i]) > len(p.prefixes[j]) })
+	return p, nil
+}
+
+// For returns the codec for key.
+func (p *Policy) For(key string) string {
+	if p == nil {
+		return None
+	}
+	for _, prefix := range p.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return p.rules[prefix]
+		}
+	}
+	return None
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) Encode(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCodec) Decode(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+type flateCodec struct{}
+
+func (flateCodec) Encode(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (flateCodec) Decode(data []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(data))
+	defer r.Close()
+	return io.ReadAll(r)
+}
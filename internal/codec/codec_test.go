@@ -0,0 +1,56 @@
+package codec
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+const (
+	prefixLogs = "logs/"
+	prefixJSON = "logs/json/"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("compressible "), 100)
+	for _, name := range []string{Gzip, Flate} {
+		got, out, err := Compress(name, data)
+		if err != nil || got != name || len(out) >= len(data) {
+			t.Fatalf("%s: codec=%q len=%d err=%v", name, got, len(out), err)
+		}
+		back, err := Decompress(got, out)
+		if err != nil || !bytes.Equal(back, data) {
+			t.Fatalf("%s: round trip failed: %v", name, err)
+		}
+	}
+}
+
+func TestCompressSkipsSmallAndIncompressible(t *testing.T) {
+	if name, out, _ := Compress(Gzip, []byte("tiny")); name != None || string(out) != "tiny" {
+		t.Fatalf("small value compressed: %q", name)
+	}
+	random := make([]byte, 4096)
+	rand.Read(random)
+	if name, out, _ := Compress(Gzip, random); name != None || !bytes.Equal(out, random) {
+		t.Fatalf("incompressible value compressed: %q", name)
+	}
+	if _, _, err := Compress("zstd", bytes.Repeat([]byte("a"), MinSize)); err == nil {
+		t.Fatalf("expected unknown codec error")
+	}
+}
+
+func TestPolicyLongestPrefix(t *testing.T) {
+	p, err := NewPolicy(map[string]string{"": Flate, prefixLogs: Gzip, prefixJSON: None})
+	if err != nil {
+		t.Fatalf("policy: %v", err)
+	}
+	cases := map[string]string{"a": Flate, prefixLogs + "x": Gzip, prefixJSON + "x": None}
+	for key, want := range cases {
+		if got := p.For(key); got != want {
+			t.Fatalf("%s: got %q want %q", key, got, want)
+		}
+	}
+	if _, err := NewPolicy(map[string]string{prefixLogs: "bogus"}); err == nil {
+		t.Fatalf("expected error for unknown codec")
+	}
+}
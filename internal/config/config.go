@@ -1,58 +1,332 @@
 package config
 
 import (
+	"fmt"
+	"log/slog"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
-	EnvID    = "DFS_ID"
-	EnvRaft  = "DFS_RAFT"
-	EnvGRPC  = "DFS_GRPC"
-	EnvData  = "DFS_DATA"
-	EnvPeers = "DFS_PEERS"
-	EnvJoin  = "DFS_JOIN"
+	EnvID = "DFS_ID"
+	// EnvRaft and EnvGRPC are the Raft and gRPC API bind addresses. When
+	// both are set and differ the API gets a listener of its own; the Raft
+	// listener always serves gRPC to other servers too.
+	EnvRaft = "DFS_RAFT"
+	EnvGRPC = "DFS_GRPC"
+	// EnvAdvertiseRaft and EnvAdvertiseGRPC are the addresses other
+	// servers and clients use to reach the node, for nodes behind NAT, in
+	// containers or bound to an unspecified address. They default to the
+	// bind addresses with the host name filled in.
+	EnvAdvertiseRaft = "DFS_ADVERTISE_RAFT"
+	EnvAdvertiseGRPC = "DFS_ADVERTISE_GRPC"
+	EnvData          = "DFS_DATA"
+	EnvPeers         = "DFS_PEERS"
+	EnvJoin          = "DFS_JOIN"
+	// EnvNonvoter makes the node join as a nonvoter read replica. It
+	// implies EnvJoin.
+	EnvNonvoter = "DFS_NONVOTER"
+	// EnvBootstrapExpect makes a new cluster bootstrap only once this many
+	// servers, counting the node itself, are reachable through the peers.
+	// It must be more than half of the peers and the node together and at
+	// most all of them.
+	EnvBootstrapExpect = "DFS_BOOTSTRAP_EXPECT"
+	// EnvCompress lists prefix=codec compression rules separated by
+	// commas, for example "logs/=gzip,=flate".
+	EnvCompress = "DFS_COMPRESS"
+	// EnvKeyFile points at the key file used to encrypt data at rest.
+	EnvKeyFile = "DFS_KEY_FILE"
+	// EnvTLSCert, EnvTLSKey and EnvTLSCA enable TLS for the API and mutual
+	// TLS between Raft peers. All three must be set together.
+	EnvTLSCert = "DFS_TLS_CERT"
+	EnvTLSKey  = "DFS_TLS_KEY"
+	EnvTLSCA   = "DFS_TLS_CA"
+	// EnvAuth enables authentication and ACL checks on the API.
+	EnvAuth = "DFS_AUTH"
+	// EnvAuthTokens points at a file of "token identity" lines.
+	EnvAuthTokens = "DFS_AUTH_TOKENS"
+	// EnvAuthAdmins lists identities with implicit admin rights.
+	EnvAuthAdmins = "DFS_AUTH_ADMINS"
+	// EnvFUSEIdentity is the identity FUSE reads and cache uploads run as.
+	EnvFUSEIdentity = "DFS_FUSE_IDENTITY"
+	// EnvNodeTokenFile points at a file holding the bearer token the node
+	// presents when it calls other servers to join, bootstrap, register
+	// its API address and check their health. Without it the node
+	// authenticates with its TLS certificate, if any.
+	EnvNodeTokenFile = "DFS_NODE_TOKEN_FILE"
 
-	DefaultID      = "node1"
-	DefaultDataDir = "data"
+	// EnvAutopilot enables the leader's server health checks. The other
+	// EnvAutopilot* variables tune them; unset values keep the defaults.
+	EnvAutopilot              = "DFS_AUTOPILOT"
+	EnvAutopilotCleanup       = "DFS_AUTOPILOT_CLEANUP"
+	EnvAutopilotMinQuorum     = "DFS_AUTOPILOT_MIN_QUORUM"
+	EnvAutopilotLastContact   = "DFS_AUTOPILOT_LAST_CONTACT"
+	EnvAutopilotMaxTrailing   = "DFS_AUTOPILOT_MAX_TRAILING_LOGS"
+	EnvAutopilotStabilization = "DFS_AUTOPILOT_STABILIZATION"
+	EnvAutopilotDead          = "DFS_AUTOPILOT_DEAD_THRESHOLD"
+
+	// EnvRaft* variables tune Raft timing, log compaction and snapshot
+	// retention. Unset values keep DefaultRaftTuning.
+	EnvRaftHeartbeat         = "DFS_RAFT_HEARTBEAT_TIMEOUT"
+	EnvRaftElection          = "DFS_RAFT_ELECTION_TIMEOUT"
+	EnvRaftLeaderLease       = "DFS_RAFT_LEADER_LEASE_TIMEOUT"
+	EnvRaftSnapshotInterval  = "DFS_RAFT_SNAPSHOT_INTERVAL"
+	EnvRaftSnapshotThreshold = "DFS_RAFT_SNAPSHOT_THRESHOLD"
+	EnvRaftTrailingLogs      = "DFS_RAFT_TRAILING_LOGS"
+	EnvRaftMaxAppendEntries  = "DFS_RAFT_MAX_APPEND_ENTRIES"
+	EnvRaftSnapshotRetain    = "DFS_RAFT_SNAPSHOT_RETAIN"
+
+	// EnvPort is the port used for addresses given without one.
+	EnvPort = "DFS_PORT"
+	// EnvMountPoint and EnvCacheDir are the FUSE mount point and the
+	// cache directory whose files are replicated into the store.
+	EnvMountPoint = "DFS_MOUNT_POINT"
+	EnvCacheDir   = "DFS_CACHE_DIR"
+	// EnvCheckInterval, EnvGCInterval and EnvKeyInterval set how often the
+	// cache is checked for consistency, unreferenced content is collected
+	// and the encryption keys are checked for rotation.
+	EnvCheckInterval = "DFS_CHECK_INTERVAL"
+	EnvGCInterval    = "DFS_GC_INTERVAL"
+	EnvKeyInterval   = "DFS_KEY_INTERVAL"
+	// EnvShutdownTimeout bounds the orderly shutdown on SIGINT or SIGTERM.
+	EnvShutdownTimeout = "DFS_SHUTDOWN_TIMEOUT"
+	// EnvMetrics is the address of the HTTP listener serving Prometheus
+	// metrics at /metrics. Unset leaves the endpoint off.
+	EnvMetrics = "DFS_METRICS"
+	// EnvLogLevel is the lowest level logged: debug, info, warn or error.
+	// EnvLogFormat selects text or JSON log records.
+	EnvLogLevel  = "DFS_LOG_LEVEL"
+	EnvLogFormat = "DFS_LOG_FORMAT"
+
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+
+	// EnvTraceFile is a file spans are appended to as JSON and
+	// EnvTraceOTLP the OTLP/gRPC endpoint of a collector, such as
+	// http://localhost:4317; an http URL connects without TLS. Tracing is
+	// off unless one of them is set. EnvTraceSample is the fraction of new
+	// traces recorded; traces started by a caller keep its decision.
+	EnvTraceFile   = "DFS_TRACE_FILE"
+	EnvTraceOTLP   = "DFS_TRACE_OTLP"
+	EnvTraceSample = "DFS_TRACE_SAMPLE"
+
+	DefaultID              = "node1"
+	DefaultDataDir         = "data"
+	DefaultPort            = 13000
+	DefaultMountPoint      = "/mnt/dfs"
+	DefaultCacheDir        = "/mnt/hostfs"
+	DefaultCheckInterval   = time.Minute
+	DefaultGCInterval      = 10 * time.Minute
+	DefaultKeyInterval     = time.Minute
+	DefaultShutdownTimeout = 30 * time.Second
+	DefaultLogFormat       = LogFormatText
+	DefaultTraceSample     = 1.0
+
+	maxPort = 65535
 
 	commaSep = ','
+	ruleSep  = "="
 )
 
 type Config struct {
-	ID    string
-	Raft  string
-	GRPC  string
-	Data  string
-	Peers []string
-	Join  bool
+	ID            string
+	Raft          string
+	GRPC          string
+	AdvertiseRaft string
+	AdvertiseGRPC string
+	Data          string
+	Peers         []string
+	Join          bool
+	// Nonvoter nodes never bootstrap and are added without a vote.
+	Nonvoter bool
+	// BootstrapExpect is the cluster size to wait for before bootstrapping;
+	// zero bootstraps immediately unless Join is set.
+	BootstrapExpect int
+	// Compress maps key prefixes to compression codecs.
+	Compress map[string]string
+	// KeyFile enables encryption at rest with keys from this file.
+	KeyFile string
+	// TLSCert, TLSKey and TLSCA are PEM files for the node certificate,
+	// its private key and the CA that signs node and client certificates.
+	TLSCert string
+	TLSKey  string
+	TLSCA   string
+	// Auth enables authentication by bearer token or client certificate.
+	Auth bool
+	// Port completes addresses given without a port.
+	Port       int
+	MountPoint string
+	CacheDir   string
+	// ShutdownTimeout bounds the orderly shutdown.
+	ShutdownTimeout time.Duration
+	// Metrics is the metrics listener address; empty disables it.
+	Metrics string
+	// LogFormat is LogFormatText or LogFormatJSON.
+	LogFormat string
+	// TraceFile and TraceOTLP are where spans are exported; TraceSample
+	// is the fraction of new traces sampled.
+	TraceFile   string
+	TraceOTLP   string
+	TraceSample float64
+	Autopilot   Autopilot
+	Tuning      RaftTuning
+	Reloadable
 }
 
+// Reloadable holds the settings a running node applies again when it
+// reloads its configuration. Of the Raft tuning, the heartbeat and
+// election timeouts, the snapshot interval and threshold and the trailing
+// logs are reloaded too; every other setting needs a restart.
+type Reloadable struct {
+	// AuthTokens is the token file; it is read again on every reload.
+	AuthTokens string
+	Admins     []string
+	// FUSEIdentity is checked against the ACL for FUSE file operations.
+	FUSEIdentity string
+	// NodeTokenFile holds the token sent to other servers; it is read
+	// again on every reload.
+	NodeTokenFile string
+	// CheckInterval, GCInterval and KeyInterval are the periods of the
+	// cache consistency check, garbage collection and key rotation.
+	CheckInterval time.Duration
+	GCInterval    time.Duration
+	KeyInterval   time.Duration
+	// LogLevel is the lowest level logged; the zero value is info.
+	LogLevel slog.Level
+}
+
+// RaftTuning holds Raft timing and log compaction settings.
+type RaftTuning struct {
+	HeartbeatTimeout   time.Duration
+	ElectionTimeout    time.Duration
+	LeaderLeaseTimeout time.Duration
+	// SnapshotInterval is how often Raft checks whether SnapshotThreshold
+	// entries were applied since the last snapshot.
+	SnapshotInterval  time.Duration
+	SnapshotThreshold uint64
+	// TrailingLogs entries are kept after a snapshot so slow followers
+	// can catch up without installing it.
+	TrailingLogs     uint64
+	MaxAppendEntries int
+	// SnapshotRetain is the number of snapshots kept in the data directory.
+	SnapshotRetain int
+}
+
+// DefaultRaftTuning returns the Raft library defaults, suited to a LAN.
+func DefaultRaftTuning() RaftTuning {
+	return RaftTuning{
+		HeartbeatTimeout:   time.Second,
+		ElectionTimeout:    time.Second,
+		LeaderLeaseTimeout: 500 * time.Millisecond,
+		SnapshotInterval:   120 * time.Second,
+		SnapshotThreshold:  8192,
+		TrailingLogs:       10240,
+		MaxAppendEntries:   64,
+		SnapshotRetain:     1,
+	}
+}
+
+// minTimeout is the shortest timeout Raft accepts.
+const minTimeout = 5 * time.Millisecond
+
+// maxAppendEntries is the largest batch Raft accepts.
+const maxAppendEntries = 1024
+
+// Validate reports settings Raft would refuse to start with.
+func (t RaftTuning) Validate() error {
+	for name, d := range map[string]time.Duration{
+		EnvRaftHeartbeat:        t.HeartbeatTimeout,
+		EnvRaftElection:         t.ElectionTimeout,
+		EnvRaftLeaderLease:      t.LeaderLeaseTimeout,
+		EnvRaftSnapshotInterval: t.SnapshotInterval,
+	} {
+		if d < minTimeout {
+			return fmt.Errorf("%s: %s is shorter than %s", name, d, minTimeout)
+		}
+	}
+	switch {
+	case t.LeaderLeaseTimeout > t.HeartbeatTimeout:
+		return fmt.Errorf("%s must not exceed %s", EnvRaftLeaderLease, EnvRaftHeartbeat)
+	case t.ElectionTimeout < t.HeartbeatTimeout:
+		return fmt.Errorf("%s must not be shorter than %s", EnvRaftElection, EnvRaftHeartbeat)
+	case t.SnapshotThreshold == 0:
+		return fmt.Errorf("%s must be positive", EnvRaftSnapshotThreshold)
+	case t.MaxAppendEntries < 1 || t.MaxAppendEntries > maxAppendEntries:
+		return fmt.Errorf("%s must be between 1 and %d", EnvRaftMaxAppendEntries, maxAppendEntries)
+	case t.SnapshotRetain < 1:
+		return fmt.Errorf("%s must be positive", EnvRaftSnapshotRetain)
+	}
+	return nil
+}
+
+// Autopilot holds the autopilot settings. Zero values select defaults.
+type Autopilot struct {
+	Enabled              bool
+	CleanupDeadServers   bool
+	MinQuorum            int
+	LastContactThreshold time.Duration
+	MaxTrailingLogs      uint64
+	StabilizationTime    time.Duration
+	DeadServerThreshold  time.Duration
+}
+
+// TLS reports whether TLS is configured.
+func (c Config) TLS() bool { return c.TLSCert != "" || c.TLSKey != "" || c.TLSCA != "" }
+
+// Tracing reports whether spans are exported.
+func (c Config) Tracing() bool { return c.TraceFile != "" || c.TraceOTLP != "" }
+
 // Load reads configuration from environment variables.
 func Load() (Config, error) {
-	cfg := Config{ID: DefaultID, Data: DefaultDataDir}
+	return load(os.LookupEnv)
+}
+
+// lookupFunc returns the value of the setting named by its environment
+// variable and whether it was given.
+type lookupFunc func(env string) (string, bool)
 
-	if v, ok := os.LookupEnv(EnvID); ok && v != "" {
+// load builds a Config from the defaults and the settings found by lookup.
+func load(lookup lookupFunc) (Config, error) {
+	cfg := Config{
+		ID:              DefaultID,
+		Data:            DefaultDataDir,
+		Port:            DefaultPort,
+		MountPoint:      DefaultMountPoint,
+		CacheDir:        DefaultCacheDir,
+		ShutdownTimeout: DefaultShutdownTimeout,
+		LogFormat:       DefaultLogFormat,
+		TraceSample:     DefaultTraceSample,
+		Tuning:          DefaultRaftTuning(),
+		Reloadable: Reloadable{
+			CheckInterval: DefaultCheckInterval,
+			GCInterval:    DefaultGCInterval,
+			KeyInterval:   DefaultKeyInterval,
+		},
+	}
+
+	if v, ok := lookup(EnvID); ok && v != "" {
 		cfg.ID = v
 	}
-	if v, ok := os.LookupEnv(EnvRaft); ok && v != "" {
+	if v, ok := lookup(EnvRaft); ok && v != "" {
 		cfg.Raft = v
 	}
-	if v, ok := os.LookupEnv(EnvGRPC); ok && v != "" {
+	if v, ok := lookup(EnvGRPC); ok && v != "" {
 		cfg.GRPC = v
 	}
-	if v, ok := os.LookupEnv(EnvData); ok && v != "" {
+	if v, ok := lookup(EnvData); ok && v != "" {
 		cfg.Data = v
 	}
-	if v, ok := os.LookupEnv(EnvPeers); ok {
+	if v, ok := lookup(EnvPeers); ok {
 		if v != "" {
 			cfg.Peers = strings.Split(v, string(commaSep))
 		} else {
 			cfg.Peers = nil
 		}
 	}
-	if v, ok := os.LookupEnv(EnvJoin); ok {
+	if v, ok := lookup(EnvJoin); ok {
 		if v != "" {
 			b, err := strconv.ParseBool(v)
 			if err != nil {
@@ -62,5 +336,207 @@ func Load() (Config, error) {
 		}
 	}
 
+	if v, ok := lookup(EnvNonvoter); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Nonvoter = b
+		cfg.Join = cfg.Join || b
+	}
+
+	if v, ok := lookup(EnvBootstrapExpect); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("%s: bad server count %q", EnvBootstrapExpect, v)
+		}
+		cfg.BootstrapExpect = n
+	}
+
+	if v, ok := lookup(EnvKeyFile); ok && v != "" {
+		cfg.KeyFile = v
+	}
+	if v, ok := lookup(EnvAuth); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Auth = b
+	}
+	if v, ok := lookup(EnvAuthAdmins); ok && v != "" {
+		cfg.Admins = strings.Split(v, string(commaSep))
+	}
+	for env, dst := range map[string]*string{
+		EnvTLSCert:       &cfg.TLSCert,
+		EnvTLSKey:        &cfg.TLSKey,
+		EnvTLSCA:         &cfg.TLSCA,
+		EnvAuthTokens:    &cfg.AuthTokens,
+		EnvFUSEIdentity:  &cfg.FUSEIdentity,
+		EnvNodeTokenFile: &cfg.NodeTokenFile,
+		EnvMountPoint:    &cfg.MountPoint,
+		EnvCacheDir:      &cfg.CacheDir,
+		EnvAdvertiseRaft: &cfg.AdvertiseRaft,
+		EnvAdvertiseGRPC: &cfg.AdvertiseGRPC,
+		EnvMetrics:       &cfg.Metrics,
+		EnvLogFormat:     &cfg.LogFormat,
+		EnvTraceFile:     &cfg.TraceFile,
+		EnvTraceOTLP:     &cfg.TraceOTLP,
+	} {
+		if v, ok := lookup(env); ok && v != "" {
+			*dst = v
+		}
+	}
+	if v, ok := lookup(EnvPort); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPort {
+			return cfg, fmt.Errorf("%s: bad port %q", EnvPort, v)
+		}
+		cfg.Port = n
+	}
+	for env, dst := range map[string]*time.Duration{
+		EnvCheckInterval:   &cfg.CheckInterval,
+		EnvGCInterval:      &cfg.GCInterval,
+		EnvKeyInterval:     &cfg.KeyInterval,
+		EnvShutdownTimeout: &cfg.ShutdownTimeout,
+	} {
+		if v, ok := lookup(env); ok && v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return cfg, fmt.Errorf("%s: %w", env, err)
+			}
+			if d <= 0 {
+				return cfg, fmt.Errorf("%s must be positive", env)
+			}
+			*dst = d
+		}
+	}
+	if cfg.LogFormat != LogFormatText && cfg.LogFormat != LogFormatJSON {
+		return cfg, fmt.Errorf("%s must be %s or %s", EnvLogFormat, LogFormatText, LogFormatJSON)
+	}
+	if v, ok := lookup(EnvLogLevel); ok && v != "" {
+		if err := cfg.LogLevel.UnmarshalText([]byte(v)); err != nil {
+			return cfg, fmt.Errorf("%s: %w", EnvLogLevel, err)
+		}
+	}
+	if cfg.TraceOTLP != "" {
+		u, err := url.Parse(cfg.TraceOTLP)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return cfg, fmt.Errorf("%s: want an http or https URL, got %q", EnvTraceOTLP, cfg.TraceOTLP)
+		}
+	}
+	if v, ok := lookup(EnvTraceSample); ok && v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 1 {
+			return cfg, fmt.Errorf("%s: bad fraction %q", EnvTraceSample, v)
+		}
+		cfg.TraceSample = f
+	}
+	if err := loadAutopilot(lookup, &cfg.Autopilot); err != nil {
+		return cfg, err
+	}
+	if err := loadTuning(lookup, &cfg.Tuning); err != nil {
+		return cfg, err
+	}
+	if err := cfg.Tuning.Validate(); err != nil {
+		return cfg, err
+	}
+	if cfg.TLS() && (cfg.TLSCert == "" || cfg.TLSKey == "" || cfg.TLSCA == "") {
+		return cfg, fmt.Errorf("%s, %s and %s must be set together", EnvTLSCert, EnvTLSKey, EnvTLSCA)
+	}
+	if v, ok := lookup(EnvCompress); ok && v != "" {
+		cfg.Compress = make(map[string]string)
+		for _, rule := range strings.Split(v, string(commaSep)) {
+			prefix, name, found := strings.Cut(rule, ruleSep)
+			if !found {
+				return cfg, fmt.Errorf("%s: bad rule %q", EnvCompress, rule)
+			}
+			cfg.Compress[prefix] = name
+		}
+	}
+
 	return cfg, nil
 }
+
+// loadAutopilot reads the EnvAutopilot* settings into a.
+func loadAutopilot(lookup lookupFunc, a *Autopilot) error {
+	for env, dst := range map[string]*bool{EnvAutopilot: &a.Enabled, EnvAutopilotCleanup: &a.CleanupDeadServers} {
+		if v, ok := lookup(env); ok && v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", env, err)
+			}
+			*dst = b
+		}
+	}
+	for env, dst := range map[string]*time.Duration{
+		EnvAutopilotLastContact:   &a.LastContactThreshold,
+		EnvAutopilotStabilization: &a.StabilizationTime,
+		EnvAutopilotDead:          &a.DeadServerThreshold,
+	} {
+		if v, ok := lookup(env); ok && v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", env, err)
+			}
+			*dst = d
+		}
+	}
+	if v, ok := lookup(EnvAutopilotMinQuorum); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return fmt.Errorf("%s: bad server count %q", EnvAutopilotMinQuorum, v)
+		}
+		a.MinQuorum = n
+	}
+	if v, ok := lookup(EnvAutopilotMaxTrailing); ok && v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s: %w", EnvAutopilotMaxTrailing, err)
+		}
+		a.MaxTrailingLogs = n
+	}
+	return nil
+}
+
+// loadTuning reads the EnvRaft* tuning settings into t.
+func loadTuning(lookup lookupFunc, t *RaftTuning) error {
+	for env, dst := range map[string]*time.Duration{
+		EnvRaftHeartbeat:        &t.HeartbeatTimeout,
+		EnvRaftElection:         &t.ElectionTimeout,
+		EnvRaftLeaderLease:      &t.LeaderLeaseTimeout,
+		EnvRaftSnapshotInterval: &t.SnapshotInterval,
+	} {
+		if v, ok := lookup(env); ok && v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", env, err)
+			}
+			*dst = d
+		}
+	}
+	for env, dst := range map[string]*uint64{
+		EnvRaftSnapshotThreshold: &t.SnapshotThreshold,
+		EnvRaftTrailingLogs:      &t.TrailingLogs,
+	} {
+		if v, ok := lookup(env); ok && v != "" {
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return fmt.Errorf("%s: %w", env, err)
+			}
+			*dst = n
+		}
+	}
+	for env, dst := range map[string]*int{
+		EnvRaftMaxAppendEntries: &t.MaxAppendEntries,
+		EnvRaftSnapshotRetain:   &t.SnapshotRetain,
+	} {
+		if v, ok := lookup(env); ok && v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", env, err)
+			}
+			*dst = n
+		}
+	}
+	return nil
+}
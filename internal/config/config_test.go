@@ -1,9 +1,12 @@
 package config
 
 import (
+	"log/slog"
+	"os"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 const (
@@ -85,3 +88,168 @@ func TestLoadPeersLarge(t *testing.T) {
 		t.Fatalf("unexpected peers length: %d", len(cfg.Peers))
 	}
 }
+
+func TestLoadCompress(t *testing.T) {
+	t.Setenv(EnvCompress, "logs/=gzip,=flate")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(cfg.Compress) != 2 || cfg.Compress["logs/"] != "gzip" || cfg.Compress[""] != "flate" {
+		t.Fatalf("unexpected compress rules: %+v", cfg.Compress)
+	}
+	t.Setenv(EnvCompress, "nocodec")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestLoadNonvoter(t *testing.T) {
+	t.Setenv(EnvJoin, "")
+	t.Setenv(EnvNonvoter, joinTrue)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !cfg.Nonvoter || !cfg.Join {
+		t.Fatalf("nonvoter must join: %+v", cfg)
+	}
+	t.Setenv(EnvNonvoter, joinBad)
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestLoadBootstrapExpect(t *testing.T) {
+	t.Setenv(EnvBootstrapExpect, "3")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.BootstrapExpect != 3 {
+		t.Fatalf("unexpected expectation: %d", cfg.BootstrapExpect)
+	}
+	for _, v := range []string{"-1", "three"} {
+		t.Setenv(EnvBootstrapExpect, v)
+		if _, err := Load(); err == nil {
+			t.Fatalf("%s: expected error", v)
+		}
+	}
+}
+
+func TestLoadAutopilot(t *testing.T) {
+	t.Setenv(EnvAutopilot, joinTrue)
+	t.Setenv(EnvAutopilotCleanup, joinTrue)
+	t.Setenv(EnvAutopilotMinQuorum, "5")
+	t.Setenv(EnvAutopilotDead, "2m")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	want := Autopilot{Enabled: true, CleanupDeadServers: true, MinQuorum: 5, DeadServerThreshold: 2 * time.Minute}
+	if cfg.Autopilot != want {
+		t.Fatalf("unexpected autopilot: %+v", cfg.Autopilot)
+	}
+	for env, v := range map[string]string{EnvAutopilotMinQuorum: "0", EnvAutopilotDead: "soon", EnvAutopilot: joinBad} {
+		old := os.Getenv(env)
+		t.Setenv(env, v)
+		if _, err := Load(); err == nil {
+			t.Fatalf("%s=%s: expected error", env, v)
+		}
+		t.Setenv(env, old)
+	}
+}
+
+func TestLoadLogging(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.LogLevel != slog.LevelInfo || cfg.LogFormat != LogFormatText {
+		t.Fatalf("unexpected log defaults: %v %q", cfg.LogLevel, cfg.LogFormat)
+	}
+	t.Setenv(EnvLogLevel, "WARN")
+	t.Setenv(EnvLogFormat, LogFormatJSON)
+	if cfg, err = Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.LogLevel != slog.LevelWarn || cfg.LogFormat != LogFormatJSON || cfg.Values()[EnvLogLevel] != "warn" {
+		t.Fatalf("unexpected logging: %v %q", cfg.LogLevel, cfg.LogFormat)
+	}
+	for env, v := range map[string]string{EnvLogLevel: "loud", EnvLogFormat: "xml"} {
+		old := os.Getenv(env)
+		t.Setenv(env, v)
+		if _, err := Load(); err == nil {
+			t.Fatalf("%s=%s: expected error", env, v)
+		}
+		t.Setenv(env, old)
+	}
+}
+
+func TestLoadTracing(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Tracing() || cfg.TraceSample != DefaultTraceSample {
+		t.Fatalf("unexpected tracing defaults: %+v", cfg)
+	}
+	t.Setenv(EnvTraceOTLP, "http://localhost:4317")
+	t.Setenv(EnvTraceSample, "0.25")
+	if cfg, err = Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !cfg.Tracing() || cfg.TraceSample != 0.25 || cfg.Values()[EnvTraceSample] != "0.25" {
+		t.Fatalf("unexpected tracing: %q %v", cfg.TraceOTLP, cfg.TraceSample)
+	}
+	for env, v := range map[string]string{EnvTraceSample: "2", EnvTraceOTLP: "localhost:4317"} {
+		old := os.Getenv(env)
+		t.Setenv(env, v)
+		if _, err := Load(); err == nil {
+			t.Fatalf("%s=%s: expected error", env, v)
+		}
+		t.Setenv(env, old)
+	}
+}
+
+func TestLoadTuning(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Tuning != DefaultRaftTuning() {
+		t.Fatalf("unexpected default tuning: %+v", cfg.Tuning)
+	}
+	t.Setenv(EnvRaftHeartbeat, "3s")
+	t.Setenv(EnvRaftElection, "5s")
+	t.Setenv(EnvRaftLeaderLease, "2s")
+	t.Setenv(EnvRaftSnapshotRetain, "3")
+	t.Setenv(EnvRaftTrailingLogs, "100")
+	if cfg, err = Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	want := DefaultRaftTuning()
+	want.HeartbeatTimeout = 3 * time.Second
+	want.ElectionTimeout = 5 * time.Second
+	want.LeaderLeaseTimeout = 2 * time.Second
+	want.SnapshotRetain = 3
+	want.TrailingLogs = 100
+	if cfg.Tuning != want {
+		t.Fatalf("unexpected tuning: %+v", cfg.Tuning)
+	}
+	for env, v := range map[string]string{
+		EnvRaftLeaderLease:      "4s",
+		EnvRaftElection:         "1s",
+		EnvRaftHeartbeat:        "1ms",
+		EnvRaftMaxAppendEntries: "2000",
+		EnvRaftSnapshotRetain:   "0",
+		EnvRaftSnapshotInterval: "often",
+	} {
+		old := os.Getenv(env)
+		t.Setenv(env, v)
+		if _, err := Load(); err == nil {
+			t.Fatalf("%s=%s: expected error", env, v)
+		}
+		t.Setenv(env, old)
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import "fmt"
+
+// Change is a setting that differs between two configurations, keyed by
+// its config file key. A file setting whose contents were read again has
+// equal Old and New values.
+type Change struct {
+	Key      string
+	Old, New string
+	// Applied is false for settings that take effect on restart.
+	Applied bool
+}
+
+// Reload returns c with the reloadable settings taken from next, and the
+// settings that differ between the two in the order they are documented.
+// It fails when the reloadable settings of next do not fit the settings
+// of c that need a restart, such as Raft timeouts valid only together.
+func (c Config) Reload(next Config) (Config, []Change, error) {
+	cur, nv := c.Values(), next.Values()
+	merged := make(map[string]string, len(cur))
+	var changes []Change
+	for _, s := range settings {
+		v := cur[s.env]
+		if nv[s.env] != v {
+			changes = append(changes, Change{Key: FileKey(s.env), Old: v, New: nv[s.env], Applied: s.reload})
+			if s.reload {
+				v = nv[s.env]
+			}
+		}
+		if v != "" {
+			merged[s.env] = v
+		}
+	}
+	out, err := load(func(env string) (string, bool) {
+		v, ok := merged[env]
+		return v, ok
+	})
+	if err != nil {
+		return c, nil, err
+	}
+	return out, changes, nil
+}
+
+func (c Change) String() string {
+	state := "needs restart"
+	if c.Applied {
+		state = "applied"
+	}
+	if c.Old == c.New {
+		return fmt.Sprintf("%s: %s reloaded (%s)", c.Key, c.New, state)
+	}
+	return fmt.Sprintf("%s: %q -> %q (%s)", c.Key, c.Old, c.New, state)
+}
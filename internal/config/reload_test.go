@@ -0,0 +1,51 @@
+package config
+
+import (
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	cur, err := Parse("dfs", []string{"-port", "14000", "-auth-admins", "root"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	next, err := Parse("dfs", []string{"-port", "15000", "-auth-admins", "root,ops", "-gc-interval", "1h", "-raft-trailing-logs", "100", "-log-level", "debug"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	got, changes, err := cur.Reload(next)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	want := []Change{
+		{Key: "auth_admins", Old: "root", New: "root,ops", Applied: true},
+		{Key: "port", Old: "14000", New: "15000"},
+		{Key: "gc_interval", Old: DefaultGCInterval.String(), New: "1h0m0s", Applied: true},
+		{Key: "log_level", Old: "info", New: "debug", Applied: true},
+		{Key: "raft_trailing_logs", Old: "10240", New: "100", Applied: true},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("unexpected changes:\n got %+v\nwant %+v", changes, want)
+	}
+	if s := changes[1].String(); s != `port: "14000" -> "15000" (needs restart)` {
+		t.Fatalf("unexpected description %q", s)
+	}
+	if got.Port != 14000 || got.GCInterval != time.Hour || got.Tuning.TrailingLogs != 100 || got.LogLevel != slog.LevelDebug || !reflect.DeepEqual(got.Admins, []string{"root", "ops"}) {
+		t.Fatalf("unexpected reloaded config: %+v", got)
+	}
+	if _, changes, _ := got.Reload(got); len(changes) != 0 {
+		t.Fatalf("unexpected changes reloading the same config: %+v", changes)
+	}
+
+	// A heartbeat below the leader lease, which needs a restart, is refused.
+	next, err = Parse("dfs", []string{"-raft-heartbeat-timeout", "100ms", "-raft-election-timeout", "100ms", "-raft-leader-lease-timeout", "100ms"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if _, _, err := cur.Reload(next); err == nil {
+		t.Fatalf("expected error for a heartbeat below the running leader lease")
+	}
+}
@@ -0,0 +1,294 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvConfig points at an optional JSON config file. The -config flag
+// overrides it.
+const EnvConfig = "DFS_CONFIG"
+
+const (
+	envPrefix  = "DFS_"
+	flagConfig = "config"
+	keySep     = "_"
+	flagSep    = "-"
+)
+
+// setting describes one option. Its environment variable name also
+// derives its config file key and command-line flag, e.g.
+// DFS_RAFT_ELECTION_TIMEOUT is "raft_election_timeout" in the file and
+// -raft-election-timeout on the command line.
+type setting struct {
+	env    string
+	usage  string
+	isBool bool
+	reload bool // applied by a running node on reload
+}
+
+var settings = []setting{
+	{env: EnvID, usage: "node identifier"},
+	{env: EnvRaft, usage: "Raft bind address"},
+	{env: EnvGRPC, usage: "gRPC API bind address"},
+	{env: EnvAdvertiseRaft, usage: "Raft address given to other servers"},
+	{env: EnvAdvertiseGRPC, usage: "gRPC API address given to clients"},
+	{env: EnvData, usage: "data directory for Raft state"},
+	{env: EnvPeers, usage: "comma separated peers as id=address or bare Raft addresses"},
+	{env: EnvJoin, usage: "join an existing cluster instead of bootstrapping", isBool: true},
+	{env: EnvNonvoter, usage: "join as a nonvoting read replica", isBool: true},
+	{env: EnvBootstrapExpect, usage: "servers to wait for before bootstrapping a new cluster"},
+	{env: EnvCompress, usage: "comma separated prefix=codec compression rules"},
+	{env: EnvKeyFile, usage: "key file for encryption at rest"},
+	{env: EnvTLSCert, usage: "node certificate file"},
+	{env: EnvTLSKey, usage: "node private key file"},
+	{env: EnvTLSCA, usage: "CA certificate file"},
+	{env: EnvAuth, usage: "require authentication and ACL checks", isBool: true},
+	{env: EnvAuthTokens, usage: "file of \"token identity\" lines", reload: true},
+	{env: EnvAuthAdmins, usage: "comma separated identities with implicit admin rights", reload: true},
+	{env: EnvFUSEIdentity, usage: "identity FUSE operations run as", reload: true},
+	{env: EnvNodeTokenFile, usage: "file holding the token presented to other servers", reload: true},
+	{env: EnvPort, usage: "port for addresses given without one"},
+	{env: EnvMountPoint, usage: "FUSE mount point"},
+	{env: EnvCacheDir, usage: "cache directory replicated into the store"},
+	{env: EnvCheckInterval, usage: "cache consistency check interval", reload: true},
+	{env: EnvGCInterval, usage: "garbage collection interval", reload: true},
+	{env: EnvKeyInterval, usage: "key rotation check interval", reload: true},
+	{env: EnvShutdownTimeout, usage: "deadline for the orderly shutdown"},
+	{env: EnvMetrics, usage: "HTTP address serving Prometheus metrics at /metrics"},
+	{env: EnvLogLevel, usage: "lowest level logged: debug, info, warn or error", reload: true},
+	{env: EnvLogFormat, usage: "log record format: text or json"},
+	{env: EnvTraceFile, usage: "file spans are appended to as JSON"},
+	{env: EnvTraceOTLP, usage: "OTLP/gRPC collector endpoint spans are sent to, such as http://localhost:4317"},
+	{env: EnvTraceSample, usage: "fraction of new traces sampled, from 0 to 1"},
+	{env: EnvAutopilot, usage: "run autopilot health checks on the leader", isBool: true},
+	{env: EnvAutopilotCleanup, usage: "remove dead voters", isBool: true},
+	{env: EnvAutopilotMinQuorum, usage: "voters never removed below this count"},
+	{env: EnvAutopilotLastContact, usage: "unhealthy when not heard from for longer"},
+	{env: EnvAutopilotMaxTrailing, usage: "unhealthy when further behind the leader"},
+	{env: EnvAutopilotStabilization, usage: "healthy time before a new server is promoted"},
+	{env: EnvAutopilotDead, usage: "unhealthy time after which a voter is dead"},
+	{env: EnvRaftHeartbeat, usage: "Raft heartbeat timeout", reload: true},
+	{env: EnvRaftElection, usage: "Raft election timeout", reload: true},
+	{env: EnvRaftLeaderLease, usage: "Raft leader lease timeout"},
+	{env: EnvRaftSnapshotInterval, usage: "how often Raft checks whether to snapshot", reload: true},
+	{env: EnvRaftSnapshotThreshold, usage: "new log entries that trigger a snapshot", reload: true},
+	{env: EnvRaftTrailingLogs, usage: "log entries kept after a snapshot", reload: true},
+	{env: EnvRaftMaxAppendEntries, usage: "log entries per replication request"},
+	{env: EnvRaftSnapshotRetain, usage: "snapshots kept in the data directory"},
+}
+
+// FileKey returns the config file key of the setting named by env.
+func FileKey(env string) string {
+	return strings.ToLower(strings.TrimPrefix(env, envPrefix))
+}
+
+func flagName(env string) string {
+	return strings.ReplaceAll(FileKey(env), keySep, flagSep)
+}
+
+// Parse builds a Config from command-line flags, environment variables
+// and the config file named by -config or EnvConfig, in that order of
+// precedence, falling back to the defaults. Empty values count as unset.
+func Parse(name string, args []string) (Config, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	given := map[string]string{}
+	for _, s := range settings {
+		fs.Var(&flagValue{env: s.env, given: given, isBool: s.isBool}, flagName(s.env), s.usage+" ($"+s.env+")")
+	}
+	path := fs.String(flagConfig, os.Getenv(EnvConfig), "JSON config file ($"+EnvConfig+")")
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	if fs.NArg() > 0 {
+		return Config{}, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	file := map[string]string{}
+	if *path != "" {
+		var err error
+		if file, err = readFile(*path); err != nil {
+			return Config{}, err
+		}
+	}
+	return load(func(env string) (string, bool) {
+		if v, ok := given[env]; ok && v != "" {
+			return v, true
+		}
+		if v, ok := os.LookupEnv(env); ok && v != "" {
+			return v, true
+		}
+		v, ok := file[env]
+		return v, ok
+	})
+}
+
+// flagValue records a flag under its setting's environment variable name.
+type flagValue struct {
+	env    string
+	given  map[string]string
+	isBool bool
+}
+
+func (f *flagValue) String() string { return "" }
+
+func (f *flagValue) Set(v string) error {
+	f.given[f.env] = v
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool { return f.isBool }
+
+// readFile reads a JSON object of settings keyed by FileKey. Values are
+// strings, numbers, booleans or, for lists, arrays of strings. Unknown
+// keys are rejected.
+func readFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	dec.UseNumber()
+	var raw map[string]any
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	envs := make(map[string]string, len(settings))
+	for _, s := range settings {
+		envs[FileKey(s.env)] = s.env
+	}
+	out := make(map[string]string, len(raw))
+	for key, v := range raw {
+		env, ok := envs[key]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown key %q", path, key)
+		}
+		s, err := fileValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, key, err)
+		}
+		out[env] = s
+	}
+	return out, nil
+}
+
+func fileValue(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case json.Number:
+		return v.String(), nil
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return "", fmt.Errorf("list items must be strings")
+			}
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, string(commaSep)), nil
+	}
+	return "", fmt.Errorf("unsupported value %v", v)
+}
+
+// Values returns the effective settings keyed by environment variable
+// name. Unset optional settings are left out.
+func (c Config) Values() map[string]string {
+	rules := make([]string, 0, len(c.Compress))
+	for prefix, name := range c.Compress {
+		rules = append(rules, prefix+ruleSep+name)
+	}
+	sort.Strings(rules)
+	a, t := c.Autopilot, c.Tuning
+	v := map[string]string{
+		EnvID:                     c.ID,
+		EnvRaft:                   c.Raft,
+		EnvGRPC:                   c.GRPC,
+		EnvAdvertiseRaft:          c.AdvertiseRaft,
+		EnvAdvertiseGRPC:          c.AdvertiseGRPC,
+		EnvData:                   c.Data,
+		EnvPeers:                  strings.Join(c.Peers, string(commaSep)),
+		EnvJoin:                   strconv.FormatBool(c.Join),
+		EnvNonvoter:               strconv.FormatBool(c.Nonvoter),
+		EnvBootstrapExpect:        strconv.Itoa(c.BootstrapExpect),
+		EnvCompress:               strings.Join(rules, string(commaSep)),
+		EnvKeyFile:                c.KeyFile,
+		EnvTLSCert:                c.TLSCert,
+		EnvTLSKey:                 c.TLSKey,
+		EnvTLSCA:                  c.TLSCA,
+		EnvAuth:                   strconv.FormatBool(c.Auth),
+		EnvAuthTokens:             c.AuthTokens,
+		EnvAuthAdmins:             strings.Join(c.Admins, string(commaSep)),
+		EnvFUSEIdentity:           c.FUSEIdentity,
+		EnvNodeTokenFile:          c.NodeTokenFile,
+		EnvPort:                   strconv.Itoa(c.Port),
+		EnvMountPoint:             c.MountPoint,
+		EnvCacheDir:               c.CacheDir,
+		EnvCheckInterval:          c.CheckInterval.String(),
+		EnvGCInterval:             c.GCInterval.String(),
+		EnvKeyInterval:            c.KeyInterval.String(),
+		EnvShutdownTimeout:        c.ShutdownTimeout.String(),
+		EnvMetrics:                c.Metrics,
+		EnvLogLevel:               strings.ToLower(c.LogLevel.String()),
+		EnvLogFormat:              c.LogFormat,
+		EnvTraceFile:              c.TraceFile,
+		EnvTraceOTLP:              c.TraceOTLP,
+		EnvTraceSample:            strconv.FormatFloat(c.TraceSample, 'g', -1, 64),
+		EnvAutopilot:              strconv.FormatBool(a.Enabled),
+		EnvAutopilotCleanup:       strconv.FormatBool(a.CleanupDeadServers),
+		EnvAutopilotMinQuorum:     optional(a.MinQuorum != 0, strconv.Itoa(a.MinQuorum)),
+		EnvAutopilotLastContact:   optionalDuration(a.LastContactThreshold),
+		EnvAutopilotMaxTrailing:   optional(a.MaxTrailingLogs != 0, strconv.FormatUint(a.MaxTrailingLogs, 10)),
+		EnvAutopilotStabilization: optionalDuration(a.StabilizationTime),
+		EnvAutopilotDead:          optionalDuration(a.DeadServerThreshold),
+		EnvRaftHeartbeat:          t.HeartbeatTimeout.String(),
+		EnvRaftElection:           t.ElectionTimeout.String(),
+		EnvRaftLeaderLease:        t.LeaderLeaseTimeout.String(),
+		EnvRaftSnapshotInterval:   t.SnapshotInterval.String(),
+		EnvRaftSnapshotThreshold:  strconv.FormatUint(t.SnapshotThreshold, 10),
+		EnvRaftTrailingLogs:       strconv.FormatUint(t.TrailingLogs, 10),
+		EnvRaftMaxAppendEntries:   strconv.Itoa(t.MaxAppendEntries),
+		EnvRaftSnapshotRetain:     strconv.Itoa(t.SnapshotRetain),
+	}
+	for env, s := range v {
+		if s == "" {
+			delete(v, env)
+		}
+	}
+	return v
+}
+
+func optional(set bool, v string) string {
+	if !set {
+		return ""
+	}
+	return v
+}
+
+func optionalDuration(d time.Duration) string {
+	return optional(d != 0, d.String())
+}
+
+// Print writes the effective settings to w as a JSON config file.
+func (c Config) Print(w io.Writer) error {
+	out := map[string]string{}
+	for env, v := range c.Values() {
+		out[FileKey(env)] = v
+	}
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", b)
+	return err
+}
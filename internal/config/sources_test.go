@@ -0,0 +1,94 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "dfs.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return path
+}
+
+func TestParsePrecedence(t *testing.T) {
+	path := writeConfig(t, `{"id": "file", "data": "/file", "port": 14000, "peers": ["a", "b"], "join": true}`)
+	t.Setenv(EnvID, testID)
+	t.Setenv(EnvData, testData)
+	cfg, err := Parse("dfs", []string{"-config", path, "-data", "/flag", "-raft-election-timeout", "3s"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if cfg.ID != testID || cfg.Data != "/flag" || cfg.Port != 14000 || !cfg.Join {
+		t.Fatalf("unexpected precedence: %+v", cfg)
+	}
+	if !reflect.DeepEqual(cfg.Peers, []string{peerA, peerB}) || cfg.Tuning.ElectionTimeout != 3*time.Second {
+		t.Fatalf("unexpected values: %+v", cfg)
+	}
+	if cfg.MountPoint != DefaultMountPoint || cfg.GCInterval != DefaultGCInterval {
+		t.Fatalf("defaults not kept: %+v", cfg)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown key": `{"bogus": 1}`,
+		"bad value":   `{"port": "none"}`,
+		"bad list":    `{"peers": [1]}`,
+		"not json":    `id = "x"`,
+	} {
+		if _, err := Parse("dfs", []string{"-config", writeConfig(t, content)}); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+	if _, err := Parse("dfs", []string{"-bogus"}); err == nil {
+		t.Fatalf("expected error for unknown flag")
+	}
+	if _, err := Parse("dfs", []string{"extra"}); err == nil {
+		t.Fatalf("expected error for argument")
+	}
+}
+
+func TestPrintRoundTrip(t *testing.T) {
+	cfg, err := Parse("dfs", []string{
+		"-id", testID, "-peers", "a=x:1,b=y:2", "-nonvoter", "-compress", "logs/=gzip,=flate",
+		"-auth-admins", "root,ops", "-check-interval", "30s", "-autopilot", "-autopilot-min-quorum", "5",
+		"-autopilot-max-trailing-logs", "10", "-autopilot-last-contact", "1s", "-autopilot-stabilization", "2s",
+		"-autopilot-dead-threshold", "3m", "-raft-snapshot-retain", "3", "-tls-cert", "c", "-tls-key", "k", "-tls-ca", "ca",
+	})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	values := cfg.Values()
+	unset := map[string]bool{
+		EnvRaft: true, EnvGRPC: true, EnvAdvertiseRaft: true, EnvAdvertiseGRPC: true,
+		EnvKeyFile: true, EnvAuthTokens: true, EnvFUSEIdentity: true, EnvMetrics: true,
+		EnvNodeTokenFile: true, EnvTraceFile: true, EnvTraceOTLP: true,
+	}
+	for _, s := range settings {
+		if _, ok := values[s.env]; ok == unset[s.env] {
+			t.Fatalf("%s: printed %v", s.env, ok)
+		}
+	}
+	if len(values) != len(settings)-len(unset) {
+		t.Fatalf("values without a setting: %v", values)
+	}
+	var buf bytes.Buffer
+	if err := cfg.Print(&buf); err != nil {
+		t.Fatalf("print: %v", err)
+	}
+	again, err := Parse("dfs", []string{"-config", writeConfig(t, buf.String())})
+	if err != nil {
+		t.Fatalf("parse printed config: %v\n%s", err, buf.String())
+	}
+	if !reflect.DeepEqual(cfg, again) {
+		t.Fatalf("round trip changed config:\n%+v\n%+v", cfg, again)
+	}
+}
@@ -0,0 +1,178 @@
+// Package envelope implements AES-GCM envelope encryption for data at rest.
+// Each message is encrypted with a fresh data key which is itself wrapped
+// with a key-encryption key supplied by a KeyProvider.
+package envelope
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+const (
+	// KeySize is the size of key-encryption and data keys (AES-256).
+	KeySize = 32
+
+	nonceSize   = 12
+	tagSize     = 16
+	wrappedSize = KeySize + tagSize
+	maxIDLen    = 255
+)
+
+// magic prefixes every sealed message. It only guards against opening
+// data that was never sealed: plaintext may start with the same bytes, so
+// callers record out of band which data they sealed.
+var magic = []byte("DFE1")
+
+// ErrUnknownKey is returned by a KeyProvider asked for a key it does not
+// hold.
+var ErrUnknownKey = errors.New("envelope: unknown key")
+
+var (
+	errNoKeys    = errors.New("envelope: sealed data but no key provider")
+	errNotSealed = errors.New("envelope: data is not sealed")
+	errShort     = errors.New("envelope: message truncated")
+	errKeySize   = errors.New("envelope: key must be 32 bytes")
+	errIDLength  = errors.New("envelope: key id too long")
+)
+
+// KeyProvider supplies key-encryption keys.
+type KeyProvider interface {
+	// Current returns the id and key new data is sealed with.
+	Current() (string, []byte, error)
+	// Key returns the key with the given id.
+	Key(id string) ([]byte, error)
+}
+
+// Sealer encrypts and decrypts messages. A nil Sealer passes data through
+// unchanged.
+type Sealer struct{ keys KeyProvider }
+
+// New returns a Sealer using keys.
+func New(keys KeyProvider) *Sealer { return &Sealer{keys: keys} }
+
+// Keys returns the provider the Sealer was created with.
+func (s *Sealer) Keys() KeyProvider {
+	if s == nil {
+		return nil
+	}
+	return s.keys
+}
+
+// IsSealed reports whether b looks like a sealed message.
+func IsSealed(b []byte) bool { return bytes.HasPrefix(b, magic) }
+
+// KeyID returns the id of the key b was sealed with.
+func KeyID(b []byte) (string, error) {
+	if !IsSealed(b) {
+		return "", errNotSealed
+	}
+	id, _, err := parseID(b)
+	return id, err
+}
+
+// Seal encrypts plain with a new data key wrapped by the current key.
+//
+// Layout: magic | id length | id | wrap nonce | wrapped data key | nonce | ciphertext.
+func (s *Sealer) Seal(plain []byte) ([]byte, error) {
+	if s == nil {
+		return plain, nil
+	}
+	id, kek, err := s.keys.Current()
+	if err != nil {
+		return nil, err
+	}
+	if len(id) > maxIDLen {
+		return nil, errIDLength
+	}
+	dek := make([]byte, KeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, err
+	}
+	header := make([]byte, 0, len(magic)+1+len(id))
+	header = append(header, magic...)
+	header = append(header, byte(len(id)))
+	header = append(header, id...)
+
+	out := make([]byte, 0, len(header)+2*nonceSize+wrappedSize+len(plain)+tagSize)
+	out = append(out, header...)
+	out, err = seal(kek, out, dek, header)
+	if err != nil {
+		return nil, err
+	}
+	return seal(dek, out, plain, header)
+}
+
+// Open decrypts a message produced by Seal. Unlike Seal it does not pass
+// data through: it fails without a key provider or when b is not a sealed
+// message, so callers only open data they know to be sealed.
+func (s *Sealer) Open(b []byte) ([]byte, error) {
+	if s == nil {
+		return nil, errNoKeys
+	}
+	if !IsSealed(b) {
+		return nil, errNotSealed
+	}
+	id, rest, err := parseID(b)
+	if err != nil {
+		return nil, err
+	}
+	header := b[:len(b)-len(rest)]
+	kek, err := s.keys.Key(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) < nonceSize+wrappedSize+nonceSize+tagSize {
+		return nil, errShort
+	}
+	dek, err := open(kek, rest[:nonceSize+wrappedSize], header)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: unwrap key %q: %w", id, err)
+	}
+	return open(dek, rest[nonceSize+wrappedSize:], header)
+}
+
+func parseID(b []byte) (string, []byte, error) {
+	b = b[len(magic):]
+	if len(b) < 1 || len(b) < 1+int(b[0]) {
+		return "", nil, errShort
+	}
+	n := int(b[0])
+	return string(b[1 : 1+n]), b[1+n:], nil
+}
+
+// seal appends nonce and ciphertext of plain to dst.
+func seal(key, dst, plain, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	dst = append(dst, nonce...)
+	return gcm.Seal(dst, nonce, plain, aad), nil
+}
+
+func open(key, b, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, b[:nonceSize], b[nonceSize:], aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, errKeySize
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,140 @@
+package envelope
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	keyA      = "k1"
+	keyB      = "k2"
+	plaintext = "secret payload"
+	keyName   = "keys"
+)
+
+func newKey(t *testing.T) string {
+	t.Helper()
+	k := make([]byte, KeySize)
+	if _, err := rand.Read(k); err != nil {
+		t.Fatalf("rand: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(k)
+}
+
+func writeKeys(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	var b bytes.Buffer
+	b.WriteString("# test keys\n")
+	for _, l := range lines {
+		b.WriteString(l + "\n")
+	}
+	if err := os.WriteFile(path, b.Bytes(), 0o600); err != nil {
+		t.Fatalf("write keys: %v", err)
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), keyName)
+	writeKeys(t, path, keyA+" "+newKey(t))
+	keys, err := LoadKeyFile(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	s := New(keys)
+	sealed, err := s.Seal([]byte(plaintext))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if !IsSealed(sealed) || bytes.Contains(sealed, []byte(plaintext)) {
+		t.Fatalf("plaintext visible in sealed output")
+	}
+	got, err := s.Open(sealed)
+	if err != nil || string(got) != plaintext {
+		t.Fatalf("open: %v %q", err, got)
+	}
+	if _, err := s.Open([]byte(plaintext)); err == nil {
+		t.Fatalf("opened data that was never sealed")
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, err := s.Open(sealed); err == nil {
+		t.Fatalf("expected tamper detection")
+	}
+	var none *Sealer
+	if _, err := none.Open(sealed); err == nil {
+		t.Fatalf("expected error without keys")
+	}
+}
+
+func TestRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), keyName)
+	writeKeys(t, path, keyA+" "+newKey(t))
+	keys, err := LoadKeyFile(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	s := New(keys)
+	old, _ := s.Seal([]byte(plaintext))
+	b, _ := os.ReadFile(path)
+	writeKeys(t, path, string(bytes.TrimSpace(b[bytes.IndexByte(b, '\n')+1:])), keyB+" "+newKey(t))
+	changed, err := keys.Reload()
+	if err != nil || !changed {
+		t.Fatalf("reload: changed=%v err=%v", changed, err)
+	}
+	if got, err := s.Open(old); err != nil || string(got) != plaintext {
+		t.Fatalf("old key no longer opens data: %v", err)
+	}
+	if id, _, _ := keys.Current(); id != keyB {
+		t.Fatalf("current key %q", id)
+	}
+}
+
+func TestKeyReadsAppendedKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), keyName)
+	lineA := keyA + " " + newKey(t)
+	writeKeys(t, path, lineA)
+	keys, err := LoadKeyFile(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	other := filepath.Join(t.TempDir(), keyName)
+	writeKeys(t, other, lineA, keyB+" "+newKey(t))
+	peer, err := LoadKeyFile(other)
+	if err != nil {
+		t.Fatalf("load peer: %v", err)
+	}
+	sealed, _ := New(peer).Seal([]byte(plaintext))
+	if id, err := KeyID(sealed); err != nil || id != keyB {
+		t.Fatalf("key id %q: %v", id, err)
+	}
+	if _, err := New(keys).Open(sealed); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey, got %v", err)
+	}
+	b, _ := os.ReadFile(other)
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		t.Fatalf("write keys: %v", err)
+	}
+	if got, err := New(keys).Open(sealed); err != nil || string(got) != plaintext {
+		t.Fatalf("appended key not read: %q %v", got, err)
+	}
+	if id, _, _ := keys.Current(); id != keyA {
+		t.Fatalf("current key changed without a reload: %q", id)
+	}
+	if ids := keys.IDs(); len(ids) != 2 || ids[0] != keyA || ids[1] != keyB {
+		t.Fatalf("unexpected ids %v", ids)
+	}
+}
+
+func TestLoadKeyFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), keyName)
+	for _, content := range []string{"", keyA, keyA + " notbase64!", keyA + " " + base64.StdEncoding.EncodeToString([]byte("short"))} {
+		writeKeys(t, path, content)
+		if _, err := LoadKeyFile(path); err == nil {
+			t.Fatalf("expected error for %q", content)
+		}
+	}
+}
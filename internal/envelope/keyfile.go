@@ -0,0 +1,129 @@
+package envelope
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const commentPrefix = "#"
+
+var errNoCurrent = errors.New("envelope: key file has no keys")
+
+// FileKeys is a KeyProvider backed by a local key file. Each non-empty line
+// holds a key id and a base64 encoded 32 byte key separated by whitespace.
+// The last key is current; earlier keys are kept to decrypt old data.
+// Lines starting with # are ignored.
+type FileKeys struct {
+	path string
+
+	mu      sync.RWMutex
+	keys    map[string][]byte
+	current string
+}
+
+// LoadKeyFile reads keys from path.
+func LoadKeyFile(path string) (*FileKeys, error) {
+	k := &FileKeys{path: path}
+	if _, err := k.Reload(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Reload re-reads the key file and reports whether the current key changed.
+// Appending a key to the file and reloading rotates to it.
+func (k *FileKeys) Reload() (bool, error) {
+	keys, current, err := k.read()
+	if err != nil {
+		return false, err
+	}
+	k.mu.Lock()
+	changed := k.current != current
+	k.keys = keys
+	k.current = current
+	k.mu.Unlock()
+	return changed, nil
+}
+
+// read parses the key file and returns its keys and the id of the last.
+func (k *FileKeys) read() (map[string][]byte, string, error) {
+	b, err := os.ReadFile(k.path)
+	if err != nil {
+		return nil, "", err
+	}
+	keys := make(map[string][]byte)
+	var current string
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, commentPrefix) {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, "", fmt.Errorf("envelope: %s:%d: want \"id key\"", k.path, line)
+		}
+		key, err := base64.StdEncoding.DecodeString(fields[1])
+		if err != nil {
+			return nil, "", fmt.Errorf("envelope: %s:%d: %w", k.path, line, err)
+		}
+		if len(key) != KeySize {
+			return nil, "", fmt.Errorf("envelope: %s:%d: %w", k.path, line, errKeySize)
+		}
+		if len(fields[0]) > maxIDLen {
+			return nil, "", errIDLength
+		}
+		keys[fields[0]] = key
+		current = fields[0]
+	}
+	if current == "" {
+		return nil, "", errNoCurrent
+	}
+	return keys, current, nil
+}
+
+// Current implements KeyProvider.
+func (k *FileKeys) Current() (string, []byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current, k.keys[k.current], nil
+}
+
+// Key implements KeyProvider. An id that is not loaded makes it read the
+// file again, so data sealed with a key appended since the last Reload
+// can be opened. Only the keys are taken from the file: the current key
+// still changes on Reload alone.
+func (k *FileKeys) Key(id string) ([]byte, error) {
+	k.mu.RLock()
+	key, ok := k.keys[id]
+	k.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+	keys, _, err := k.read()
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %v", ErrUnknownKey, id, err)
+	}
+	if key, ok = keys[id]; !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+	k.mu.Lock()
+	maps.Copy(k.keys, keys)
+	k.mu.Unlock()
+	return key, nil
+}
+
+// IDs returns the sorted ids of the loaded keys.
+func (k *FileKeys) IDs() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return slices.Sorted(maps.Keys(k.keys))
+}
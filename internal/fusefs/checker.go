@@ -9,7 +9,7 @@ import (
 	"strconv"
 	"time"
 
-	"dfs"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -25,12 +25,20 @@ func Check(ctx context.Context, cacheDir string, interval time.Duration) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			scan(cacheDir)
+			scan(ctx, cacheDir)
 		}
 	}
 }
 
-func scan(cacheDir string) {
+// scan validates every cached file once. The scan is traced in a span
+// counting the files checked, removed and refreshed.
+func scan(ctx context.Context, cacheDir string) {
+	ctx, span := tracer.Start(ctx, spanScan)
+	var files, removed, refreshed int
+	defer func() {
+		span.SetAttributes(attribute.Int(attrFiles, files), attribute.Int(attrRemoved, removed), attribute.Int(attrRefreshed, refreshed))
+		span.End()
+	}()
 	filepath.WalkDir(cacheDir, func(p string, d fs.DirEntry, err error) error {
 		if err != nil || d.IsDir() || filepath.Ext(p) == verSuffix {
 			return nil
@@ -39,10 +47,14 @@ func scan(cacheDir string) {
 		if err != nil {
 			return nil
 		}
-		meta, err := dfs.GetMetadata(rel)
+		files++
+		meta, err := metadata(ctx, rel)
 		if err != nil || meta.Deleted {
 			os.Remove(p)
 			os.Remove(p + verSuffix)
+			removed++
+			checkRepairs.WithLabelValues(repairRemove).Inc()
+			logger().DebugContext(ctx, "removed cached file", keyPath, rel)
 			return nil
 		}
 		data, err := os.ReadFile(p)
@@ -56,11 +68,14 @@ func scan(cacheDir string) {
 		v, _ := strconv.ParseUint(string(vb), 10, 64)
 		h := sha256.Sum256(data)
 		if v != meta.Version || h != meta.Hash {
-			if data, err = dfs.GetFile(rel); err != nil {
+			if data, err = fetch(ctx, rel); err != nil {
 				return nil
 			}
 			_ = os.WriteFile(p, data, scanPerm)
 			_ = os.WriteFile(p+verSuffix, []byte(strconv.FormatUint(meta.Version, 10)), scanPerm)
+			refreshed++
+			checkRepairs.WithLabelValues(repairRefresh).Inc()
+			logger().DebugContext(ctx, "refreshed stale cached file", keyPath, rel, "version", meta.Version)
 		}
 		return nil
 	})
@@ -40,7 +40,7 @@ func TestScanUpdates(t *testing.T) {
 	if err := os.WriteFile(filepath.Join(dir, fileName+verSuffix), []byte("1"), 0o644); err != nil {
 		t.Fatalf("write ver: %v", err)
 	}
-	scan(dir)
+	scan(t.Context(), dir)
 	b, err := os.ReadFile(filepath.Join(dir, fileName))
 	if err != nil || string(b) != newData {
 		t.Fatalf("unexpected data %q err %v", b, err)
@@ -62,7 +62,7 @@ func TestScanDeletes(t *testing.T) {
 	if err := os.WriteFile(f+verSuffix, []byte("1"), 0o644); err != nil {
 		t.Fatalf("write ver: %v", err)
 	}
-	scan(dir)
+	scan(t.Context(), dir)
 	if _, err := os.Stat(f); !errors.Is(err, os.ErrNotExist) {
 		t.Fatalf("file still exists")
 	}
@@ -110,6 +110,6 @@ func BenchmarkScan(b *testing.B) {
 	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		scan(dir)
+		scan(b.Context(), dir)
 	}
 }
@@ -2,23 +2,27 @@ package fusefs
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strconv"
 	"sync"
+	"sync/atomic"
 
 	"bazil.org/fuse"
 	bazilfs "bazil.org/fuse/fs"
 	"github.com/fsnotify/fsnotify"
+	"go.opentelemetry.io/otel/attribute"
 
 	"dfs"
+	"dfs/internal/logging"
 )
 
 var (
 	mountFn   = fuse.Mount
+	unmountFn = fuse.Unmount
 	serveFn   = bazilfs.Serve
-	putFileFn = dfs.PutFile
+	putFileFn = dfs.PutFileContext
 	watchFn   = func() (watcher, error) {
 		w, err := fsnotify.NewWatcher()
 		return &fsWatcher{Watcher: w}, err
@@ -27,6 +31,25 @@ var (
 
 const verSuffix = ".ver"
 
+const (
+	componentFUSE = "fusefs"
+	keyPath       = "path"
+)
+
+// loggerPtr is the logger set by SetLogger.
+var loggerPtr atomic.Pointer[slog.Logger]
+
+// SetLogger makes the filesystem, cache watcher and consistency checker
+// log to l instead of slog.Default.
+func SetLogger(l *slog.Logger) { loggerPtr.Store(logging.Component(l, componentFUSE)) }
+
+func logger() *slog.Logger {
+	if l := loggerPtr.Load(); l != nil {
+		return l
+	}
+	return logging.Component(slog.Default(), componentFUSE)
+}
+
 type watcher interface {
 	Add(string) error
 	Close() error
@@ -64,9 +87,12 @@ func (f *FS) Root() (bazilfs.Node, error) {
 }
 
 // ensure returns file data for the given path, loading it from the cache
-// or DFS as needed.
-func (f *FS) ensure(path string) ([]byte, error) {
-	meta, err := dfs.GetMetadata(path)
+// or DFS as needed. The metadata lookup, disk cache read and fetch are
+// traced as children of a span in ctx.
+func (f *FS) ensure(ctx context.Context, path string) (_ []byte, err error) {
+	ctx, span := tracer.Start(ctx, spanEnsure)
+	defer func() { endSpan(span, err) }()
+	meta, err := metadata(ctx, path)
 	if err != nil {
 		f.mu.Lock()
 		delete(f.mem, path)
@@ -80,24 +106,24 @@ func (f *FS) ensure(path string) ([]byte, error) {
 	ce, ok := f.mem[path]
 	f.mu.RUnlock()
 	if ok && ce.version == meta.Version {
+		cacheHits.WithLabelValues(tierMemory).Inc()
+		span.SetAttributes(attribute.String(attrCache, tierMemory))
 		return ce.data, nil
 	}
 	diskPath := filepath.Join(f.cacheDir, path)
 	verPath := diskPath + verSuffix
-	if data, err := os.ReadFile(diskPath); err == nil {
-		if vb, err := os.ReadFile(verPath); err == nil {
-			if v, err := strconv.ParseUint(string(vb), 10, 64); err == nil && v == meta.Version {
-				f.mu.Lock()
-				f.mem[path] = cacheEntry{data: data, version: v}
-				f.mu.Unlock()
-				return data, nil
-			}
-		}
-		os.Remove(diskPath)
-		os.Remove(verPath)
+	if data, ok := readDisk(ctx, path, diskPath, meta.Version); ok {
+		f.mu.Lock()
+		f.mem[path] = cacheEntry{data: data, version: meta.Version}
+		f.mu.Unlock()
+		cacheHits.WithLabelValues(tierDisk).Inc()
+		span.SetAttributes(attribute.String(attrCache, tierDisk))
+		return data, nil
 	}
-	log.Printf("fetching %s from DFS", path)
-	data, err := dfs.GetFile(path)
+	logger().DebugContext(ctx, "cache miss, fetching from the store", keyPath, path)
+	cacheMisses.Inc()
+	span.SetAttributes(attribute.String(attrCache, tierMiss))
+	data, err := fetch(ctx, path)
 	if err != nil {
 		return nil, err
 	}
@@ -114,6 +140,26 @@ func (f *FS) ensure(path string) ([]byte, error) {
 	return data, nil
 }
 
+// readDisk returns the cached copy of path at diskPath if it holds
+// version, removing a stale copy.
+func readDisk(ctx context.Context, path, diskPath string, version uint64) ([]byte, bool) {
+	_, span := tracer.Start(ctx, spanDisk)
+	defer span.End()
+	data, err := os.ReadFile(diskPath)
+	if err != nil {
+		return nil, false
+	}
+	if vb, err := os.ReadFile(diskPath + verSuffix); err == nil {
+		if v, err := strconv.ParseUint(string(vb), 10, 64); err == nil && v == version {
+			span.SetAttributes(attribute.Int(attrSize, len(data)))
+			return data, true
+		}
+	}
+	os.Remove(diskPath)
+	os.Remove(diskPath + verSuffix)
+	return nil, false
+}
+
 // Dir represents a directory.
 type Dir struct {
 	fs   *FS
@@ -176,7 +222,7 @@ type File struct {
 
 // Attr sets attributes for the file.
 func (f *File) Attr(ctx context.Context, a *fuse.Attr) error {
-	data, err := f.fs.ensure(f.path)
+	data, err := f.fs.ensure(ctx, f.path)
 	if err != nil {
 		return err
 	}
@@ -187,7 +233,7 @@ func (f *File) Attr(ctx context.Context, a *fuse.Attr) error {
 
 // ReadAll reads the file data.
 func (f *File) ReadAll(ctx context.Context) ([]byte, error) {
-	return f.fs.ensure(f.path)
+	return f.fs.ensure(ctx, f.path)
 }
 
 // Mount mounts the filesystem at the given mount point.
@@ -202,12 +248,27 @@ func Mount(mountPoint, cacheDir string) error {
 	}
 	go func() {
 		if err := serveFn(c, fs); err != nil {
-			log.Printf("serve: %v", err)
+			logger().Error("serve", logging.Err(err))
 		}
 	}()
 	return nil
 }
 
+// Unmount unmounts the filesystem at the given mount point, which ends
+// the serve loop started by Mount.
+func Unmount(mountPoint string) error {
+	return unmountFn(mountPoint)
+}
+
+// upload replicates a file written to the cache in a span of its own.
+func upload(ctx context.Context, path string, data []byte) error {
+	ctx, span := tracer.Start(ctx, spanUpload)
+	span.SetAttributes(attribute.Int(attrSize, len(data)))
+	err := putFileFn(ctx, path, data)
+	endSpan(span, err)
+	return err
+}
+
 // Watch monitors cache directory changes and replicates new or modified files
 // into the DFS. The watch runs until ctx is canceled.
 func Watch(ctx context.Context, cacheDir string) error {
@@ -218,7 +279,7 @@ func Watch(ctx context.Context, cacheDir string) error {
 	defer watcher.Close()
 	addDir := func(p string) {
 		if err := watcher.Add(p); err != nil {
-			log.Printf("watch add: %v", err)
+			logger().Warn("watch directory", keyPath, p, logging.Err(err))
 		}
 	}
 	filepath.WalkDir(cacheDir, func(p string, d os.DirEntry, err error) error {
@@ -235,6 +296,7 @@ func Watch(ctx context.Context, cacheDir string) error {
 			if !ok {
 				return nil
 			}
+			countEvent(ev)
 			if ev.Op&(fsnotify.Create|fsnotify.Write) != 0 {
 				if fi, err := os.Stat(ev.Name); err == nil {
 					if fi.IsDir() {
@@ -243,7 +305,7 @@ func Watch(ctx context.Context, cacheDir string) error {
 						rel, err := filepath.Rel(cacheDir, ev.Name)
 						if err == nil {
 							if data, err := os.ReadFile(ev.Name); err == nil {
-								if err := putFileFn(rel, data); err != nil {
+								if err := upload(ctx, rel, data); err != nil {
 									return err
 								}
 							}
@@ -255,7 +317,7 @@ func Watch(ctx context.Context, cacheDir string) error {
 			if !ok {
 				return nil
 			}
-			log.Printf("watcher error: %v", err)
+			logger().Warn("watcher", logging.Err(err))
 		}
 	}
 }
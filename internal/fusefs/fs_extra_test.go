@@ -1,8 +1,11 @@
 package fusefs
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sync"
@@ -14,6 +17,7 @@ import (
 	"github.com/fsnotify/fsnotify"
 
 	"dfs"
+	"dfs/internal/logging"
 	"dfs/internal/metastore"
 	"dfs/internal/node"
 )
@@ -45,7 +49,7 @@ func TestFSEnsureErrorPaths(t *testing.T) {
 	dir := t.TempDir()
 	fs := New(dir)
 	dfs.SetNode(nil)
-	if _, err := fs.ensure("missing"); err == nil {
+	if _, err := fs.ensure(t.Context(), "missing"); err == nil {
 		t.Fatalf("expected error")
 	}
 	nd := node.NewInmem()
@@ -55,7 +59,7 @@ func TestFSEnsureErrorPaths(t *testing.T) {
 	if err := os.WriteFile(filepath.Join(dir, "a"), []byte(dataValue), 0o644); err != nil {
 		t.Fatalf("prep: %v", err)
 	}
-	if _, err := fs.ensure("a/b"); err != nil {
+	if _, err := fs.ensure(t.Context(), "a/b"); err != nil {
 		t.Fatalf("ensure: %v", err)
 	}
 	time.Sleep(time.Duration(waitMS) * time.Millisecond)
@@ -74,10 +78,10 @@ func TestDirAndFileErrorPaths(t *testing.T) {
 	}
 	dfs.SetNode(nil)
 	f := &File{fs: fs, path: "nope"}
-	if err := f.Attr(nil, &a); err == nil {
+	if err := f.Attr(t.Context(), &a); err == nil {
 		t.Fatalf("expected attr error")
 	}
-	if _, err := f.ReadAll(nil); err == nil {
+	if _, err := f.ReadAll(t.Context()); err == nil {
 		t.Fatalf("expected readall error")
 	}
 }
@@ -128,6 +132,15 @@ func TestMountVariants(t *testing.T) {
 		}
 		time.Sleep(time.Duration(waitMS) * time.Millisecond)
 	})
+	t.Run("unmount", func(t *testing.T) {
+		old := unmountFn
+		var got string
+		unmountFn = func(dir string) error { got = dir; return nil }
+		defer func() { unmountFn = old }()
+		if err := Unmount("/mnt/x"); err != nil || got != "/mnt/x" {
+			t.Fatalf("unmount %q: %v", got, err)
+		}
+	})
 }
 
 func TestWatchNewWatcherError(t *testing.T) {
@@ -146,7 +159,7 @@ func TestWatchFlows(t *testing.T) {
 	oldW, oldP := watchFn, putFileFn
 	watchFn = func() (watcher, error) { return fw, nil }
 	putErr := errors.New("put")
-	putFileFn = func(string, []byte) error { return putErr }
+	putFileFn = func(context.Context, string, []byte) error { return putErr }
 	defer func() { watchFn, putFileFn = oldW, oldP }()
 	ctx, cancel := context.WithCancel(context.Background())
 	done := make(chan error)
@@ -203,3 +216,22 @@ func TestWatchErrorsChannelClosed(t *testing.T) {
 		t.Fatalf("watch: %v", err)
 	}
 }
+
+func TestSetLogger(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(logging.New(&buf, true, slog.LevelDebug))
+	defer loggerPtr.Store(nil)
+	dfs.SetNode(node.NewInmem())
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, dfsFile), []byte(dataValue), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	scan(t.Context(), dir)
+	var r map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatalf("decode %q: %v", buf.String(), err)
+	}
+	if r[logging.KeyComponent] != componentFUSE || r[keyPath] != dfsFile || r["level"] != "DEBUG" {
+		t.Fatalf("unexpected record: %v", r)
+	}
+}
@@ -31,7 +31,7 @@ func TestFSEnsureAndNodes(t *testing.T) {
 	fs.mu.Lock()
 	fs.mem[cacheFile] = cacheEntry{data: []byte(dataValue), version: 1}
 	fs.mu.Unlock()
-	if v, err := fs.ensure(cacheFile); err != nil || string(v) != dataValue {
+	if v, err := fs.ensure(t.Context(), cacheFile); err != nil || string(v) != dataValue {
 		t.Fatalf("mem ensure: %v v=%q", err, v)
 	}
 
@@ -44,7 +44,7 @@ func TestFSEnsureAndNodes(t *testing.T) {
 	if err := os.WriteFile(filepath.Join(dir, cacheFile+verSuffix), []byte("1"), 0o644); err != nil {
 		t.Fatalf("write ver: %v", err)
 	}
-	if v, err := fs.ensure(cacheFile); err != nil || string(v) != dataValue {
+	if v, err := fs.ensure(t.Context(), cacheFile); err != nil || string(v) != dataValue {
 		t.Fatalf("disk ensure: %v v=%q", err, v)
 	}
 
@@ -52,7 +52,7 @@ func TestFSEnsureAndNodes(t *testing.T) {
 		t.Fatalf("put: %v", err)
 	}
 	nd.Meta.Sync(&metastore.Entry{Path: dfsFile, Version: 1})
-	if v, err := fs.ensure(dfsFile); err != nil || string(v) != dataValue {
+	if v, err := fs.ensure(t.Context(), dfsFile); err != nil || string(v) != dataValue {
 		t.Fatalf("dfs ensure: %v v=%q", err, v)
 	}
 	time.Sleep(time.Duration(waitMS) * time.Millisecond)
@@ -83,10 +83,10 @@ func TestFSEnsureAndNodes(t *testing.T) {
 
 	f := &File{fs: fs, path: dfsFile}
 	var attr fuse.Attr
-	if err := f.Attr(nil, &attr); err != nil || attr.Size == 0 {
+	if err := f.Attr(t.Context(), &attr); err != nil || attr.Size == 0 {
 		t.Fatalf("attr: %v size=%d", err, attr.Size)
 	}
-	if v, err := f.ReadAll(nil); err != nil || string(v) != dataValue {
+	if v, err := f.ReadAll(t.Context()); err != nil || string(v) != dataValue {
 		t.Fatalf("readall: %v v=%q", err, v)
 	}
 	dfs.SetNode(nil)
@@ -101,7 +101,7 @@ func TestEnsureVersionInvalidates(t *testing.T) {
 		t.Fatalf("put1: %v", err)
 	}
 	nd.Meta.Sync(&metastore.Entry{Path: dfsFile, Version: 1})
-	if v, err := fs.ensure(dfsFile); err != nil || string(v) != "v1" {
+	if v, err := fs.ensure(t.Context(), dfsFile); err != nil || string(v) != "v1" {
 		t.Fatalf("first ensure: %v v=%q", err, v)
 	}
 	time.Sleep(time.Duration(waitMS) * time.Millisecond)
@@ -110,7 +110,7 @@ func TestEnsureVersionInvalidates(t *testing.T) {
 		t.Fatalf("put2: %v", err)
 	}
 	nd.Meta.Sync(&metastore.Entry{Path: dfsFile, Version: 2})
-	if v, err := fs.ensure(dfsFile); err != nil || string(v) != "v2" {
+	if v, err := fs.ensure(t.Context(), dfsFile); err != nil || string(v) != "v2" {
 		t.Fatalf("second ensure: %v v=%q", err, v)
 	}
 	time.Sleep(time.Duration(waitMS) * time.Millisecond)
@@ -0,0 +1,75 @@
+package fusefs
+
+import (
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Cache tiers and checker actions used as metric labels.
+const (
+	tierMemory    = "memory"
+	tierDisk      = "disk"
+	repairRefresh = "refresh"
+	repairRemove  = "remove"
+)
+
+// watchOps are the watcher operations counted separately.
+var watchOps = []fsnotify.Op{fsnotify.Create, fsnotify.Write, fsnotify.Remove, fsnotify.Rename, fsnotify.Chmod}
+
+var (
+	cacheHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "dfs",
+		Subsystem: "fuse",
+		Name:      "cache_hits_total",
+		Help:      "File reads served from the cache, by tier.",
+	}, []string{"tier"})
+	cacheMisses = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "dfs",
+		Subsystem: "fuse",
+		Name:      "cache_misses_total",
+		Help:      "File reads fetched from the store.",
+	})
+	watchEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "dfs",
+		Subsystem: "fuse",
+		Name:      "watch_events_total",
+		Help:      "Cache directory events seen by the watcher, by operation.",
+	}, []string{"op"})
+	checkRepairs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "dfs",
+		Subsystem: "fuse",
+		Name:      "check_repairs_total",
+		Help:      "Cached files the consistency checker refreshed or removed.",
+	}, []string{"action"})
+)
+
+// countEvent counts ev once for each operation it carries.
+func countEvent(ev fsnotify.Event) {
+	for _, op := range watchOps {
+		if ev.Has(op) {
+			watchEvents.WithLabelValues(strings.ToLower(op.String())).Inc()
+		}
+	}
+}
+
+// Collector returns a Prometheus collector for the cache hit rate, the
+// watcher events and the checker repairs of this process.
+func Collector() prometheus.Collector {
+	return collectors{cacheHits, cacheMisses, watchEvents, checkRepairs}
+}
+
+type collectors []prometheus.Collector
+
+func (cs collectors) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range cs {
+		c.Describe(ch)
+	}
+}
+
+func (cs collectors) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range cs {
+		c.Collect(ch)
+	}
+}
@@ -0,0 +1,61 @@
+package fusefs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"dfs"
+	"dfs/internal/metastore"
+	"dfs/internal/node"
+)
+
+// delta returns a function reporting how much c grew since the call.
+func delta(c prometheus.Collector) func() float64 {
+	before := testutil.ToFloat64(c)
+	return func() float64 { return testutil.ToFloat64(c) - before }
+}
+
+func TestMetrics(t *testing.T) {
+	dir := t.TempDir()
+	fs := New(dir)
+	nd := node.NewInmem()
+	dfs.SetNode(nd)
+	nd.Put(dfsFile, []byte(dataValue))
+	nd.Meta.Sync(&metastore.Entry{Path: dfsFile, Version: 1})
+	mem, disk, miss := delta(cacheHits.WithLabelValues(tierMemory)), delta(cacheHits.WithLabelValues(tierDisk)), delta(cacheMisses)
+	fs.ensure(t.Context(), dfsFile)
+	fs.ensure(t.Context(), dfsFile)
+	os.WriteFile(filepath.Join(dir, cacheFile), []byte(dataValue), 0o644)
+	os.WriteFile(filepath.Join(dir, cacheFile+verSuffix), []byte("1"), 0o644)
+	nd.Meta.Sync(&metastore.Entry{Path: cacheFile, Version: 1})
+	fs.ensure(t.Context(), cacheFile)
+	if mem() != 1 || disk() != 1 || miss() != 1 {
+		t.Fatalf("hits %v memory, %v disk, %v misses", mem(), disk(), miss())
+	}
+
+	create, write := delta(watchEvents.WithLabelValues("create")), delta(watchEvents.WithLabelValues("write"))
+	countEvent(fsnotify.Event{Op: fsnotify.Create | fsnotify.Write})
+	countEvent(fsnotify.Event{Op: fsnotify.Write})
+	if create() != 1 || write() != 2 {
+		t.Fatalf("events: %v create, %v write", create(), write())
+	}
+
+	prepNode()
+	dir = t.TempDir()
+	os.WriteFile(filepath.Join(dir, fileName), []byte(oldData), 0o644)
+	os.WriteFile(filepath.Join(dir, "gone"), []byte(oldData), 0o644)
+	refresh, remove := delta(checkRepairs.WithLabelValues(repairRefresh)), delta(checkRepairs.WithLabelValues(repairRemove))
+	scan(t.Context(), dir)
+	scan(t.Context(), dir)
+	if refresh() != 1 || remove() != 1 {
+		t.Fatalf("repairs: %v refreshed, %v removed", refresh(), remove())
+	}
+	if problems, err := testutil.CollectAndLint(Collector()); err != nil || len(problems) > 0 {
+		t.Fatalf("lint: %v %v", problems, err)
+	}
+}
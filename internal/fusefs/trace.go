@@ -0,0 +1,69 @@
+package fusefs
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"dfs"
+	"dfs/internal/metastore"
+)
+
+// tracer starts the filesystem's spans. It follows the global tracer
+// provider, so spans are dropped until the program installs one.
+var tracer = otel.Tracer("dfs/internal/fusefs")
+
+const (
+	spanEnsure   = "fusefs.ensure"
+	spanMetadata = "fusefs.metadata"
+	spanDisk     = "fusefs.disk"
+	spanFetch    = "fusefs.fetch"
+	spanUpload   = "fusefs.upload"
+	spanScan     = "fusefs.scan"
+)
+
+// Span attribute keys.
+const (
+	attrSize      = "fusefs.size"
+	attrCache     = "fusefs.cache"
+	attrFiles     = "fusefs.files"
+	attrRemoved   = "fusefs.removed"
+	attrRefreshed = "fusefs.refreshed"
+)
+
+// tierMiss is the cache attribute of reads fetched from the store.
+const tierMiss = "miss"
+
+// endSpan ends span, marking it failed with err when set. Missing files
+// are an expected outcome and only recorded.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		if !errors.Is(err, os.ErrNotExist) {
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
+
+// metadata looks up the metadata of path in a child span of ctx.
+func metadata(ctx context.Context, path string) (metastore.Entry, error) {
+	_, span := tracer.Start(ctx, spanMetadata)
+	meta, err := dfs.GetMetadata(path)
+	endSpan(span, err)
+	return meta, err
+}
+
+// fetch reads path from the store in a child span of ctx.
+func fetch(ctx context.Context, path string) ([]byte, error) {
+	_, span := tracer.Start(ctx, spanFetch)
+	data, err := dfs.GetFile(path)
+	span.SetAttributes(attribute.Int(attrSize, len(data)))
+	endSpan(span, err)
+	return data, err
+}
@@ -0,0 +1,115 @@
+package fusefs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	recorder     = tracetest.NewSpanRecorder()
+	recorderOnce sync.Once
+)
+
+// startTrace returns a context with a new root span. Tracers bind to the
+// first global provider, so all tests share one recorder.
+func startTrace(t *testing.T) (context.Context, trace.Span) {
+	t.Helper()
+	recorderOnce.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	})
+	return otel.Tracer("test").Start(context.Background(), t.Name())
+}
+
+// children returns the ended spans whose parent is span, by name.
+func children(span trace.Span) map[string][]sdktrace.ReadOnlySpan {
+	out := map[string][]sdktrace.ReadOnlySpan{}
+	for _, s := range recorder.Ended() {
+		if s.Parent().SpanID() == span.SpanContext().SpanID() {
+			out[s.Name()] = append(out[s.Name()], s)
+		}
+	}
+	return out
+}
+
+// only returns the single span named name in spans.
+func only(t *testing.T, spans map[string][]sdktrace.ReadOnlySpan, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	if len(spans[name]) != 1 {
+		t.Fatalf("%s: %d spans in %v", name, len(spans[name]), spans)
+	}
+	return spans[name][0]
+}
+
+func spanAttr(s sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, kv := range s.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+// asSpan returns a handle on a recorded span for use with children.
+func asSpan(s sdktrace.ReadOnlySpan) trace.Span {
+	return trace.SpanFromContext(trace.ContextWithSpanContext(context.Background(), s.SpanContext()))
+}
+
+func TestTrace(t *testing.T) {
+	prepNode()
+	dir := t.TempDir()
+	stale := filepath.Join(dir, fileName)
+	os.WriteFile(stale, []byte(oldData), 0o644)
+	os.WriteFile(stale+verSuffix, []byte("1"), 0o644)
+	fs := New(dir)
+
+	ctx, root := startTrace(t)
+	scan(ctx, dir)
+	if _, err := fs.ensure(ctx, fileName); err != nil {
+		t.Fatalf("ensure: %v", err)
+	}
+	if _, err := fs.ensure(ctx, "missing"); err == nil {
+		t.Fatalf("expected missing file")
+	}
+	if err := upload(ctx, "up", []byte(newData)); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	root.End()
+	spans := children(root)
+
+	sc := only(t, spans, spanScan)
+	if spanAttr(sc, attrFiles).AsInt64() != 1 || spanAttr(sc, attrRefreshed).AsInt64() != 1 || spanAttr(sc, attrRemoved).AsInt64() != 0 {
+		t.Fatalf("unexpected scan attributes: %v", sc.Attributes())
+	}
+	scanned := children(asSpan(sc))
+	only(t, scanned, spanMetadata)
+	only(t, scanned, spanFetch)
+
+	if len(spans[spanEnsure]) != 2 {
+		t.Fatalf("ensure spans: %v", spans[spanEnsure])
+	}
+	hit, missing := spans[spanEnsure][0], spans[spanEnsure][1]
+	if spanAttr(hit, attrCache).AsString() != tierDisk {
+		t.Fatalf("unexpected ensure attributes: %v", hit.Attributes())
+	}
+	read := children(asSpan(hit))
+	only(t, read, spanMetadata)
+	only(t, read, spanDisk)
+	if missing.Status().Code == codes.Error || len(missing.Events()) != 1 {
+		t.Fatalf("missing file: status %v, events %v", missing.Status(), missing.Events())
+	}
+
+	up := only(t, spans, spanUpload)
+	if spanAttr(up, attrSize).AsInt64() != int64(len(newData)) || len(children(asSpan(up))) == 0 {
+		t.Fatalf("unexpected upload span: %v", up.Attributes())
+	}
+}
@@ -0,0 +1,37 @@
+package join
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+
+	"dfs/internal/node"
+	pb "dfs/proto"
+)
+
+var errNoLeader = errors.New("no leader")
+
+// RegisterAPI records addr as the gRPC address of n in the cluster unless
+// it already is. A follower asks the leader over its Raft address, which
+// serves gRPC between servers whether or not the API has a listener of
+// its own.
+func RegisterAPI(ctx context.Context, n *node.Node, addr string, opts ...grpc.DialOption) error {
+	if n.APIAddress(n.ID()) == addr || !n.IsMember() {
+		return nil
+	}
+	if n.IsLeader() {
+		return n.SetAPIAddress(n.ID(), addr)
+	}
+	leader := string(n.Leader())
+	if leader == "" {
+		return errNoLeader
+	}
+	conn, err := grpc.DialContext(ctx, leader, opts...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = pb.NewFileServiceClient(conn).SetAPIAddress(ctx, &pb.SetAPIAddressRequest{Id: n.ID(), Address: addr})
+	return err
+}
@@ -0,0 +1,74 @@
+package join
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/soheilhy/cmux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"dfs/internal/node"
+	"dfs/internal/server"
+	pb "dfs/proto"
+)
+
+// serveShared starts a node whose listener carries both Raft and gRPC, as
+// dfs does, and returns it with its address.
+func serveShared(t *testing.T, id string, bootstrap bool) (*node.Node, string) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	mux := cmux.New(lis)
+	grpcL := mux.Match(cmux.HTTP2())
+	raftL := mux.Match(cmux.Any())
+	n, err := node.NewWithListener(id, raftL, t.TempDir(), empty, bootstrap)
+	if err != nil {
+		t.Fatalf("%s: %v", id, err)
+	}
+	srv := grpc.NewServer()
+	pb.RegisterFileServiceServer(srv, server.New(n))
+	go srv.Serve(grpcL)
+	go mux.Serve()
+	t.Cleanup(srv.Stop)
+	return n, lis.Addr().String()
+}
+
+func TestRegisterAPI(t *testing.T) {
+	n1, _ := serveShared(t, idA, true)
+	if !waitLeader(n1) {
+		t.Fatalf("n1 not leader")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	creds := grpc.WithTransportCredentials(insecure.NewCredentials())
+	if err := RegisterAPI(ctx, n1, "api-a:1", creds); err != nil {
+		t.Fatalf("register leader: %v", err)
+	}
+	if got := n1.LeaderAPIAddress(); got != "api-a:1" {
+		t.Fatalf("leader api address: %q", got)
+	}
+
+	n2, addr2 := serveShared(t, idB, false)
+	// A node outside the cluster has nobody to register with.
+	if err := RegisterAPI(ctx, n2, "api-b:1", creds); err != nil {
+		t.Fatalf("register non-member: %v", err)
+	}
+	if err := n1.AddPeer(idB, addr2); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for string(n2.Leader()) == empty && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err := RegisterAPI(ctx, n2, "api-b:1", creds); err != nil {
+		t.Fatalf("register follower: %v", err)
+	}
+	if got := n1.APIAddress(idB); got != "api-b:1" {
+		t.Fatalf("follower api address: %q", got)
+	}
+}
@@ -0,0 +1,82 @@
+package join
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"dfs/internal/node"
+	pb "dfs/proto"
+)
+
+const pollInterval = time.Second
+
+// ErrClusterExists is returned by Discover when a seed already belongs to
+// a cluster, so the node must join it instead of bootstrapping a new one.
+var ErrClusterExists = errors.New("join: a seed already belongs to a cluster")
+
+// Discover waits until expect servers, counting this one, answer without
+// any Raft configuration and returns the expect of them with the lowest
+// ids, sorted by id. expect must be more than half of this server and its
+// seeds, so two groups of servers that cannot reach each other never both
+// bootstrap. Nodes that reach the same servers compute the same list; its
+// first member bootstraps it, and nodes left out of it join the cluster.
+func Discover(ctx context.Context, id, addr string, seeds []string, expect int, opts ...grpc.DialOption) ([]node.Member, error) {
+	total := len(seeds) + 1
+	if expect <= total/2 || expect > total {
+		return nil, fmt.Errorf("join: bootstrap expectation %d must be more than half of %d servers and at most all of them", expect, total)
+	}
+	for {
+		members, err := probe(ctx, id, addr, seeds, opts)
+		if err != nil {
+			return nil, err
+		}
+		if len(members) >= expect {
+			return members[:expect], nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("join: %w with %d of %d servers", ctx.Err(), len(members), expect)
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// probe returns this node and every reachable seed, failing if a seed
+// already has a configuration or two of them claim the same id.
+func probe(ctx context.Context, id, addr string, seeds []string, opts []grpc.DialOption) ([]node.Member, error) {
+	members := []node.Member{{ID: id, Address: addr}}
+	seen := map[string]string{id: addr}
+	for _, seed := range seeds {
+		st, err := status(ctx, seed, opts)
+		if err != nil {
+			continue
+		}
+		if len(st.Members) > 0 {
+			return nil, fmt.Errorf("%w: %s", ErrClusterExists, seed)
+		}
+		if other, ok := seen[st.Id]; ok {
+			if other == seed {
+				continue
+			}
+			return nil, fmt.Errorf("join: id %s used at %s and %s", st.Id, other, seed)
+		}
+		seen[st.Id] = seed
+		members = append(members, node.Member{ID: st.Id, Address: seed})
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+	return members, nil
+}
+
+func status(ctx context.Context, seed string, opts []grpc.DialOption) (*pb.ClusterStatusResponse, error) {
+	conn, err := grpc.DialContext(ctx, seed, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return pb.NewFileServiceClient(conn).ClusterStatus(ctx, &pb.ClusterStatusRequest{})
+}
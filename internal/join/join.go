@@ -0,0 +1,95 @@
+// Package join adds a starting node to an existing cluster by asking its
+// seed peers for the leader over the gRPC API, and records the addresses
+// servers advertise for the API.
+package join
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+
+	pb "dfs/proto"
+)
+
+const (
+	stateLeader = "Leader"
+	minBackoff  = 500 * time.Millisecond
+	maxBackoff  = 30 * time.Second
+)
+
+var errNoSeeds = errors.New("join: no seed peers")
+
+// Join registers the node id with Raft address addr through the leader
+// found via seeds, retrying with exponential backoff until it succeeds or
+// ctx is done. A node already listed with addr is left as it is.
+func Join(ctx context.Context, seeds []string, id, addr string, nonvoter bool, opts ...grpc.DialOption) error {
+	if len(seeds) == 0 {
+		return errNoSeeds
+	}
+	req := &pb.AddPeerRequest{Id: id, Address: addr, Nonvoter: nonvoter}
+	backoff := minBackoff
+	for {
+		err := attempt(ctx, seeds, req, opts)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("join: %w (last error: %v)", ctx.Err(), err)
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// attempt tries each seed in turn and returns the last error if none of
+// them led to the leader.
+func attempt(ctx context.Context, seeds []string, req *pb.AddPeerRequest, opts []grpc.DialOption) error {
+	var err error
+	for _, seed := range seeds {
+		if err = viaSeed(ctx, seed, req, opts); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// viaSeed asks seed for the cluster status and adds the node through the
+// leader, which is seed itself or the leader address it reports. A
+// server's Raft listener also serves gRPC to other servers, so seeds and
+// the leader are reached on their Raft addresses.
+func viaSeed(ctx context.Context, seed string, req *pb.AddPeerRequest, opts []grpc.DialOption) error {
+	conn, err := grpc.DialContext(ctx, seed, opts...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	client := pb.NewFileServiceClient(conn)
+	st, err := client.ClusterStatus(ctx, &pb.ClusterStatusRequest{})
+	if err != nil {
+		return err
+	}
+	for _, m := range st.Members {
+		if m.Id == req.Id && m.Address == req.Address {
+			return nil
+		}
+	}
+	if st.State != stateLeader {
+		if st.LeaderAddress == "" {
+			return fmt.Errorf("%s: no leader", seed)
+		}
+		lconn, err := grpc.DialContext(ctx, st.LeaderAddress, opts...)
+		if err != nil {
+			return err
+		}
+		defer lconn.Close()
+		client = pb.NewFileServiceClient(lconn)
+	}
+	_, err = client.AddPeer(ctx, req)
+	return err
+}
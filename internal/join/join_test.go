@@ -0,0 +1,172 @@
+package join
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"dfs/internal/node"
+	"dfs/internal/server"
+	pb "dfs/proto"
+)
+
+const (
+	idA   = "n1"
+	idB   = "n2"
+	empty = ""
+)
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
+
+// serve starts a gRPC server for n and returns its address.
+func serve(t *testing.T, n *node.Node) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	pb.RegisterFileServiceServer(srv, server.New(n))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func waitLeader(n *node.Node) bool {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if n.IsLeader() {
+			return true
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return false
+}
+
+func TestJoin(t *testing.T) {
+	n1, err := node.New(idA, freeAddr(t), t.TempDir(), empty, true)
+	if err != nil {
+		t.Fatalf("n1: %v", err)
+	}
+	if !waitLeader(n1) {
+		t.Fatalf("n1 not leader")
+	}
+	seed := serve(t, n1)
+	addrB := freeAddr(t)
+	n2, err := node.New(idB, addrB, t.TempDir(), empty, false)
+	if err != nil {
+		t.Fatalf("n2: %v", err)
+	}
+	if n2.IsMember() {
+		t.Fatalf("unbootstrapped node reports membership")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	creds := grpc.WithTransportCredentials(insecure.NewCredentials())
+	unreachable := freeAddr(t)
+	if err := Join(ctx, []string{unreachable, seed}, idB, addrB, true, creds); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	st, err := n1.Status()
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if len(st.Members) != 2 || st.Members[1].ID != idB || st.Members[1].Suffrage != "Nonvoter" {
+		t.Fatalf("unexpected members: %+v", st.Members)
+	}
+	// Joining again is a no-op for a member.
+	if err := Join(ctx, []string{seed}, idB, addrB, true, creds); err != nil {
+		t.Fatalf("rejoin: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !n2.IsMember() && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if !n2.IsMember() {
+		t.Fatalf("joined node does not see itself as member")
+	}
+}
+
+func TestJoinGivesUp(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	creds := grpc.WithTransportCredentials(insecure.NewCredentials())
+	if err := Join(ctx, []string{freeAddr(t)}, idB, freeAddr(t), false, creds); err == nil {
+		t.Fatalf("expected error without a reachable seed")
+	}
+	if err := Join(ctx, nil, idB, freeAddr(t), false, creds); err != errNoSeeds {
+		t.Fatalf("expected errNoSeeds, got %v", err)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	n2, err := node.New(idB, freeAddr(t), t.TempDir(), empty, false)
+	if err != nil {
+		t.Fatalf("n2: %v", err)
+	}
+	seed := serve(t, n2)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	creds := grpc.WithTransportCredentials(insecure.NewCredentials())
+	members, err := Discover(ctx, "n3", "self:1", []string{seed}, 2, creds)
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	if len(members) != 2 || members[0].ID != idB || members[0].Address != seed || members[1].ID != "n3" {
+		t.Fatalf("unexpected members: %+v", members)
+	}
+	for _, expect := range []int{1, 3} {
+		if _, err := Discover(ctx, "n3", "self:1", []string{seed}, expect, creds); err == nil {
+			t.Fatalf("expected error for expectation %d with one seed", expect)
+		}
+	}
+	// A majority that answers is enough; the lowest ids are chosen.
+	n4, err := node.New("n4", freeAddr(t), t.TempDir(), empty, false)
+	if err != nil {
+		t.Fatalf("n4: %v", err)
+	}
+	seed4 := serve(t, n4)
+	for self, want := range map[string][2]string{"n3": {idB, "n3"}, "n5": {idB, "n4"}} {
+		members, err := Discover(ctx, self, "self:1", []string{seed, seed4, freeAddr(t)}, 3, creds)
+		if err != nil {
+			t.Fatalf("discover %s: %v", self, err)
+		}
+		if len(members) != 3 || members[0].ID != want[0] || members[1].ID != want[1] {
+			t.Fatalf("%s: unexpected members: %+v", self, members)
+		}
+	}
+	if _, err := Discover(ctx, "n3", "self:1", []string{seed, seed4, freeAddr(t)}, 2, creds); err == nil {
+		t.Fatalf("expected error for an expectation of half the servers")
+	}
+	short, cancelShort := context.WithTimeout(ctx, 1500*time.Millisecond)
+	defer cancelShort()
+	if _, err := Discover(short, "n3", "self:1", []string{seed, freeAddr(t)}, 3, creds); err == nil {
+		t.Fatalf("expected timeout waiting for an unreachable seed")
+	}
+
+	n1, err := node.New(idA, freeAddr(t), t.TempDir(), empty, true)
+	if err != nil {
+		t.Fatalf("n1: %v", err)
+	}
+	if !waitLeader(n1) {
+		t.Fatalf("n1 not leader")
+	}
+	existing := serve(t, n1)
+	if _, err := Discover(ctx, "n3", "self:1", []string{seed, existing}, 3, creds); !errors.Is(err, ErrClusterExists) {
+		t.Fatalf("expected ErrClusterExists, got %v", err)
+	}
+}
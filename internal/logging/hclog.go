@@ -0,0 +1,143 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// LevelTrace is the slog level of hclog trace records, below debug.
+const LevelTrace = slog.LevelDebug - 4
+
+// keyName is the attribute holding the hclog logger name, such as the
+// "raft-net" transport.
+const keyName = "logger"
+
+var levels = map[hclog.Level]slog.Level{
+	hclog.NoLevel: slog.LevelInfo,
+	hclog.Trace:   LevelTrace,
+	hclog.Debug:   slog.LevelDebug,
+	hclog.Info:    slog.LevelInfo,
+	hclog.Warn:    slog.LevelWarn,
+	hclog.Error:   slog.LevelError,
+}
+
+// Hclog adapts l to the hclog.Logger interface used by the Raft library.
+// Levels are decided by l's handler, so SetLevel has no effect.
+func Hclog(l *slog.Logger) hclog.Logger { return &hclogger{l: l} }
+
+type hclogger struct {
+	l       *slog.Logger
+	name    string
+	implied []interface{}
+}
+
+func (h *hclogger) Log(level hclog.Level, msg string, args ...interface{}) {
+	lvl, ok := levels[level]
+	if !ok {
+		return
+	}
+	l := h.l
+	if h.name != "" {
+		l = l.With(keyName, h.name)
+	}
+	l.Log(context.Background(), lvl, msg, expand(args)...)
+}
+
+// expand returns args with the hclog.Fmt values formatted and other
+// fmt.Stringer values, such as the Raft instance, turned into strings as
+// hclog would. The caller's slice is left untouched.
+func expand(args []interface{}) []interface{} {
+	out := args
+	for i, a := range args {
+		var s string
+		switch v := a.(type) {
+		case hclog.Format:
+			if len(v) == 0 {
+				continue
+			}
+			format, _ := v[0].(string)
+			s = fmt.Sprintf(format, v[1:]...)
+		case error:
+			continue
+		case fmt.Stringer:
+			s = v.String()
+		default:
+			continue
+		}
+		if &out[0] == &args[0] {
+			out = append([]interface{}(nil), args...)
+		}
+		out[i] = s
+	}
+	return out
+}
+
+func (h *hclogger) Trace(msg string, args ...interface{}) { h.Log(hclog.Trace, msg, args...) }
+func (h *hclogger) Debug(msg string, args ...interface{}) { h.Log(hclog.Debug, msg, args...) }
+func (h *hclogger) Info(msg string, args ...interface{})  { h.Log(hclog.Info, msg, args...) }
+func (h *hclogger) Warn(msg string, args ...interface{})  { h.Log(hclog.Warn, msg, args...) }
+func (h *hclogger) Error(msg string, args ...interface{}) { h.Log(hclog.Error, msg, args...) }
+
+func (h *hclogger) enabled(level slog.Level) bool {
+	return h.l.Enabled(context.Background(), level)
+}
+
+func (h *hclogger) IsTrace() bool { return h.enabled(LevelTrace) }
+func (h *hclogger) IsDebug() bool { return h.enabled(slog.LevelDebug) }
+func (h *hclogger) IsInfo() bool  { return h.enabled(slog.LevelInfo) }
+func (h *hclogger) IsWarn() bool  { return h.enabled(slog.LevelWarn) }
+func (h *hclogger) IsError() bool { return h.enabled(slog.LevelError) }
+
+func (h *hclogger) ImpliedArgs() []interface{} { return h.implied }
+
+func (h *hclogger) With(args ...interface{}) hclog.Logger {
+	implied := append(append([]interface{}(nil), h.implied...), args...)
+	return &hclogger{l: h.l.With(args...), name: h.name, implied: implied}
+}
+
+func (h *hclogger) Name() string { return h.name }
+
+func (h *hclogger) Named(name string) hclog.Logger {
+	if h.name != "" {
+		name = h.name + "." + name
+	}
+	return h.ResetNamed(name)
+}
+
+func (h *hclogger) ResetNamed(name string) hclog.Logger {
+	return &hclogger{l: h.l, name: name, implied: h.implied}
+}
+
+func (h *hclogger) SetLevel(hclog.Level) {}
+
+func (h *hclogger) GetLevel() hclog.Level {
+	for _, level := range []hclog.Level{hclog.Trace, hclog.Debug, hclog.Info, hclog.Warn, hclog.Error} {
+		if h.enabled(levels[level]) {
+			return level
+		}
+	}
+	return hclog.Off
+}
+
+func (h *hclogger) StandardLogger(opts *hclog.StandardLoggerOptions) *log.Logger {
+	return log.New(h.StandardWriter(opts), "", 0)
+}
+
+// StandardWriter logs each line written at info, or at the level forced
+// by opts.
+func (h *hclogger) StandardWriter(opts *hclog.StandardLoggerOptions) io.Writer {
+	level := slog.LevelInfo
+	if opts != nil && opts.ForceLevel != hclog.NoLevel {
+		level = levels[opts.ForceLevel]
+	}
+	l := h.l
+	if h.name != "" {
+		l = l.With(keyName, h.name)
+	}
+	return slog.NewLogLogger(l.Handler(), level).Writer()
+}
@@ -0,0 +1,87 @@
+// Package logging builds the structured logger shared by a node's
+// components and carries request ids and trace contexts through contexts
+// into log records.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys every component uses.
+const (
+	KeyNode      = "node_id"
+	KeyComponent = "component"
+	KeyRequestID = "request_id"
+	KeyTraceID   = "trace_id"
+	KeySpanID    = "span_id"
+	KeyError     = "err"
+)
+
+const requestIDSize = 8
+
+// New returns a logger writing text or, with json set, JSON records of
+// at least level to w. Records logged with a context carrying a request
+// id or a recording span are tagged with them.
+func New(w io.Writer, json bool, level slog.Leveler) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler = slog.NewTextHandler(w, opts)
+	if json {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(contextHandler{h})
+}
+
+// Component returns l tagged with the component name.
+func Component(l *slog.Logger, name string) *slog.Logger {
+	return l.With(KeyComponent, name)
+}
+
+// Err returns the attribute logging err.
+func Err(err error) slog.Attr { return slog.Any(KeyError, err) }
+
+type requestIDKey struct{}
+
+// WithRequestID returns ctx carrying the request id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request id carried by ctx, if any.
+func RequestID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// NewRequestID returns a random request id.
+func NewRequestID() string {
+	b := make([]byte, requestIDSize)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// contextHandler adds the request id and span of the record's context.
+type contextHandler struct{ slog.Handler }
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id, ok := RequestID(ctx); ok {
+		r.AddAttrs(slog.String(KeyRequestID, id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String(KeyTraceID, sc.TraceID().String()), slog.String(KeySpanID, sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,122 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const testNode = "n1"
+
+// records decodes the JSON records written to buf.
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var r map[string]any
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("decode %q: %v", line, err)
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	level := new(slog.LevelVar)
+	l := Component(New(&buf, true, level).With(KeyNode, testNode), "server")
+	id := NewRequestID()
+	if len(id) != 2*requestIDSize || id == NewRequestID() {
+		t.Fatalf("unexpected request id %q", id)
+	}
+	ctx := WithRequestID(context.Background(), id)
+	l.InfoContext(ctx, "served")
+	l.Info("idle")
+	l.Debug("hidden")
+	level.Set(slog.LevelDebug)
+	l.DebugContext(ctx, "shown", Err(context.Canceled))
+
+	rs := records(t, &buf)
+	if len(rs) != 3 {
+		t.Fatalf("unexpected records: %v", rs)
+	}
+	for _, r := range rs {
+		if r[KeyNode] != testNode || r[KeyComponent] != "server" {
+			t.Fatalf("untagged record: %v", r)
+		}
+	}
+	if rs[0][KeyRequestID] != id || rs[1][KeyRequestID] != nil || rs[2][KeyRequestID] != id {
+		t.Fatalf("unexpected request ids: %v", rs)
+	}
+	if rs[2]["msg"] != "shown" || rs[2][KeyError] != context.Canceled.Error() {
+		t.Fatalf("unexpected debug record: %v", rs[2])
+	}
+	if _, ok := RequestID(context.Background()); ok {
+		t.Fatalf("request id without one set")
+	}
+}
+
+func TestNewTraced(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, true, slog.LevelInfo)
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+	l.InfoContext(trace.ContextWithSpanContext(context.Background(), sc), "traced")
+	l.InfoContext(context.Background(), "untraced")
+
+	rs := records(t, &buf)
+	if len(rs) != 2 {
+		t.Fatalf("unexpected records: %v", rs)
+	}
+	if rs[0][KeyTraceID] != sc.TraceID().String() || rs[0][KeySpanID] != sc.SpanID().String() {
+		t.Fatalf("untraced record: %v", rs[0])
+	}
+	if rs[1][KeyTraceID] != nil {
+		t.Fatalf("unexpected trace id: %v", rs[1])
+	}
+}
+
+func TestHclog(t *testing.T) {
+	var buf bytes.Buffer
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelDebug)
+	h := Hclog(New(&buf, true, level)).Named("raft").With("term", 2)
+	h.Trace("dropped")
+	h.Debug("vote", "from", testNode, "servers", hclog.Fmt("%d of %d", 2, 3), "min", hclog.Warn)
+	h.Named("net").Warn("dial failed")
+	h.StandardLogger(&hclog.StandardLoggerOptions{ForceLevel: hclog.Error}).Print("from std")
+	h.Log(hclog.Off, "never")
+
+	if h.IsTrace() || !h.IsDebug() || h.GetLevel() != hclog.Debug || h.Name() != "raft" {
+		t.Fatalf("unexpected level or name: %v %q", h.GetLevel(), h.Name())
+	}
+	if args := h.ImpliedArgs(); len(args) != 2 || args[0] != "term" {
+		t.Fatalf("unexpected implied args: %v", args)
+	}
+	rs := records(t, &buf)
+	if len(rs) != 3 {
+		t.Fatalf("unexpected records: %v", rs)
+	}
+	if rs[0]["level"] != "DEBUG" || rs[0][keyName] != "raft" || rs[0]["from"] != testNode || rs[0]["term"] != 2.0 || rs[0]["servers"] != "2 of 3" || rs[0]["min"] != "warn" {
+		t.Fatalf("unexpected debug record: %v", rs[0])
+	}
+	if rs[1]["level"] != "WARN" || rs[1][keyName] != "raft.net" {
+		t.Fatalf("unexpected warn record: %v", rs[1])
+	}
+	if rs[2]["level"] != "ERROR" || rs[2]["msg"] != "from std" {
+		t.Fatalf("unexpected standard logger record: %v", rs[2])
+	}
+}
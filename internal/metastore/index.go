@@ -0,0 +1,207 @@
+package metastore
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type pathSet map[string]struct{}
+
+// index holds secondary indexes over live entries. It is only touched with
+// the owning Store's lock held.
+type index struct {
+	paths  []string                      // sorted live paths for prefix scans
+	hashes map[[hashSize]byte]pathSet    // content hash -> paths
+	labels map[string]map[string]pathSet // label key -> value -> paths
+}
+
+func newIndex() *index {
+	return &index{
+		hashes: make(map[[hashSize]byte]pathSet),
+		labels: make(map[string]map[string]pathSet),
+	}
+}
+
+func (x *index) add(e *Entry) {
+	i := sort.SearchStrings(x.paths, e.Path)
+	if i == len(x.paths) || x.paths[i] != e.Path {
+		x.paths = append(x.paths, emptyPath)
+		copy(x.paths[i+1:], x.paths[i:])
+		x.paths[i] = e.Path
+	}
+	hs, ok := x.hashes[e.Hash]
+	if !ok {
+		hs = make(pathSet)
+		x.hashes[e.Hash] = hs
+	}
+	hs[e.Path] = struct{}{}
+	for k, v := range e.Labels {
+		vals, ok := x.labels[k]
+		if !ok {
+			vals = make(map[string]pathSet)
+			x.labels[k] = vals
+		}
+		ps, ok := vals[v]
+		if !ok {
+			ps = make(pathSet)
+			vals[v] = ps
+		}
+		ps[e.Path] = struct{}{}
+	}
+}
+
+func (x *index) remove(e *Entry) {
+	if i := sort.SearchStrings(x.paths, e.Path); i < len(x.paths) && x.paths[i] == e.Path {
+		x.paths = append(x.paths[:i], x.paths[i+1:]...)
+	}
+	if hs, ok := x.hashes[e.Hash]; ok {
+		delete(hs, e.Path)
+		if len(hs) == 0 {
+			delete(x.hashes, e.Hash)
+		}
+	}
+	for k, v := range e.Labels {
+		vals := x.labels[k]
+		if ps, ok := vals[v]; ok {
+			delete(ps, e.Path)
+			if len(ps) == 0 {
+				delete(vals, v)
+			}
+		}
+		if len(vals) == 0 {
+			delete(x.labels, k)
+		}
+	}
+}
+
+// LabelMatch selects entries whose label Key equals Value, or starts with
+// Value when Prefix is set.
+type LabelMatch struct {
+	Key    string
+	Value  string
+	Prefix bool
+}
+
+// Query describes a Find request. All set predicates must match.
+type Query struct {
+	Hash       *[hashSize]byte
+	PathPrefix string
+	Labels     []LabelMatch
+	Limit      int
+	After      string // return only paths sorting after this one
+	Exclude    string // skip paths with this prefix when set
+}
+
+// Find returns live entries matching q in path order together with the
+// path to pass as After for the next page, or "" when there are no more.
+// Candidates come from the most selective index; entries are never scanned
+// wholesale.
+func (s *Store) Find(q Query) ([]Entry, string) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var cands []string
+	switch {
+	case q.Hash != nil:
+		cands = sortedSet(s.idx.hashes[*q.Hash])
+	case len(q.Labels) > 0:
+		cands = sortedSet(s.idx.matchLabel(q.Labels[0]))
+	default:
+		cands = s.idx.prefixRange(q.PathPrefix)
+		if q.Exclude != emptyPath && strings.HasPrefix(q.Exclude, q.PathPrefix) {
+			// Excluded paths are contiguous; step over them.
+			skip := s.idx.prefixRange(q.Exclude)
+			if len(skip) > 0 {
+				i := sort.SearchStrings(cands, skip[0])
+				cands = append(cands[:i:i], cands[i+len(skip):]...)
+			}
+		}
+	}
+	if q.After != emptyPath {
+		cands = cands[sort.Search(len(cands), func(i int) bool { return cands[i] > q.After }):]
+	}
+	var res []Entry
+	for _, p := range cands {
+		e := s.data[p]
+		if !q.match(e) {
+			continue
+		}
+		if len(res) == limit {
+			return res, res[limit-1].Path
+		}
+		res = append(res, clone(e))
+	}
+	return res, emptyPath
+}
+
+// prefixRange returns the sorted live paths starting with prefix. The
+// result aliases the index and must not be modified.
+func (x *index) prefixRange(prefix string) []string {
+	start := sort.SearchStrings(x.paths, prefix)
+	end := start
+	for end < len(x.paths) && strings.HasPrefix(x.paths[end], prefix) {
+		end++
+	}
+	return x.paths[start:end]
+}
+
+func (x *index) matchLabel(m LabelMatch) pathSet {
+	vals := x.labels[m.Key]
+	if !m.Prefix {
+		return vals[m.Value]
+	}
+	res := make(pathSet)
+	for v, ps := range vals {
+		if strings.HasPrefix(v, m.Value) {
+			for p := range ps {
+				res[p] = struct{}{}
+			}
+		}
+	}
+	return res
+}
+
+func (q *Query) match(e *Entry) bool {
+	if e == nil || e.Deleted {
+		return false
+	}
+	if q.Hash != nil && e.Hash != *q.Hash {
+		return false
+	}
+	if !strings.HasPrefix(e.Path, q.PathPrefix) {
+		return false
+	}
+	if q.Exclude != emptyPath && strings.HasPrefix(e.Path, q.Exclude) {
+		return false
+	}
+	for _, m := range q.Labels {
+		v, ok := e.Labels[m.Key]
+		if !ok {
+			return false
+		}
+		if m.Prefix && !strings.HasPrefix(v, m.Value) || !m.Prefix && v != m.Value {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedSet(ps pathSet) []string {
+	res := make([]string, 0, len(ps))
+	for p := range ps {
+		res = append(res, p)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,106 @@
+package metastore
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+)
+
+const (
+	labelBuild = "build"
+	build1     = "123"
+	build2     = "124"
+)
+
+func paths(es []Entry) []string {
+	res := make([]string, len(es))
+	for i := range es {
+		res[i] = es[i].Path
+	}
+	return res
+}
+
+func TestFindByHashAndLabel(t *testing.T) {
+	s := New()
+	h1 := sha256.Sum256([]byte(data1))
+	h2 := sha256.Sum256([]byte(data2))
+	s.Sync(&Entry{Path: pathA, Version: 1, Hash: h1, Labels: map[string]string{labelBuild: build1}})
+	s.Sync(&Entry{Path: pathB, Version: 1, Hash: h1, Labels: map[string]string{labelBuild: build2}})
+	s.Sync(&Entry{Path: "/c", Version: 1, Hash: h2})
+
+	if got := paths(mustFind(s, Query{Hash: &h1})); fmt.Sprint(got) != "[/a /b]" {
+		t.Fatalf("hash find: %v", got)
+	}
+	if got := paths(mustFind(s, Query{Labels: []LabelMatch{{Key: labelBuild, Value: build1}}})); fmt.Sprint(got) != "[/a]" {
+		t.Fatalf("label find: %v", got)
+	}
+	if got := paths(mustFind(s, Query{Labels: []LabelMatch{{Key: labelBuild, Value: "12", Prefix: true}}})); len(got) != 2 {
+		t.Fatalf("label prefix find: %v", got)
+	}
+	if got := mustFind(s, Query{Hash: &h2, Labels: []LabelMatch{{Key: labelBuild, Value: build1}}}); len(got) != 0 {
+		t.Fatalf("expected no match, got %v", paths(got))
+	}
+
+	// Overwrite and delete must drop stale index entries.
+	s.Sync(&Entry{Path: pathA, Version: 2, Hash: h2})
+	s.Delete(pathB, 2)
+	if got := mustFind(s, Query{Hash: &h1}); len(got) != 0 {
+		t.Fatalf("stale hash index: %v", paths(got))
+	}
+	if got := mustFind(s, Query{Labels: []LabelMatch{{Key: labelBuild, Value: build1}}}); len(got) != 0 {
+		t.Fatalf("stale label index: %v", paths(got))
+	}
+	if got := paths(mustFind(s, Query{Hash: &h2})); fmt.Sprint(got) != "[/a /c]" {
+		t.Fatalf("hash find after update: %v", got)
+	}
+}
+
+func TestFindPrefixPagination(t *testing.T) {
+	s := New()
+	const total = 25
+	for i := 0; i < total; i++ {
+		s.Sync(&Entry{Path: fmt.Sprintf("/p/%02d", i), Version: 1})
+	}
+	s.Sync(&Entry{Path: "/q/0", Version: 1})
+	var all []string
+	after := emptyPath
+	for pages := 0; ; pages++ {
+		if pages > total {
+			t.Fatalf("pagination did not terminate")
+		}
+		res, next := s.Find(Query{PathPrefix: "/p/", Limit: 10, After: after})
+		all = append(all, paths(res)...)
+		if next == emptyPath {
+			break
+		}
+		after = next
+	}
+	if len(all) != total || all[0] != "/p/00" || all[total-1] != "/p/24" {
+		t.Fatalf("unexpected pages: %v", all)
+	}
+}
+
+func TestFindExcludeAndDeletePrefix(t *testing.T) {
+	s := New()
+	for i, p := range []string{"\x00ns\x00a", "\x00ns\x00b", "a", "b"} {
+		s.Sync(&Entry{Path: p, Version: uint64(i + 1)})
+	}
+	if got := paths(mustFind(s, Query{Exclude: "\x00"})); fmt.Sprint(got) != "[a b]" {
+		t.Fatalf("exclude: %q", got)
+	}
+	if got := paths(mustFind(s, Query{After: "\x00ns\x00a", Exclude: "\x00"})); fmt.Sprint(got) != "[a b]" {
+		t.Fatalf("exclude after: %q", got)
+	}
+	s.DeletePrefix("\x00ns\x00")
+	if got := paths(mustFind(s, Query{})); fmt.Sprint(got) != "[a b]" {
+		t.Fatalf("delete prefix: %q", got)
+	}
+	if _, ok := s.Get("\x00ns\x00a"); ok {
+		t.Fatalf("entry not deleted")
+	}
+}
+
+func mustFind(s *Store, q Query) []Entry {
+	res, _ := s.Find(q)
+	return res
+}
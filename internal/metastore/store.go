@@ -18,16 +18,19 @@ type Entry struct {
 	Hash     [hashSize]byte
 	Replicas []ReplicaID
 	Deleted  bool
+	Labels   map[string]string `json:",omitempty"`
+	Codec    string            `json:",omitempty"` // compression codec of the stored value
 }
 
 // Store keeps file metadata in memory.
 type Store struct {
 	mu   sync.RWMutex
 	data map[string]*Entry
+	idx  *index
 }
 
 // New returns empty Store.
-func New() *Store { return &Store{data: make(map[string]*Entry)} }
+func New() *Store { return &Store{data: make(map[string]*Entry), idx: newIndex()} }
 
 // Sync merges metadata entry by version. Higher versions overwrite.
 func (s *Store) Sync(e *Entry) {
@@ -37,11 +40,8 @@ func (s *Store) Sync(e *Entry) {
 	s.mu.Lock()
 	cur, ok := s.data[e.Path]
 	if !ok || cur.Version < e.Version {
-		copyEntry := *e
-		if len(e.Replicas) > 0 {
-			copyEntry.Replicas = append([]ReplicaID(nil), e.Replicas...)
-		}
-		s.data[e.Path] = &copyEntry
+		copyEntry := clone(e)
+		s.replace(cur, &copyEntry)
 	}
 	s.mu.Unlock()
 }
@@ -57,10 +57,7 @@ func (s *Store) Get(path string) (Entry, bool) {
 		s.mu.RUnlock()
 		return Entry{}, false
 	}
-	res := *e
-	if len(e.Replicas) > 0 {
-		res.Replicas = append([]ReplicaID(nil), e.Replicas...)
-	}
+	res := clone(e)
 	s.mu.RUnlock()
 	return res, true
 }
@@ -73,7 +70,19 @@ func (s *Store) Delete(path string, version uint64) {
 	s.mu.Lock()
 	cur, ok := s.data[path]
 	if !ok || cur.Version < version {
-		s.data[path] = &Entry{Path: path, Version: version, Deleted: true}
+		s.replace(cur, &Entry{Path: path, Version: version, Deleted: true})
+	}
+	s.mu.Unlock()
+}
+
+// DeletePrefix marks every live path starting with prefix as deleted,
+// bumping each entry's version.
+func (s *Store) DeletePrefix(prefix string) {
+	s.mu.Lock()
+	paths := append([]string(nil), s.idx.prefixRange(prefix)...)
+	for _, p := range paths {
+		cur := s.data[p]
+		s.replace(cur, &Entry{Path: p, Version: cur.Version + 1, Deleted: true})
 	}
 	s.mu.Unlock()
 }
@@ -86,16 +95,26 @@ func (s *Store) List() []Entry {
 		if e.Deleted {
 			continue
 		}
-		cp := *e
-		if len(e.Replicas) > 0 {
-			cp.Replicas = append([]ReplicaID(nil), e.Replicas...)
-		}
-		res = append(res, cp)
+		res = append(res, clone(e))
 	}
 	s.mu.RUnlock()
 	return res
 }
 
+// Count returns the number of live entries and of deleted entries kept
+// until the next GC.
+func (s *Store) Count() (live, deleted int) {
+	s.mu.RLock()
+	for _, e := range s.data {
+		if e.Deleted {
+			deleted++
+		}
+	}
+	live = len(s.data) - deleted
+	s.mu.RUnlock()
+	return live, deleted
+}
+
 // GC removes entries marked deleted.
 func (s *Store) GC() {
 	s.mu.Lock()
@@ -106,3 +125,37 @@ func (s *Store) GC() {
 	}
 	s.mu.Unlock()
 }
+
+// Reset drops all entries and indexes.
+func (s *Store) Reset() {
+	s.mu.Lock()
+	s.data = make(map[string]*Entry)
+	s.idx = newIndex()
+	s.mu.Unlock()
+}
+
+// replace swaps cur for next and keeps the secondary indexes in step.
+// The caller must hold the write lock.
+func (s *Store) replace(cur, next *Entry) {
+	if cur != nil && !cur.Deleted {
+		s.idx.remove(cur)
+	}
+	s.data[next.Path] = next
+	if !next.Deleted {
+		s.idx.add(next)
+	}
+}
+
+func clone(e *Entry) Entry {
+	cp := *e
+	if len(e.Replicas) > 0 {
+		cp.Replicas = append([]ReplicaID(nil), e.Replicas...)
+	}
+	if len(e.Labels) > 0 {
+		cp.Labels = make(map[string]string, len(e.Labels))
+		for k, v := range e.Labels {
+			cp.Labels[k] = v
+		}
+	}
+	return cp
+}
@@ -68,7 +68,14 @@ func TestStoreConcurrent(t *testing.T) {
 func TestStoreGC(t *testing.T) {
 	s := New()
 	s.Delete(pathA, 1)
+	s.Sync(&Entry{Path: pathB, Version: 1})
+	if live, deleted := s.Count(); live != 1 || deleted != 1 {
+		t.Fatalf("count before gc: %d live, %d deleted", live, deleted)
+	}
 	s.GC()
+	if live, deleted := s.Count(); live != 1 || deleted != 0 {
+		t.Fatalf("count after gc: %d live, %d deleted", live, deleted)
+	}
 	if _, ok := s.data[pathA]; ok {
 		t.Fatalf("expected entry removed")
 	}
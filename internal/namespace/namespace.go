@@ -0,0 +1,82 @@
+// Package namespace scopes keys to tenant namespaces.
+//
+// Keys of the default namespace are stored unchanged. Keys of any other
+// namespace are stored as sep + namespace + sep + key, so they never collide
+// with default keys, which may not contain sep.
+package namespace
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	// Default is the namespace of requests that do not name one. It always
+	// exists and cannot be deleted.
+	Default = ""
+
+	// Scoped is the storage prefix shared by the keys of all non-default
+	// namespaces.
+	Scoped = sep
+
+	sep    = "\x00"
+	maxLen = 64
+)
+
+var (
+	errLong    = errors.New("namespace: name too long")
+	errChars   = errors.New("namespace: name may only contain letters, digits, '-', '_' and '.'")
+	errBadKey  = errors.New("namespace: key contains a NUL byte")
+	errDefault = errors.New("namespace: the default namespace cannot be changed")
+)
+
+// Valid checks that ns can be created.
+func Valid(ns string) error {
+	switch {
+	case ns == Default:
+		return errDefault
+	case len(ns) > maxLen:
+		return errLong
+	}
+	for _, r := range ns {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return errChars
+		}
+	}
+	return nil
+}
+
+// ValidKey checks that key can be stored in any namespace.
+func ValidKey(key string) error {
+	if strings.Contains(key, sep) {
+		return errBadKey
+	}
+	return nil
+}
+
+// Key returns the storage key for key in ns.
+func Key(ns, key string) string {
+	if ns == Default {
+		return key
+	}
+	return Prefix(ns) + key
+}
+
+// Prefix returns the storage prefix shared by all keys of ns. For the
+// default namespace it is empty and Scoped must be used to skip the keys
+// of other namespaces.
+func Prefix(ns string) string {
+	if ns == Default {
+		return ""
+	}
+	return sep + ns + sep
+}
+
+// Split returns the namespace and key of a storage key.
+func Split(stored string) (string, string) {
+	if !strings.HasPrefix(stored, sep) {
+		return Default, stored
+	}
+	ns, key, _ := strings.Cut(stored[len(sep):], sep)
+	return ns, key
+}
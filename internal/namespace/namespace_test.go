@@ -0,0 +1,31 @@
+package namespace
+
+import "testing"
+
+func TestKeySplit(t *testing.T) {
+	for _, c := range []struct{ ns, key string }{{Default, "a/b"}, {"team", "a/b"}, {"team", ""}} {
+		ns, key := Split(Key(c.ns, c.key))
+		if ns != c.ns || key != c.key {
+			t.Fatalf("split(key(%q, %q)) = %q, %q", c.ns, c.key, ns, key)
+		}
+	}
+	if Key("team", "x") == Key(Default, "team/x") {
+		t.Fatalf("namespaced key collides with default key")
+	}
+}
+
+func TestValid(t *testing.T) {
+	for _, ns := range []string{"team", "a.b-c_1"} {
+		if err := Valid(ns); err != nil {
+			t.Fatalf("valid %q: %v", ns, err)
+		}
+	}
+	for _, ns := range []string{Default, "a/b", "a\x00b", string(make([]byte, maxLen+1))} {
+		if Valid(ns) == nil {
+			t.Fatalf("expected %q to be invalid", ns)
+		}
+	}
+	if ValidKey("a\x00b") == nil {
+		t.Fatalf("expected NUL key to be invalid")
+	}
+}
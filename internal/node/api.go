@@ -0,0 +1,33 @@
+package node
+
+import "context"
+
+// SetAPIAddress records addr as the gRPC address of server id for the
+// whole cluster, or forgets it when addr is empty. Only the leader can
+// record addresses.
+func (n *Node) SetAPIAddress(id, addr string) error {
+	if n.raft == nil {
+		n.fsm.mu.Lock()
+		n.fsm.setAPIAddress(id, addr)
+		n.fsm.mu.Unlock()
+		return nil
+	}
+	return n.apply(context.Background(), &command{Op: opAPIAddr, Key: []byte(id), Addr: addr})
+}
+
+// APIAddress returns the gRPC address server id advertised, or "" when
+// it is not known.
+func (n *Node) APIAddress(id string) string { return n.fsm.apiAddress(id) }
+
+// LeaderAPIAddress returns the leader's advertised gRPC address, or ""
+// when there is no leader or its address is not known.
+func (n *Node) LeaderAPIAddress() string {
+	if n.raft == nil {
+		return ""
+	}
+	_, id := n.raft.LeaderWithID()
+	return n.APIAddress(string(id))
+}
+
+// ID returns the node's server id.
+func (n *Node) ID() string { return n.id }
@@ -0,0 +1,92 @@
+package node
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+const (
+	apiA = "api-a:13001"
+	apiB = "api-b:13001"
+)
+
+func TestAPIAddress(t *testing.T) {
+	n := NewInmem()
+	if err := n.SetAPIAddress(idA, apiA); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	n.SetAPIAddress(idB, apiB)
+	s, err := n.fsm.Snapshot()
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	var sink memSink
+	if err := s.Persist(&sink); err != nil {
+		t.Fatalf("persist: %v", err)
+	}
+	n.SetAPIAddress(idB, empty)
+	if got := n.APIAddress(idB); got != empty {
+		t.Fatalf("address not forgotten: %q", got)
+	}
+	n2 := NewInmem()
+	if err := n2.fsm.Restore(io.NopCloser(&sink)); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if n2.APIAddress(idA) != apiA || n2.APIAddress(idB) != apiB {
+		t.Fatalf("unexpected addresses after restore: %v", n2.fsm.apis)
+	}
+}
+
+func TestAPIAddressReplicated(t *testing.T) {
+	// n1 binds to a wildcard address but advertises a reachable one.
+	addr1 := getFreePort(t)
+	_, port, _ := net.SplitHostPort(addr1)
+	n1, err := New(idA, ":"+port, t.TempDir(), empty, true, WithAdvertise(addr1))
+	if err != nil {
+		t.Fatalf("n1: %v", err)
+	}
+	defer n1.raft.Shutdown()
+	addr2 := getFreePort(t)
+	n2, err := New(idB, addr2, t.TempDir(), empty, false)
+	if err != nil {
+		t.Fatalf("n2: %v", err)
+	}
+	defer n2.raft.Shutdown()
+	if waitLeader(n1) != n1 {
+		t.Fatalf("n1 not leader")
+	}
+	if n1.LeaderAPIAddress() != empty {
+		t.Fatalf("unexpected leader api address before registration")
+	}
+	if err := n1.SetAPIAddress(idA, apiA); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := n1.AddPeer(idB, addr2); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	n1.SetAPIAddress(idB, apiB)
+
+	deadline := time.Now().Add(time.Duration(timeout) * time.Second)
+	for time.Now().Before(deadline) && n2.LeaderAPIAddress() != apiA {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if got := n2.LeaderAPIAddress(); got != apiA {
+		t.Fatalf("follower leader api address: %q", got)
+	}
+	st, err := n1.Status()
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if st.LeaderAPIAddress != apiA || len(st.Members) != 2 || st.Members[0].Address != addr1 || st.Members[1].APIAddress != apiB {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+
+	if err := n1.RemovePeer(idB); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if got := n1.APIAddress(idB); got != empty {
+		t.Fatalf("api address kept after removal: %q", got)
+	}
+}
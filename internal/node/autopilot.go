@@ -0,0 +1,276 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+
+	"dfs/internal/logging"
+)
+
+// keyServer is the log attribute naming the server autopilot acted on.
+const keyServer = "server"
+
+// AutopilotConfig controls the leader's server health checks.
+type AutopilotConfig struct {
+	Interval             time.Duration // how often the leader checks servers
+	LastContactThreshold time.Duration // unhealthy when not heard from for longer
+	MaxTrailingLogs      uint64        // unhealthy when further behind the leader
+	StabilizationTime    time.Duration // healthy time before a new server is promoted
+	CleanupDeadServers   bool          // remove voters that are dead
+	DeadServerThreshold  time.Duration // unhealthy time after which a server is dead
+	MinQuorum            int           // voters never removed below this count
+}
+
+// DefaultAutopilotConfig returns conservative autopilot settings.
+func DefaultAutopilotConfig() AutopilotConfig {
+	return AutopilotConfig{
+		Interval:             5 * time.Second,
+		LastContactThreshold: 10 * time.Second,
+		MaxTrailingLogs:      250,
+		StabilizationTime:    10 * time.Second,
+		DeadServerThreshold:  5 * time.Minute,
+		MinQuorum:            3,
+	}
+}
+
+// ErrStatusDenied is wrapped by a StatsFunc error when the server
+// answered but refused to report its status. The server counts as
+// reachable and its trailing logs keep their last known value, so a
+// missing permission never makes a server look dead.
+var ErrStatusDenied = errors.New("node: server refused its status")
+
+// StatsFunc fetches the status a server reports about itself.
+type StatsFunc func(ctx context.Context, m Member) (Status, error)
+
+// ServerHealth is the leader's view of a server's health.
+type ServerHealth struct {
+	ID           string
+	Healthy      bool
+	LastContact  time.Duration // since the server last answered
+	TrailingLogs uint64        // entries behind the leader's last log index
+	StableSince  time.Time     // when the server last became healthy
+}
+
+// autopilot tracks server health on the leader. New voters are added as
+// nonvoters marked as pending in the replicated state and promoted once
+// stable, so a leader elected while a server is pending promotes it.
+type autopilot struct {
+	cfg   AutopilotConfig
+	fetch StatsFunc
+
+	mu       sync.Mutex
+	health   map[string]*ServerHealth
+	lastSeen map[string]time.Time
+}
+
+// StartAutopilot runs health checks while this node leads. fetch is used
+// to ask each other server for its status.
+func (n *Node) StartAutopilot(cfg AutopilotConfig, fetch StatsFunc) {
+	p := &autopilot{
+		cfg:      cfg,
+		fetch:    fetch,
+		health:   map[string]*ServerHealth{},
+		lastSeen: map[string]time.Time{},
+	}
+	n.pilot.Store(p)
+	n.every(newInterval(cfg.Interval), func() {
+		n.autopilotStep(context.Background(), time.Now())
+	})
+}
+
+// Health returns the autopilot's view of each server, or nil when this
+// node is not the leader or autopilot is off.
+func (n *Node) Health() []ServerHealth {
+	p := n.pilot.Load()
+	if p == nil || !n.IsLeader() {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var out []ServerHealth
+	for _, h := range p.health {
+		out = append(out, *h)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// autopilotStep refreshes server health, promotes stable pending servers
+// and removes at most one dead voter. Membership changes are made after
+// mu is released, so Health is not held up by Raft.
+func (n *Node) autopilotStep(ctx context.Context, now time.Time) {
+	p := n.pilot.Load()
+	if p == nil {
+		return
+	}
+	if !n.IsLeader() {
+		p.reset()
+		return
+	}
+	self, err := n.Status()
+	if err != nil {
+		return
+	}
+	stats, reached := p.fetchAll(ctx, self)
+	pending := n.fsm.pendingSet()
+	for _, m := range self.Members {
+		// A server marked while it was a voter, as one that joined again is,
+		// needs no promotion.
+		if pending[m.ID] && m.Suffrage == raft.Voter.String() {
+			delete(pending, m.ID)
+			if err := n.setPending(m.ID, false); err != nil {
+				n.log.Warn("autopilot clear pending", keyServer, m.ID, logging.Err(err))
+			}
+		}
+	}
+	promote, remove := p.plan(n.id, self, stats, reached, pending, now)
+	for _, id := range promote {
+		if err := n.Promote(id); err != nil {
+			n.log.Warn("autopilot promote", keyServer, id, logging.Err(err))
+			continue
+		}
+		n.log.Info("autopilot promoted a stable server", keyServer, id)
+	}
+	if remove != nil {
+		if err := n.RemovePeer(remove.ID); err != nil {
+			n.log.Warn("autopilot remove dead server", keyServer, remove.ID, logging.Err(err))
+		} else {
+			n.log.Info("autopilot removed a dead server", keyServer, remove.ID, "last_contact", remove.LastContact)
+		}
+	}
+}
+
+// fetchAll asks every other member of self for its status in parallel,
+// all under one deadline of the check interval, so unreachable servers
+// do not push a check past the next one. It returns the statuses
+// received and the servers that answered, including self.
+func (p *autopilot) fetchAll(ctx context.Context, self Status) (map[string]Status, map[string]bool) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Interval)
+	defer cancel()
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		stats   = map[string]Status{self.ID: self}
+		reached = map[string]bool{self.ID: true}
+	)
+	for _, m := range self.Members {
+		if m.ID == self.ID {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			st, err := p.fetch(ctx, m)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				stats[m.ID] = st
+				reached[m.ID] = true
+			case errors.Is(err, ErrStatusDenied):
+				reached[m.ID] = true
+			}
+		}()
+	}
+	wg.Wait()
+	return stats, reached
+}
+
+// plan updates the health of the members of self from the statuses in
+// stats and the servers in reached, and returns the pending nonvoters to
+// promote and the first dead voter to remove, if any. leader is the id
+// of this node, which is never removed.
+func (p *autopilot) plan(leader string, self Status, stats map[string]Status, reached, pending map[string]bool, now time.Time) ([]string, *ServerHealth) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	voters := 0
+	current := map[string]bool{}
+	for _, m := range self.Members {
+		current[m.ID] = true
+		if m.Suffrage == raft.Voter.String() {
+			voters++
+		}
+	}
+	for id := range p.health {
+		if !current[id] {
+			delete(p.health, id)
+			delete(p.lastSeen, id)
+		}
+	}
+	var promote []string
+	var remove *ServerHealth
+	for _, m := range self.Members {
+		st, ok := stats[m.ID]
+		h := p.update(m.ID, st, ok, reached[m.ID], self.LastLogIndex, now)
+		switch {
+		case pending[m.ID] && m.Suffrage == raft.Nonvoter.String() && h.Healthy &&
+			now.Sub(h.StableSince) >= p.cfg.StabilizationTime:
+			promote = append(promote, m.ID)
+		case remove == nil && p.cfg.CleanupDeadServers && m.Suffrage == raft.Voter.String() && m.ID != leader &&
+			!h.Healthy && h.LastContact >= p.cfg.DeadServerThreshold && voters-1 >= p.cfg.MinQuorum:
+			dead := *h
+			remove = &dead
+		}
+	}
+	return promote, remove
+}
+
+// update records the health of server id given its status st, which is
+// only valid when ok, whether it answered at all and the leader's last
+// log index. The caller holds mu.
+func (p *autopilot) update(id string, st Status, ok, reached bool, lastIndex uint64, now time.Time) *ServerHealth {
+	h, known := p.health[id]
+	if !known {
+		h = &ServerHealth{ID: id}
+		p.health[id] = h
+		p.lastSeen[id] = now
+	}
+	if reached {
+		p.lastSeen[id] = now
+	}
+	if ok {
+		h.TrailingLogs = 0
+		if lastIndex > st.LastLogIndex {
+			h.TrailingLogs = lastIndex - st.LastLogIndex
+		}
+	}
+	h.LastContact = now.Sub(p.lastSeen[id])
+	healthy := h.LastContact <= p.cfg.LastContactThreshold && h.TrailingLogs <= p.cfg.MaxTrailingLogs
+	if healthy && !h.Healthy {
+		h.StableSince = now
+	}
+	h.Healthy = healthy
+	return h
+}
+
+// reset forgets all health state after losing leadership.
+func (p *autopilot) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	clear(p.health)
+	clear(p.lastSeen)
+}
+
+// setPending records through Raft whether autopilot is to promote server
+// id. Nothing is applied when the mark is already as requested.
+func (n *Node) setPending(id string, pending bool) error {
+	if n.fsm.pendingSet()[id] == pending {
+		return nil
+	}
+	if n.raft == nil {
+		n.fsm.mu.Lock()
+		n.fsm.setPending(id, pending)
+		n.fsm.mu.Unlock()
+		return nil
+	}
+	c := &command{Op: opPendingRemove, Key: []byte(id)}
+	if pending {
+		c.Op = opPendingAdd
+	}
+	return n.apply(context.Background(), c)
+}
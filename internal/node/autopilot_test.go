@@ -0,0 +1,215 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+func TestAutopilot(t *testing.T) {
+	addr1 := getFreePort(t)
+	n1, err := New(idA, addr1, t.TempDir(), empty, true)
+	if err != nil {
+		t.Fatalf("n1: %v", err)
+	}
+	defer n1.raft.Shutdown()
+	addr2 := getFreePort(t)
+	n2, err := New(idB, addr2, t.TempDir(), empty, false)
+	if err != nil {
+		t.Fatalf("n2: %v", err)
+	}
+	defer n2.raft.Shutdown()
+	if waitLeader(n1) != n1 {
+		t.Fatalf("n1 not leader")
+	}
+
+	const (
+		alive = iota
+		denied
+		down
+	)
+	var state atomic.Int32
+	cfg := DefaultAutopilotConfig()
+	cfg.Interval = time.Hour // steps are driven by the test
+	cfg.CleanupDeadServers = true
+	cfg.MinQuorum = 1
+	n1.StartAutopilot(cfg, func(ctx context.Context, m Member) (Status, error) {
+		switch state.Load() {
+		case denied:
+			return Status{}, fmt.Errorf("status: %w", ErrStatusDenied)
+		case down:
+			return Status{}, errors.New("unreachable")
+		}
+		return n2.Status()
+	})
+	suffrage := func() string {
+		st, err := n1.Status()
+		if err != nil {
+			t.Fatalf("status: %v", err)
+		}
+		for _, m := range st.Members {
+			if m.ID == idB {
+				return m.Suffrage
+			}
+		}
+		return empty
+	}
+
+	if err := n1.AddPeer(idB, addr2); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if s := suffrage(); s != raft.Nonvoter.String() {
+		t.Fatalf("expected pending nonvoter, got %q", s)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !n2.fsm.pendingSet()[idB] {
+		if time.Now().After(deadline) {
+			t.Fatalf("pending mark not replicated")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	now := time.Now()
+	n1.autopilotStep(context.Background(), now)
+	if s := suffrage(); s != raft.Nonvoter.String() {
+		t.Fatalf("promoted before stabilizing: %q", s)
+	}
+	health := n1.Health()
+	if len(health) != 2 || health[1].ID != idB || !health[1].Healthy {
+		t.Fatalf("unexpected health: %+v", health)
+	}
+
+	now = now.Add(cfg.StabilizationTime)
+	n1.autopilotStep(context.Background(), now)
+	if s := suffrage(); s != raft.Voter.String() {
+		t.Fatalf("expected voter after stabilizing, got %q", s)
+	}
+	if n1.fsm.pendingSet()[idB] {
+		t.Fatalf("pending mark kept after promotion")
+	}
+
+	state.Store(denied)
+	now = now.Add(cfg.DeadServerThreshold)
+	n1.autopilotStep(context.Background(), now)
+	if s := suffrage(); s != raft.Voter.String() {
+		t.Fatalf("removed a server that refused its status: %q", s)
+	}
+	if h := n1.Health(); !h[1].Healthy {
+		t.Fatalf("expected a server that refused its status to stay healthy: %+v", h[1])
+	}
+
+	state.Store(down)
+	n1.autopilotStep(context.Background(), now.Add(cfg.LastContactThreshold+time.Second))
+	if s := suffrage(); s != raft.Voter.String() {
+		t.Fatalf("removed before dead threshold: %q", s)
+	}
+	if h := n1.Health(); h[1].Healthy {
+		t.Fatalf("expected unhealthy server: %+v", h[1])
+	}
+	n1.autopilotStep(context.Background(), now.Add(cfg.DeadServerThreshold))
+	if s := suffrage(); s != empty {
+		t.Fatalf("dead server not removed: %q", s)
+	}
+}
+
+func TestAutopilotPlanRemovesOneVoter(t *testing.T) {
+	cfg := DefaultAutopilotConfig()
+	cfg.CleanupDeadServers = true
+	cfg.MinQuorum = 1
+	p := &autopilot{
+		cfg:      cfg,
+		health:   map[string]*ServerHealth{},
+		lastSeen: map[string]time.Time{},
+	}
+	voter := raft.Voter.String()
+	self := Status{ID: idA, Members: []Member{
+		{ID: idA, Suffrage: voter},
+		{ID: idB, Suffrage: voter},
+		{ID: "n3", Suffrage: voter},
+	}}
+	now := time.Now()
+	reached := map[string]bool{idA: true}
+	p.plan(idA, self, nil, reached, nil, now)
+	_, remove := p.plan(idA, self, nil, reached, nil, now.Add(cfg.DeadServerThreshold))
+	if remove == nil || remove.ID != idB {
+		t.Fatalf("expected %s to be removed first, got %+v", idB, remove)
+	}
+}
+
+func TestAutopilotPendingSurvivesLeaderChange(t *testing.T) {
+	ids := []string{idA, idB, "n3", "n4"}
+	nodes := map[string]*Node{}
+	addrs := map[string]string{}
+	for i, id := range ids {
+		addrs[id] = getFreePort(t)
+		n, err := New(id, addrs[id], t.TempDir(), empty, i == 0)
+		if err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		defer n.raft.Shutdown()
+		nodes[id] = n
+	}
+	n1, n3 := nodes[idA], nodes["n3"]
+	if waitLeader(n1) != n1 {
+		t.Fatalf("n1 not leader")
+	}
+	if err := n1.AddPeer("n3", addrs["n3"]); err != nil {
+		t.Fatalf("add n3: %v", err)
+	}
+	cfg := DefaultAutopilotConfig()
+	cfg.Interval = time.Hour // steps are driven by the test
+	fetch := func(ctx context.Context, m Member) (Status, error) { return nodes[m.ID].Status() }
+	n1.StartAutopilot(cfg, fetch)
+	if err := n1.AddPeer(idB, addrs[idB]); err != nil {
+		t.Fatalf("add n2: %v", err)
+	}
+	if err := n1.AddNonvoter("n4", addrs["n4"]); err != nil {
+		t.Fatalf("add n4: %v", err)
+	}
+	if err := n1.TransferLeadership("n3"); err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	if waitLeader(n3) != n3 {
+		t.Fatalf("n3 not leader")
+	}
+
+	n3.StartAutopilot(cfg, fetch)
+	now := time.Now()
+	n3.autopilotStep(context.Background(), now)
+	n3.autopilotStep(context.Background(), now.Add(cfg.StabilizationTime))
+	st, err := n3.Status()
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	want := map[string]string{idA: raft.Voter.String(), idB: raft.Voter.String(), "n3": raft.Voter.String(), "n4": raft.Nonvoter.String()}
+	for _, m := range st.Members {
+		if want[m.ID] != m.Suffrage {
+			t.Fatalf("%s is a %s, want %s", m.ID, m.Suffrage, want[m.ID])
+		}
+	}
+}
+
+func TestAutopilotFetchesInParallel(t *testing.T) {
+	cfg := DefaultAutopilotConfig()
+	cfg.Interval = 200 * time.Millisecond
+	p := &autopilot{cfg: cfg, fetch: func(ctx context.Context, m Member) (Status, error) {
+		if m.ID == idB {
+			return Status{ID: idB}, nil
+		}
+		<-ctx.Done()
+		return Status{}, ctx.Err()
+	}}
+	self := Status{ID: idA, Members: []Member{{ID: idA}, {ID: idB}, {ID: "n3"}, {ID: "n4"}, {ID: "n5"}}}
+	start := time.Now()
+	stats, reached := p.fetchAll(context.Background(), self)
+	if took := time.Since(start); took >= 2*cfg.Interval {
+		t.Fatalf("fetching took %v with a %v interval", took, cfg.Interval)
+	}
+	if len(stats) != 2 || len(reached) != 2 || !reached[idB] {
+		t.Fatalf("unexpected results: %v %v", stats, reached)
+	}
+}
@@ -0,0 +1,70 @@
+package node
+
+import (
+	"encoding/base64"
+	"io/fs"
+
+	"dfs/internal/codec"
+	"dfs/internal/logging"
+	"dfs/internal/metastore"
+)
+
+// filesDir is the directory under the data directory holding a copy of
+// each live file version, sealed like the log and snapshots.
+const filesDir = "blobs"
+
+// fileName returns the blob store name of a storage key. Storage keys may
+// hold NUL bytes and path elements, which file names cannot.
+func fileName(key string) string { return base64.RawURLEncoding.EncodeToString([]byte(key)) }
+
+// writeFile stores the content of a live metadata version in the blob
+// store. The files are derived from the replicated state, so a failed
+// write is logged rather than failing the entry.
+func (f *fsm) writeFile(e *metastore.Entry) {
+	if f.files == nil || e.Deleted {
+		return
+	}
+	f.mu.RLock()
+	b, ok := f.blobs[e.Hash]
+	f.mu.RUnlock()
+	if !ok {
+		return
+	}
+	data, err := codec.Decompress(b.codec, b.data)
+	if err == nil {
+		err = f.files.Put(fileName(e.Path), e.Version, data)
+	}
+	if err != nil {
+		f.log.Warn("write blob file", "version", e.Version, logging.Err(err))
+	}
+}
+
+// Blob returns the content of version of the file at path as written to
+// the node's blob store. It fails with fs.ErrNotExist for a node without
+// a data directory.
+func (n *Node) Blob(path string, version uint64) ([]byte, error) {
+	if n.fsm.files == nil {
+		return nil, fs.ErrNotExist
+	}
+	return n.fsm.files.Get(fileName(path), version)
+}
+
+// gcFiles removes blob files of versions that are no longer live.
+func (n *Node) gcFiles() {
+	if n.fsm.files == nil {
+		return
+	}
+	keep := make(map[string]uint64)
+	for _, e := range n.Meta.List() {
+		keep[fileName(e.Path)] = e.Version
+	}
+	n.fsm.files.GC(keep)
+}
+
+// resealFiles re-encrypts blob files with the current key.
+func (n *Node) resealFiles() error {
+	if n.fsm.files == nil {
+		return nil
+	}
+	return n.fsm.files.Reseal()
+}
@@ -0,0 +1,61 @@
+package node
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dfs/internal/envelope"
+	"dfs/internal/metastore"
+	"dfs/internal/namespace"
+)
+
+func TestSealedBlobFiles(t *testing.T) {
+	dir := t.TempDir()
+	n, err := New(idA, getFreePort(t), dir, empty, true, WithSealer(envelope.New(testKeys{})))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer n.raft.Shutdown()
+	if waitLeader(n) != n {
+		t.Fatalf("no leader")
+	}
+	if err := n.CreateNamespace("team"); err != nil {
+		t.Fatalf("create namespace: %v", err)
+	}
+	key := namespace.Key("team", "../logs/a")
+	if err := n.Put(key, []byte(dupData)); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if err := n.SyncMeta(&metastore.Entry{Path: key, Version: 1, Hash: sha256.Sum256([]byte(dupData))}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if v, err := n.Blob(key, 1); err != nil || string(v) != dupData {
+		t.Fatalf("blob: %q %v", v, err)
+	}
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := os.ReadFile(p)
+		if err == nil && bytes.Contains(b, []byte(dupData)) {
+			t.Errorf("%s holds plaintext", p)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+
+	if err := n.SyncMeta(&metastore.Entry{Path: key, Version: 2, Deleted: true}); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	n.gcFiles()
+	if _, err := n.Blob(key, 1); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("deleted blob kept: %v", err)
+	}
+}
@@ -1,13 +1,29 @@
 package node
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"log/slog"
+	"maps"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/hashicorp/raft"
+	"github.com/prometheus/client_golang/prometheus"
 
+	"dfs/internal/auth"
+	"dfs/internal/blobstore"
+	"dfs/internal/codec"
+	"dfs/internal/envelope"
 	"dfs/internal/metastore"
+	"dfs/internal/namespace"
 )
 
 // op defines the state machine operation type.
@@ -17,25 +33,108 @@ const (
 	opPut op = iota
 	opDelete
 	opMeta
+	opPutHash
+	opGC
+	opGrant
+	opRevoke
+	opNSCreate
+	opNSDelete
+	opQuota
+	opAPIAddr
+	opKey
+	opPendingAdd
+	opPendingRemove
+)
+
+const hashSize = sha256.Size
+
+// ErrContentMissing is returned when a put by hash references content the
+// cluster does not hold. The caller should retry with the full data.
+var ErrContentMissing = errors.New("content not found")
+
+// ErrNamespaceExists and ErrNamespaceNotFound report namespace changes that
+// conflict with the replicated namespace registry.
+var (
+	ErrNamespaceExists   = errors.New("namespace already exists")
+	ErrNamespaceNotFound = errors.New("namespace not found")
+)
+
+// ErrCorrupt is returned when a stored value cannot be decoded.
+var ErrCorrupt = errors.New("stored value is corrupt")
+
+var (
+	errBadHash  = errors.New("bad content hash")
+	errBadRule  = errors.New("missing acl rule")
+	errBadQuota = errors.New("bad quota")
 )
 
 // command encodes a replicated operation.
 type command struct {
-	Op   op              `json:"op"`
-	Key  []byte          `json:"key,omitempty"`
-	Data []byte          `json:"data,omitempty"`
-	Meta metastore.Entry `json:"meta"`
+	Op    op     `json:"op"`
+	Key   []byte `json:"key,omitempty"`
+	Data  []byte `json:"data,omitempty"`
+	Hash  []byte `json:"hash,omitempty"`
+	Codec string `json:"codec,omitempty"`
+	// Sealed reports that Data was encrypted by the leader's sealer.
+	Sealed bool            `json:"sealed,omitempty"`
+	Meta   metastore.Entry `json:"meta"`
+	Rule   *auth.Rule      `json:"rule,omitempty"`
+	Quota  *Quota          `json:"quota,omitempty"`
+	Addr   string          `json:"addr,omitempty"`
+	// Trace is the span context of the request that submitted the
+	// command, if it was traced.
+	Trace map[string]string `json:"trace,omitempty"`
+}
+
+// blob is a unit of deduplicated content shared by all keys with the same
+// sha256 hash. data is stored encoded with codec.
+type blob struct {
+	data  []byte
+	codec string
+	refs  int
 }
 
 // fsm implements raft.FSM and stores key/value data alongside metadata.
+// Values are content addressed: keys map to hashes and each distinct
+// content is stored once in blobs.
 type fsm struct {
-	mu   sync.RWMutex
-	data map[string][]byte
-	meta *metastore.Store
+	mu     sync.RWMutex
+	keys   map[string][hashSize]byte
+	blobs  map[[hashSize]byte]*blob
+	meta   *metastore.Store
+	acl    []auth.Rule
+	ns     map[string]struct{} // namespaces besides the default one
+	quotas []*Usage
+	apis   map[string]string // server id to gRPC API address
+	// pending holds the nonvoters autopilot promotes once they are stable.
+	pending map[string]struct{}
+	seal    *envelope.Sealer // encrypts log payloads and snapshots; may be nil
+	files   *blobstore.Store // a copy of each live file version; may be nil
+	// keyring holds this server's encryption keys and keyID the id of the
+	// key the cluster agreed to seal with, empty until one is agreed.
+	keyring envelope.KeyProvider
+	keyID   atomic.Pointer[string]
+	// keyWait bounds how long a committed entry sealed with a key this
+	// server lacks is retried, every keyRetry, before the server stops.
+	keyWait  time.Duration
+	keyRetry time.Duration
+	metrics  *metrics
+	log      *slog.Logger
 }
 
 func newFSM(meta *metastore.Store) *fsm {
-	return &fsm{data: make(map[string][]byte), meta: meta}
+	return &fsm{
+		keys:     make(map[string][hashSize]byte),
+		blobs:    make(map[[hashSize]byte]*blob),
+		meta:     meta,
+		ns:       make(map[string]struct{}),
+		apis:     make(map[string]string),
+		pending:  make(map[string]struct{}),
+		keyWait:  keyWait,
+		keyRetry: keyRetry,
+		metrics:  newMetrics(),
+		log:      slog.Default(),
+	}
 }
 
 func (f *fsm) Apply(log *raft.Log) interface{} {
@@ -43,58 +142,459 @@ func (f *fsm) Apply(log *raft.Log) interface{} {
 	if err := json.Unmarshal(log.Data, &c); err != nil {
 		return err
 	}
+	span := f.startApply(log, &c)
+	resp := f.applyCommand(&c)
+	err, _ := resp.(error)
+	endSpan(span, err)
+	return resp
+}
+
+// applyCommand applies c and returns the response of Apply.
+func (f *fsm) applyCommand(c *command) interface{} {
 	switch c.Op {
-	case opPut:
-		key := string(c.Key)
+	case opPut, opPutHash:
+		data := c.Data
+		if c.Sealed {
+			data = f.open(c.Data)
+		}
+		h, err := contentHash(c, data)
+		if err != nil {
+			return err
+		}
 		f.mu.Lock()
-		f.data[key] = c.Data
-		f.mu.Unlock()
+		defer f.mu.Unlock()
+		return f.put(string(c.Key), h, c.Codec, data, c.Op == opPut)
 	case opDelete:
-		key := string(c.Key)
 		f.mu.Lock()
-		delete(f.data, key)
+		f.delete(string(c.Key))
 		f.mu.Unlock()
 	case opMeta:
+		ns, _ := namespace.Split(c.Meta.Path)
+		f.mu.RLock()
+		err := f.checkNamespace(ns)
+		f.mu.RUnlock()
+		if err != nil {
+			return err
+		}
 		f.meta.Sync(&c.Meta)
+		f.writeFile(&c.Meta)
+	case opGC:
+		f.mu.Lock()
+		f.gc()
+		f.mu.Unlock()
+	case opGrant, opRevoke:
+		if c.Rule == nil {
+			return errBadRule
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if c.Op == opGrant {
+			if err := f.checkNamespace(c.Rule.Namespace); err != nil {
+				return err
+			}
+		}
+		f.setRule(*c.Rule, c.Op == opGrant)
+	case opNSCreate:
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return f.createNamespace(string(c.Key))
+	case opNSDelete:
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return f.deleteNamespace(string(c.Key))
+	case opQuota:
+		if c.Quota == nil {
+			return errBadQuota
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if err := f.checkNamespace(c.Quota.Namespace); err != nil {
+			return err
+		}
+		f.setQuota(*c.Quota)
+	case opAPIAddr:
+		f.mu.Lock()
+		f.setAPIAddress(string(c.Key), c.Addr)
+		f.mu.Unlock()
+	case opKey:
+		f.setKeyID(string(c.Key))
+	case opPendingAdd, opPendingRemove:
+		f.mu.Lock()
+		f.setPending(string(c.Key), c.Op == opPendingAdd)
+		f.mu.Unlock()
+	}
+	return nil
+}
+
+// contentHash returns the hash of the content c points its key at. Puts
+// logged before deduplication carry no hash, so their data is hashed here;
+// otherwise the hash must match the decoded data.
+func contentHash(c *command, data []byte) ([hashSize]byte, error) {
+	var h [hashSize]byte
+	if c.Op == opPut && len(c.Hash) == 0 {
+		return sha256.Sum256(data), nil
+	}
+	if len(c.Hash) != hashSize {
+		return h, errBadHash
+	}
+	copy(h[:], c.Hash)
+	if c.Op == opPutHash {
+		return h, nil
+	}
+	plain, err := codec.Decompress(c.Codec, data)
+	if err != nil {
+		return h, err
+	}
+	if sha256.Sum256(plain) != h {
+		return h, errBadHash
+	}
+	return h, nil
+}
+
+// setAPIAddress records the API address of server id, or forgets it when
+// addr is empty. The caller must hold the write lock.
+func (f *fsm) setAPIAddress(id, addr string) {
+	if addr == "" {
+		delete(f.apis, id)
+		return
+	}
+	f.apis[id] = addr
+}
+
+// setPending records whether autopilot is to promote server id. The
+// caller must hold the write lock.
+func (f *fsm) setPending(id string, pending bool) {
+	if pending {
+		f.pending[id] = struct{}{}
+		return
+	}
+	delete(f.pending, id)
+}
+
+// pendingSet returns the servers autopilot is to promote.
+func (f *fsm) pendingSet() map[string]bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	out := make(map[string]bool, len(f.pending))
+	for id := range f.pending {
+		out[id] = true
+	}
+	return out
+}
+
+// apiAddress returns the API address of server id, if known.
+func (f *fsm) apiAddress(id string) string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.apis[id]
+}
+
+// createNamespace registers ns. The caller must hold the write lock.
+func (f *fsm) createNamespace(ns string) error {
+	if _, ok := f.ns[ns]; ok {
+		return ErrNamespaceExists
+	}
+	f.ns[ns] = struct{}{}
+	return nil
+}
+
+// deleteNamespace drops ns together with its keys, metadata, ACL rules and
+// quotas.
+// Content only it referenced is freed by the next gc. The caller must hold
+// the write lock.
+func (f *fsm) deleteNamespace(ns string) error {
+	if _, ok := f.ns[ns]; !ok {
+		return ErrNamespaceNotFound
+	}
+	delete(f.ns, ns)
+	prefix := namespace.Prefix(ns)
+	for k := range f.keys {
+		if strings.HasPrefix(k, prefix) {
+			f.delete(k)
+		}
+	}
+	acl := f.acl[:0:0]
+	for _, r := range f.acl {
+		if r.Namespace != ns {
+			acl = append(acl, r)
+		}
+	}
+	f.acl = acl
+	quotas := f.quotas[:0:0]
+	for _, u := range f.quotas {
+		if u.Namespace != ns {
+			quotas = append(quotas, u)
+		}
+	}
+	f.quotas = quotas
+	f.meta.DeletePrefix(prefix)
+	return nil
+}
+
+// namespaces returns the sorted names of all non-default namespaces.
+func (f *fsm) namespaces() []string {
+	f.mu.RLock()
+	res := make([]string, 0, len(f.ns))
+	for ns := range f.ns {
+		res = append(res, ns)
+	}
+	f.mu.RUnlock()
+	sort.Strings(res)
+	return res
+}
+
+// hasNamespace reports whether ns exists. The default namespace always
+// does.
+func (f *fsm) hasNamespace(ns string) bool {
+	if ns == namespace.Default {
+		return true
+	}
+	f.mu.RLock()
+	_, ok := f.ns[ns]
+	f.mu.RUnlock()
+	return ok
+}
+
+// checkNamespace returns ErrNamespaceNotFound unless ns exists. Writes
+// check it when they are applied rather than when they are submitted, so
+// one racing the deletion of its namespace fails alike on every server.
+// The caller must hold the lock.
+func (f *fsm) checkNamespace(ns string) error {
+	if ns == namespace.Default {
+		return nil
+	}
+	if _, ok := f.ns[ns]; !ok {
+		return ErrNamespaceNotFound
+	}
+	return nil
+}
+
+// setRule replaces the rule for the same identity, namespace and prefix, or removes
+// it when grant is false. The caller must hold the write lock.
+func (f *fsm) setRule(r auth.Rule, grant bool) {
+	acl := make([]auth.Rule, 0, len(f.acl)+1)
+	for _, cur := range f.acl {
+		if cur.Identity != r.Identity || cur.Namespace != r.Namespace || cur.Prefix != r.Prefix {
+			acl = append(acl, cur)
+		}
+	}
+	if grant {
+		acl = append(acl, r)
+	}
+	f.acl = acl
+}
+
+// rules returns the current ACL. The slice must not be modified.
+func (f *fsm) rules() []auth.Rule {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.acl
+}
+
+// put points key at the content with hash h, storing data when the
+// content is new. When withData is false the content must already exist.
+// Puts into a missing namespace or exceeding a quota fail without changing
+// state. The caller must hold the write lock.
+func (f *fsm) put(key string, h [hashSize]byte, codecName string, data []byte, withData bool) error {
+	if ns, _ := namespace.Split(key); f.checkNamespace(ns) != nil {
+		return ErrNamespaceNotFound
+	}
+	b, ok := f.blobs[h]
+	size := int64(len(data))
+	if ok {
+		size = int64(len(b.data))
+	} else if !withData {
+		return ErrContentMissing
+	}
+	old, exists := f.keys[key]
+	if exists && old == h {
+		return nil
+	}
+	if err := f.charge(key, size); err != nil {
+		return err
+	}
+	if !ok {
+		b = &blob{data: data, codec: codecName}
+		f.blobs[h] = b
+	}
+	if exists {
+		f.blobs[old].refs--
 	}
+	b.refs++
+	f.keys[key] = h
 	return nil
 }
 
+// delete drops key and its reference. Unreferenced content is kept until
+// the next gc so a put by hash racing with a delete still succeeds.
+func (f *fsm) delete(key string) {
+	h, ok := f.keys[key]
+	if !ok {
+		return
+	}
+	delete(f.keys, key)
+	b := f.blobs[h]
+	b.refs--
+	f.release(key, int64(len(b.data)))
+}
+
+// gc frees content no key references. It runs inside Apply so every
+// replica frees the same content at the same log index.
+func (f *fsm) gc() {
+	for h, b := range f.blobs {
+		if b.refs <= 0 {
+			delete(f.blobs, h)
+		}
+	}
+}
+
+// snap is the serialized state. Keys, Blobs and Codecs are keyed by hex
+// hashes; Data is the pre-deduplication format and is only read.
 type snap struct {
-	Data map[string][]byte `json:"data"`
-	Meta []metastore.Entry `json:"meta"`
+	Data    map[string][]byte `json:"data,omitempty"`
+	Keys    map[string]string `json:"keys,omitempty"`
+	Blobs   map[string][]byte `json:"blobs,omitempty"`
+	Codecs  map[string]string `json:"codecs,omitempty"`
+	Meta    []metastore.Entry `json:"meta"`
+	ACL     []auth.Rule       `json:"acl,omitempty"`
+	NS      []string          `json:"namespaces,omitempty"`
+	Quotas  []Quota           `json:"quotas,omitempty"`
+	APIs    map[string]string `json:"apis,omitempty"`
+	Pending []string          `json:"pending,omitempty"`
+	KeyID   string            `json:"key_id,omitempty"`
 }
 
 func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
+	start := time.Now()
 	f.mu.RLock()
-	data := make(map[string][]byte, len(f.data))
-	for k, v := range f.data {
-		data[k] = v
+	s := snap{
+		Keys:   make(map[string]string, len(f.keys)),
+		Blobs:  make(map[string][]byte, len(f.blobs)),
+		Codecs: make(map[string]string),
+		ACL:    f.acl,
+		APIs:   maps.Clone(f.apis),
+		KeyID:  f.currentKeyID(),
+	}
+	for ns := range f.ns {
+		s.NS = append(s.NS, ns)
+	}
+	sort.Strings(s.NS)
+	for id := range f.pending {
+		s.Pending = append(s.Pending, id)
+	}
+	sort.Strings(s.Pending)
+	for _, u := range f.quotas {
+		s.Quotas = append(s.Quotas, u.Quota)
+	}
+	for k, h := range f.keys {
+		s.Keys[k] = hex.EncodeToString(h[:])
+	}
+	for h, b := range f.blobs {
+		hs := hex.EncodeToString(h[:])
+		s.Blobs[hs] = b.data
+		if b.codec != codec.None {
+			s.Codecs[hs] = b.codec
+		}
 	}
 	f.mu.RUnlock()
-	return &fsmSnapshot{snap{Data: data, Meta: f.meta.List()}}, nil
+	s.Meta = f.meta.List()
+	return &fsmSnapshot{s: s, seal: f.seal, start: start, took: f.metrics.snapshot}, nil
 }
 
 func (f *fsm) Restore(rc io.ReadCloser) error {
 	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+	if b, err = f.openSnapshot(b); err != nil {
+		return err
+	}
 	var s snap
-	if err := json.NewDecoder(rc).Decode(&s); err != nil {
+	if err := json.Unmarshal(b, &s); err != nil {
 		return err
 	}
+	keys := make(map[string][hashSize]byte, len(s.Keys)+len(s.Data))
+	blobs := make(map[[hashSize]byte]*blob, len(s.Blobs))
+	for hs, data := range s.Blobs {
+		h, err := parseHash(hs)
+		if err != nil {
+			return err
+		}
+		blobs[h] = &blob{data: data, codec: s.Codecs[hs]}
+	}
+	for k, hs := range s.Keys {
+		h, err := parseHash(hs)
+		if err != nil {
+			return err
+		}
+		b, ok := blobs[h]
+		if !ok {
+			return ErrContentMissing
+		}
+		b.refs++
+		keys[k] = h
+	}
+	for k, data := range s.Data {
+		h := sha256.Sum256(data)
+		b, ok := blobs[h]
+		if !ok {
+			b = &blob{data: data}
+			blobs[h] = b
+		}
+		b.refs++
+		keys[k] = h
+	}
+	ns := make(map[string]struct{}, len(s.NS))
+	for _, name := range s.NS {
+		ns[name] = struct{}{}
+	}
 	f.mu.Lock()
-	f.data = s.Data
+	f.keys = keys
+	f.blobs = blobs
+	f.acl = s.ACL
+	f.ns = ns
+	f.quotas = nil
+	for _, q := range s.Quotas {
+		f.setQuota(q)
+	}
+	f.apis = make(map[string]string, len(s.APIs))
+	maps.Copy(f.apis, s.APIs)
+	f.pending = make(map[string]struct{}, len(s.Pending))
+	for _, id := range s.Pending {
+		f.pending[id] = struct{}{}
+	}
 	f.mu.Unlock()
-	f.meta = metastore.New()
+	f.setKeyID(s.KeyID)
+	f.meta.Reset()
 	for i := range s.Meta {
 		f.meta.Sync(&s.Meta[i])
 	}
 	return nil
 }
 
-type fsmSnapshot struct{ s snap }
+// openSnapshot returns the JSON state held in the snapshot b. A snapshot is
+// either a JSON object, which starts with '{', or one message sealed as a
+// whole, so stored values never decide which it is.
+func (f *fsm) openSnapshot(b []byte) ([]byte, error) {
+	if len(b) > 0 && b[0] == '{' {
+		return b, nil
+	}
+	return f.seal.Open(b)
+}
+
+type fsmSnapshot struct {
+	s     snap
+	seal  *envelope.Sealer
+	start time.Time
+	took  prometheus.Observer // time from capture until persisted
+}
 
 func (s *fsmSnapshot) Persist(sink raft.SnapshotSink) error {
 	b, err := json.Marshal(s.s)
+	if err == nil {
+		b, err = s.seal.Seal(b)
+	}
 	if err != nil {
 		sink.Cancel()
 		return err
@@ -103,14 +603,64 @@ func (s *fsmSnapshot) Persist(sink raft.SnapshotSink) error {
 		sink.Cancel()
 		return err
 	}
-	return sink.Close()
+	if err := sink.Close(); err != nil {
+		return err
+	}
+	s.took.Observe(time.Since(s.start).Seconds())
+	return nil
 }
 
 func (s *fsmSnapshot) Release() {}
 
-func (f *fsm) Get(key string) ([]byte, bool) {
+// Get returns the decoded value for key and whether it exists. A value
+// that fails to decode is reported with an error wrapping ErrCorrupt.
+func (f *fsm) Get(key string) ([]byte, bool, error) {
 	f.mu.RLock()
-	v, ok := f.data[key]
+	h, ok := f.keys[key]
+	if !ok {
+		f.mu.RUnlock()
+		return nil, false, nil
+	}
+	b := f.blobs[h]
 	f.mu.RUnlock()
-	return v, ok
+	data, err := codec.Decompress(b.codec, b.data)
+	if err != nil {
+		return nil, true, fmt.Errorf("%w: %s: %v", ErrCorrupt, key, err)
+	}
+	return data, true, nil
+}
+
+// stats returns the number of keys and distinct contents and the stored
+// size of the contents.
+func (f *fsm) stats() (keys, blobs int, size int64) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, b := range f.blobs {
+		size += int64(len(b.data))
+	}
+	return len(f.keys), len(f.blobs), size
+}
+
+// content reports whether content with hash h is stored and its codec.
+func (f *fsm) content(h [hashSize]byte) (string, bool) {
+	f.mu.RLock()
+	b, ok := f.blobs[h]
+	f.mu.RUnlock()
+	if !ok {
+		return codec.None, false
+	}
+	return b.codec, true
+}
+
+func parseHash(s string) ([hashSize]byte, error) {
+	var h [hashSize]byte
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return h, err
+	}
+	if len(b) != hashSize {
+		return h, errBadHash
+	}
+	copy(h[:], b)
+	return h, nil
 }
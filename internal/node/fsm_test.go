@@ -0,0 +1,383 @@
+package node
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+
+	"dfs/internal/auth"
+	"dfs/internal/codec"
+	"dfs/internal/envelope"
+	"dfs/internal/metastore"
+	"dfs/internal/namespace"
+)
+
+const (
+	dupData  = "same"
+	keyDupA  = "a"
+	keyDupB  = "b"
+	keyOther = "c"
+)
+
+type memSink struct{ bytes.Buffer }
+
+func (s *memSink) ID() string    { return empty }
+func (s *memSink) Cancel() error { return nil }
+func (s *memSink) Close() error  { return nil }
+
+func TestDedupRefcountAndGC(t *testing.T) {
+	n := NewInmem()
+	h := sha256.Sum256([]byte(dupData))
+	if err := n.PutHash(keyDupA, h); !errors.Is(err, ErrContentMissing) {
+		t.Fatalf("expected missing content, got %v", err)
+	}
+	if err := n.Put(keyDupA, []byte(dupData)); err != nil {
+		t.Fatalf("put a: %v", err)
+	}
+	if err := n.PutHash(keyDupB, h); err != nil {
+		t.Fatalf("put by hash: %v", err)
+	}
+	if len(n.fsm.blobs) != 1 || n.fsm.blobs[h].refs != 2 {
+		t.Fatalf("expected one shared blob, got %d blobs", len(n.fsm.blobs))
+	}
+	if v, ok := n.Get(keyDupB); !ok || string(v) != dupData {
+		t.Fatalf("get b: %q ok=%v", v, ok)
+	}
+	n.Delete(keyDupA)
+	n.GC()
+	if !n.HasContent(h) {
+		t.Fatalf("content freed while referenced")
+	}
+	if err := n.Put(keyDupB, []byte("other")); err != nil {
+		t.Fatalf("overwrite: %v", err)
+	}
+	if !n.HasContent(h) {
+		t.Fatalf("unreferenced content freed before gc")
+	}
+	n.GC()
+	if n.HasContent(h) {
+		t.Fatalf("unreferenced content not freed")
+	}
+}
+
+func TestSnapshotRestoreDedup(t *testing.T) {
+	n := NewInmem()
+	n.Put(keyDupA, []byte(dupData))
+	n.Put(keyDupB, []byte(dupData))
+	s, err := n.fsm.Snapshot()
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	var sink memSink
+	if err := s.Persist(&sink); err != nil {
+		t.Fatalf("persist: %v", err)
+	}
+	n2 := NewInmem()
+	if err := n2.fsm.Restore(io.NopCloser(&sink)); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	h := sha256.Sum256([]byte(dupData))
+	if len(n2.fsm.blobs) != 1 || n2.fsm.blobs[h].refs != 2 {
+		t.Fatalf("unexpected blobs after restore: %d", len(n2.fsm.blobs))
+	}
+
+	// Snapshots written before deduplication carry values inline.
+	legacy, _ := json.Marshal(map[string]interface{}{"data": map[string][]byte{keyDupA: []byte(dupData), keyOther: []byte(dupData)}})
+	n3 := NewInmem()
+	if err := n3.fsm.Restore(io.NopCloser(bytes.NewReader(legacy))); err != nil {
+		t.Fatalf("legacy restore: %v", err)
+	}
+	if v, ok := n3.Get(keyOther); !ok || string(v) != dupData || len(n3.fsm.blobs) != 1 {
+		t.Fatalf("legacy get: %q ok=%v blobs=%d", v, ok, len(n3.fsm.blobs))
+	}
+}
+
+func TestReplayLegacyPut(t *testing.T) {
+	f := newFSM(metastore.New())
+	apply := func(i uint64, c interface{}) interface{} {
+		b, _ := json.Marshal(c)
+		return f.Apply(&raft.Log{Index: i, Type: raft.LogCommand, Data: b})
+	}
+	// Entries logged before deduplication carry neither hash nor codec.
+	for i, kv := range [][2]string{{keyDupA, dupData}, {keyDupB, "other"}} {
+		legacy := map[string]interface{}{"op": opPut, "key": []byte(kv[0]), "data": []byte(kv[1])}
+		if err := apply(uint64(i+1), legacy); err != nil {
+			t.Fatalf("apply %s: %v", kv[0], err)
+		}
+	}
+	if v, ok, _ := f.Get(keyDupB); !ok || string(v) != "other" {
+		t.Fatalf("get b: %q ok=%v", v, ok)
+	}
+	if v, ok, _ := f.Get(keyDupA); !ok || string(v) != dupData || len(f.blobs) != 2 {
+		t.Fatalf("get a: %q ok=%v blobs=%d", v, ok, len(f.blobs))
+	}
+
+	h := sha256.Sum256([]byte(dupData))
+	bad := &command{Op: opPut, Key: []byte(keyOther), Data: []byte("forged"), Hash: h[:]}
+	if err, _ := apply(3, bad).(error); !errors.Is(err, errBadHash) {
+		t.Fatalf("expected errBadHash, got %v", err)
+	}
+	if _, ok, _ := f.Get(keyOther); ok {
+		t.Fatalf("mismatched put was applied")
+	}
+}
+
+func TestNamespaceSnapshotAndDelete(t *testing.T) {
+	const team = "team"
+	n := NewInmem()
+	if err := n.CreateNamespace(team); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := n.CreateNamespace(team); !errors.Is(err, ErrNamespaceExists) {
+		t.Fatalf("expected ErrNamespaceExists, got %v", err)
+	}
+	key := namespace.Key(team, keyDupA)
+	n.Put(key, []byte(dupData))
+	n.SyncMeta(&metastore.Entry{Path: key, Version: 1})
+	n.Grant(auth.Rule{Identity: "alice", Namespace: team, Perm: auth.Read})
+	s, err := n.fsm.Snapshot()
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	var sink memSink
+	if err := s.Persist(&sink); err != nil {
+		t.Fatalf("persist: %v", err)
+	}
+	n2 := NewInmem()
+	if err := n2.fsm.Restore(io.NopCloser(&sink)); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if !n2.HasNamespace(team) || len(n2.Namespaces()) != 1 {
+		t.Fatalf("namespaces after restore: %v", n2.Namespaces())
+	}
+	if err := n2.DeleteNamespace(team); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok := n2.Get(key); ok {
+		t.Fatalf("key survived namespace delete")
+	}
+	if _, ok := n2.Meta.Get(key); ok || len(n2.ACL()) != 0 {
+		t.Fatalf("metadata or acl survived namespace delete")
+	}
+	if err := n2.DeleteNamespace(team); !errors.Is(err, ErrNamespaceNotFound) {
+		t.Fatalf("expected ErrNamespaceNotFound, got %v", err)
+	}
+}
+
+func TestApplyDeletedNamespace(t *testing.T) {
+	const team = "team"
+	f := newFSM(metastore.New())
+	index := uint64(0)
+	apply := func(c *command) error {
+		index++
+		b, _ := json.Marshal(c)
+		err, _ := f.Apply(&raft.Log{Index: index, Type: raft.LogCommand, Data: b}).(error)
+		return err
+	}
+	key := namespace.Key(team, keyDupA)
+	h := sha256.Sum256([]byte(dupData))
+	put := &command{Op: opPut, Key: []byte(key), Data: []byte(dupData), Hash: h[:]}
+	if err := apply(put); !errors.Is(err, ErrNamespaceNotFound) {
+		t.Fatalf("put before create: %v", err)
+	}
+	apply(&command{Op: opNSCreate, Key: []byte(team)})
+	if err := apply(put); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	// Writes submitted before the namespace was deleted are applied after.
+	apply(&command{Op: opNSDelete, Key: []byte(team)})
+	for _, c := range []*command{
+		put,
+		{Op: opPutHash, Key: []byte(key), Hash: h[:]},
+		{Op: opMeta, Meta: metastore.Entry{Path: key, Version: 1}},
+		{Op: opQuota, Quota: &Quota{Namespace: team, MaxObjects: 1}},
+		{Op: opGrant, Rule: &auth.Rule{Identity: "alice", Namespace: team, Perm: auth.Read}},
+	} {
+		if err := apply(c); !errors.Is(err, ErrNamespaceNotFound) {
+			t.Fatalf("%v after delete: %v", c.Op, err)
+		}
+	}
+	if _, ok, _ := f.Get(key); ok || len(f.keys) != 0 || len(f.quotas) != 0 || len(f.acl) != 0 {
+		t.Fatalf("write applied to a deleted namespace")
+	}
+	if _, ok := f.meta.Get(key); ok {
+		t.Fatalf("metadata applied to a deleted namespace")
+	}
+}
+
+func TestPutHashReplicated(t *testing.T) {
+	addr := getFreePort(t)
+	n, err := New(idA, addr, t.TempDir(), empty, true)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer n.raft.Shutdown()
+	if waitLeader(n) != n {
+		t.Fatalf("no leader")
+	}
+	h := sha256.Sum256([]byte(dupData))
+	if err := n.PutHash(keyDupA, h); !errors.Is(err, ErrContentMissing) {
+		t.Fatalf("expected missing content, got %v", err)
+	}
+	if err := n.Put(keyDupA, []byte(dupData)); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if err := n.PutHash(keyDupB, h); err != nil {
+		t.Fatalf("put by hash: %v", err)
+	}
+	n.Delete(keyDupA)
+	n.Delete(keyDupB)
+	if err := n.GC(); err != nil {
+		t.Fatalf("gc: %v", err)
+	}
+	deadline := time.Now().Add(time.Duration(timeout) * time.Second)
+	for n.HasContent(h) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n.HasContent(h) {
+		t.Fatalf("content not freed")
+	}
+}
+
+func TestCompressionPolicy(t *testing.T) {
+	n := NewInmem()
+	if err := WithCompression(map[string]string{"logs/": codec.Gzip})(n); err != nil {
+		t.Fatalf("option: %v", err)
+	}
+	big := bytes.Repeat([]byte("log line\n"), 100)
+	if err := n.Put("logs/a", big); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	h := sha256.Sum256(big)
+	if n.Codec(h) != codec.Gzip || len(n.fsm.blobs[h].data) >= len(big) {
+		t.Fatalf("value not compressed: codec=%q", n.Codec(h))
+	}
+	if v, ok := n.Get("logs/a"); !ok || !bytes.Equal(v, big) {
+		t.Fatalf("get returned wrong data")
+	}
+	n.fsm.blobs[h].data = []byte("not gzip")
+	if _, ok, err := n.Lookup("logs/a"); !ok || !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("expected ErrCorrupt, got ok=%v err=%v", ok, err)
+	}
+	// Rules match the key within its namespace.
+	if err := n.CreateNamespace("team"); err != nil {
+		t.Fatalf("create namespace: %v", err)
+	}
+	scoped := bytes.Repeat([]byte("team log line\n"), 100)
+	if err := n.Put(namespace.Key("team", "logs/b"), scoped); err != nil {
+		t.Fatalf("put scoped: %v", err)
+	}
+	if c := n.Codec(sha256.Sum256(scoped)); c != codec.Gzip {
+		t.Fatalf("value in namespace not compressed: codec=%q", c)
+	}
+	raw := bytes.Repeat([]byte("x"), 2*codec.MinSize)
+	if err := n.Put("raw", raw); err != nil {
+		t.Fatalf("put raw: %v", err)
+	}
+	if c := n.Codec(sha256.Sum256(raw)); c != codec.None {
+		t.Fatalf("unexpected codec %q outside prefix", c)
+	}
+	if err := WithCompression(map[string]string{empty: "bogus"})(n); err == nil {
+		t.Fatalf("expected unknown codec error")
+	}
+}
+
+type testKeys struct{}
+
+func (testKeys) Current() (string, []byte, error) {
+	return idA, bytes.Repeat([]byte{7}, envelope.KeySize), nil
+}
+func (testKeys) Key(string) ([]byte, error) { return bytes.Repeat([]byte{7}, envelope.KeySize), nil }
+
+func TestSealedLogAndSnapshot(t *testing.T) {
+	addr := getFreePort(t)
+	seal := envelope.New(testKeys{})
+	n, err := New(idA, addr, t.TempDir(), empty, true, WithSealer(seal))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer n.raft.Shutdown()
+	if waitLeader(n) != n {
+		t.Fatalf("no leader")
+	}
+	if err := n.Put(keyDupA, []byte(dupData)); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if v, ok := n.Get(keyDupA); !ok || string(v) != dupData {
+		t.Fatalf("get: %q ok=%v", v, ok)
+	}
+	var entry raft.Log
+	for i := n.raft.LastIndex(); i > 0; i-- {
+		if n.logs.GetLog(i, &entry) == nil && entry.Type == raft.LogCommand {
+			break
+		}
+	}
+	if bytes.Contains(entry.Data, []byte(dupData)) {
+		t.Fatalf("log entry holds plaintext")
+	}
+
+	s, _ := n.fsm.Snapshot()
+	var sink memSink
+	if err := s.Persist(&sink); err != nil {
+		t.Fatalf("persist: %v", err)
+	}
+	if !envelope.IsSealed(sink.Bytes()) {
+		t.Fatalf("snapshot not sealed")
+	}
+	plain := NewInmem()
+	if err := plain.fsm.Restore(io.NopCloser(bytes.NewReader(sink.Bytes()))); err == nil {
+		t.Fatalf("restore without keys succeeded")
+	}
+	restored := NewInmem()
+	WithSealer(seal)(restored)
+	if err := restored.fsm.Restore(io.NopCloser(&sink)); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if v, ok := restored.Get(keyDupA); !ok || string(v) != dupData {
+		t.Fatalf("restored get: %q ok=%v", v, ok)
+	}
+	if err := n.Reseal(); err != nil {
+		t.Fatalf("reseal: %v", err)
+	}
+	if err := n.logs.GetLog(entry.Index, &raft.Log{}); !errors.Is(err, raft.ErrLogNotFound) {
+		t.Fatalf("sealed entry kept after reseal: %v", err)
+	}
+}
+
+func TestPlainValueWithSealedPrefix(t *testing.T) {
+	n, err := New(idA, getFreePort(t), t.TempDir(), empty, true)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer n.raft.Shutdown()
+	if waitLeader(n) != n {
+		t.Fatalf("no leader")
+	}
+	sealed, _ := envelope.New(testKeys{}).Seal([]byte(dupData))
+	for _, v := range [][]byte{[]byte("DFE1 user data"), sealed} {
+		if err := n.Put(keyDupA, v); err != nil {
+			t.Fatalf("put %q: %v", v, err)
+		}
+		if got, ok := n.Get(keyDupA); !ok || !bytes.Equal(got, v) {
+			t.Fatalf("get: %q ok=%v", got, ok)
+		}
+	}
+	s, _ := n.fsm.Snapshot()
+	var sink memSink
+	if err := s.Persist(&sink); err != nil {
+		t.Fatalf("persist: %v", err)
+	}
+	restored := NewInmem()
+	if err := restored.fsm.Restore(io.NopCloser(&sink)); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if got, ok := restored.Get(keyDupA); !ok || !bytes.Equal(got, sealed) {
+		t.Fatalf("restored get: %q ok=%v", got, ok)
+	}
+}
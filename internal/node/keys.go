@@ -0,0 +1,146 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"dfs/internal/logging"
+)
+
+const (
+	keyWait  = 5 * time.Minute
+	keyRetry = time.Second
+)
+
+// keyReloader is implemented by key providers that read their keys from a
+// file, such as envelope.FileKeys.
+type keyReloader interface {
+	Reload() (bool, error)
+	IDs() []string
+}
+
+// clusterKeys is the KeyProvider of the node's sealer. It seals with the
+// key the cluster agreed on, or with the key ring's current key until one
+// is agreed, and opens with any key of the ring.
+type clusterKeys struct{ f *fsm }
+
+func (k clusterKeys) Current() (string, []byte, error) {
+	id := k.f.currentKeyID()
+	if id == "" {
+		return k.f.keyring.Current()
+	}
+	key, err := k.f.keyring.Key(id)
+	return id, key, err
+}
+
+func (k clusterKeys) Key(id string) ([]byte, error) { return k.f.keyring.Key(id) }
+
+// currentKeyID returns the id of the key the cluster agreed on, or "".
+func (f *fsm) currentKeyID() string {
+	if id := f.keyID.Load(); id != nil {
+		return *id
+	}
+	return ""
+}
+
+func (f *fsm) setKeyID(id string) { f.keyID.Store(&id) }
+
+// open decrypts the payload of a committed entry. Every server must apply
+// the entry alike, so one sealed with a key this server does not hold yet
+// is retried, which makes a key file provider read its file again. When
+// the key is still missing after keyWait the server stops rather than
+// skip the entry and fall out of step with the others.
+func (f *fsm) open(b []byte) []byte {
+	deadline := time.Now().Add(f.keyWait)
+	for logged := false; ; logged = true {
+		plain, err := f.seal.Open(b)
+		if err == nil {
+			return plain
+		}
+		if time.Now().After(deadline) {
+			panic(fmt.Sprintf("node: cannot open a committed log entry: %v", err))
+		}
+		if !logged {
+			f.log.Error("cannot open a committed log entry, waiting for its key", logging.Err(err), "wait", f.keyWait)
+		}
+		time.Sleep(f.keyRetry)
+	}
+}
+
+// keyIDs returns the ids of the keys this server holds.
+func (n *Node) keyIDs() []string {
+	if r, ok := n.fsm.keyring.(keyReloader); ok {
+		return r.IDs()
+	}
+	return nil
+}
+
+// StartKeyRotation periodically reloads the key file when the provider
+// reads one. A new key only becomes the one data is sealed with once
+// every server holds it: the leader asks each member for its keys with
+// fetch and then replicates the change. Each server reseals its state and
+// blob files after the agreed key changes.
+func (n *Node) StartKeyRotation(interval time.Duration, fetch StatsFunc) {
+	r, ok := n.fsm.keyring.(keyReloader)
+	if !ok {
+		return
+	}
+	resealed := n.fsm.currentKeyID()
+	n.keyEvery = newInterval(interval)
+	n.every(n.keyEvery, func() {
+		if _, err := r.Reload(); err != nil {
+			n.log.Warn("reload keys", logging.Err(err))
+			return
+		}
+		if n.IsLeader() {
+			if err := n.rotateKey(context.Background(), fetch, n.keyEvery.get()); err != nil {
+				n.log.Warn("rotate key", logging.Err(err))
+			}
+		}
+		if id := n.fsm.currentKeyID(); id != resealed {
+			n.log.Info("encryption key changed, resealing state", "key", id)
+			if err := n.Reseal(); err != nil {
+				n.log.Warn("reseal", logging.Err(err))
+				return
+			}
+			if err := n.resealFiles(); err != nil {
+				n.log.Warn("reseal blob files", logging.Err(err))
+				return
+			}
+			resealed = id
+		}
+	})
+}
+
+// rotateKey makes the current key of the key ring the cluster's once every
+// member reports holding it. Each member is given timeout to answer.
+func (n *Node) rotateKey(ctx context.Context, fetch StatsFunc, timeout time.Duration) error {
+	id, _, err := n.fsm.keyring.Current()
+	if err != nil || id == n.fsm.currentKeyID() {
+		return err
+	}
+	self, err := n.Status()
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for _, m := range self.Members {
+		if m.ID == n.id {
+			continue
+		}
+		fctx, cancel := context.WithTimeout(ctx, timeout)
+		st, err := fetch(fctx, m)
+		cancel()
+		if err != nil || !slices.Contains(st.KeyIDs, id) {
+			missing = append(missing, m.ID)
+		}
+	}
+	if len(missing) > 0 {
+		n.log.Info("waiting for servers to load the new encryption key", "key", id, "servers", missing)
+		return nil
+	}
+	n.log.Info("switching to a new encryption key", "key", id)
+	return n.apply(ctx, &command{Op: opKey, Key: []byte(id)})
+}
@@ -0,0 +1,151 @@
+package node
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+
+	"dfs/internal/envelope"
+)
+
+const (
+	keyOld = "k1"
+	keyNew = "k2"
+)
+
+func keyLine(t *testing.T, id string) string {
+	t.Helper()
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("rand: %v", err)
+	}
+	return id + " " + base64.StdEncoding.EncodeToString(b)
+}
+
+func writeKeyFile(t *testing.T, path string, lines ...string) *envelope.FileKeys {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatalf("write keys: %v", err)
+	}
+	k, err := envelope.LoadKeyFile(path)
+	if err != nil {
+		t.Fatalf("load keys: %v", err)
+	}
+	return k
+}
+
+// TestApplyWaitsForMissingKey applies an entry sealed by a leader that
+// already holds a new key on a follower whose key file lacks it.
+func TestApplyWaitsForMissingKey(t *testing.T) {
+	old, next := keyLine(t, keyOld), keyLine(t, keyNew)
+	leader := NewInmem()
+	WithSealer(envelope.New(writeKeyFile(t, filepath.Join(t.TempDir(), "keys"), old, next)))(leader)
+	followerPath := filepath.Join(t.TempDir(), "keys")
+	follower := NewInmem()
+	WithSealer(envelope.New(writeKeyFile(t, followerPath, old)))(follower)
+	follower.fsm.keyRetry = 10 * time.Millisecond
+
+	entry := func(i uint64, key string) *raft.Log {
+		sealed, err := leader.fsm.seal.Seal([]byte(dupData))
+		if err != nil {
+			t.Fatalf("seal: %v", err)
+		}
+		b, _ := json.Marshal(&command{Op: opPut, Key: []byte(key), Data: sealed, Sealed: true})
+		return &raft.Log{Index: i, Type: raft.LogCommand, Data: b}
+	}
+
+	follower.fsm.keyWait = 5 * time.Second
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		os.WriteFile(followerPath, []byte(old+"\n"+next+"\n"), 0o600)
+	}()
+	if err, _ := follower.fsm.Apply(entry(1, keyDupA)).(error); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if v, ok := follower.Get(keyDupA); !ok || string(v) != dupData {
+		t.Fatalf("get: %q ok=%v", v, ok)
+	}
+
+	// A key that never arrives stops the server instead of dropping the entry.
+	other := NewInmem()
+	WithSealer(envelope.New(writeKeyFile(t, filepath.Join(t.TempDir(), "keys"), old)))(other)
+	other.fsm.keyWait = 50 * time.Millisecond
+	other.fsm.keyRetry = 10 * time.Millisecond
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("apply with a missing key did not stop the server")
+		}
+	}()
+	other.fsm.Apply(entry(1, keyDupB))
+}
+
+func TestRotateKeyWaitsForEveryServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	old := keyLine(t, keyOld)
+	keys := writeKeyFile(t, path, old)
+	addr1 := getFreePort(t)
+	n1, err := New(idA, addr1, t.TempDir(), empty, true, WithSealer(envelope.New(keys)))
+	if err != nil {
+		t.Fatalf("n1: %v", err)
+	}
+	defer n1.raft.Shutdown()
+	addr2 := getFreePort(t)
+	n2, err := New(idB, addr2, t.TempDir(), empty, false)
+	if err != nil {
+		t.Fatalf("n2: %v", err)
+	}
+	defer n2.raft.Shutdown()
+	if waitLeader(n1) != n1 {
+		t.Fatalf("n1 not leader")
+	}
+	if err := n1.AddPeer(idB, addr2); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	var held []string
+	fetch := func(ctx context.Context, m Member) (Status, error) {
+		return Status{ID: m.ID, KeyIDs: held}, nil
+	}
+	ctx := context.Background()
+	held = []string{keyOld}
+	if err := n1.rotateKey(ctx, fetch, time.Second); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if id := n1.fsm.currentKeyID(); id != keyOld {
+		t.Fatalf("agreed key %q, want %q", id, keyOld)
+	}
+
+	os.WriteFile(path, []byte(old+"\n"+keyLine(t, keyNew)+"\n"), 0o600)
+	if _, err := keys.Reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if err := n1.rotateKey(ctx, fetch, time.Second); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if id, _, _ := (clusterKeys{n1.fsm}).Current(); id != keyOld {
+		t.Fatalf("sealing with %q before every server holds it", id)
+	}
+
+	held = []string{keyOld, keyNew}
+	if err := n1.rotateKey(ctx, fetch, time.Second); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if id, _, _ := (clusterKeys{n1.fsm}).Current(); id != keyNew {
+		t.Fatalf("sealing with %q, want %q", id, keyNew)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for n2.fsm.currentKeyID() != keyNew {
+		if time.Now().After(deadline) {
+			t.Fatalf("follower agreed key %q", n2.fsm.currentKeyID())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
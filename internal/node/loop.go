@@ -0,0 +1,48 @@
+package node
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// interval is the period of a background loop. It can be changed while
+// the loop runs.
+type interval struct {
+	d    atomic.Int64
+	wake chan struct{}
+}
+
+func newInterval(d time.Duration) *interval {
+	i := &interval{wake: make(chan struct{}, 1)}
+	i.d.Store(int64(d))
+	return i
+}
+
+// set changes the period; the loop's next run is d from now.
+func (i *interval) set(d time.Duration) {
+	i.d.Store(int64(d))
+	select {
+	case i.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (i *interval) get() time.Duration { return time.Duration(i.d.Load()) }
+
+// every runs fn each period of iv until the node shuts down.
+func (n *Node) every(iv *interval, fn func()) {
+	t := time.NewTimer(iv.get())
+	go func() {
+		defer t.Stop()
+		for {
+			select {
+			case <-n.done:
+				return
+			case <-iv.wake:
+			case <-t.C:
+				fn()
+			}
+			t.Reset(iv.get())
+		}
+	}()
+}
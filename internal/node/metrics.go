@@ -0,0 +1,99 @@
+package node
+
+import (
+	"github.com/hashicorp/raft"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "dfs"
+
+// raftStates are the values of the state label of dfs_raft_state.
+var raftStates = []raft.RaftState{raft.Follower, raft.Candidate, raft.Leader, raft.Shutdown}
+
+var (
+	descState        = newDesc("raft", "state", "Raft state of the node; 1 for the current one.", "state")
+	descTerm         = newDesc("raft", "term", "Current Raft term.")
+	descLastLogIndex = newDesc("raft", "last_log_index", "Index of the last entry in the Raft log.")
+	descCommitIndex  = newDesc("raft", "commit_index", "Index of the last committed Raft entry.")
+	descAppliedIndex = newDesc("raft", "applied_index", "Index of the last Raft entry applied to the state machine.")
+	descKeys         = newDesc("fsm", "keys", "Keys held by the state machine.")
+	descBlobs        = newDesc("fsm", "blobs", "Distinct contents held by the state machine.")
+	descBytes        = newDesc("fsm", "bytes", "Stored size of the contents held by the state machine.")
+	descMetaEntries  = newDesc("metastore", "entries", "Metadata entries by state; deleted ones are kept until the next GC.", "state")
+)
+
+func newDesc(subsystem, name, help string, labels ...string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, subsystem, name), help, labels, nil)
+}
+
+// metrics records the latencies of the node's Raft operations.
+type metrics struct {
+	apply    prometheus.Histogram
+	snapshot prometheus.Histogram
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		apply: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "raft",
+			Name:      "apply_duration_seconds",
+			Help:      "Time to commit and apply a command submitted by this node.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		snapshot: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "raft",
+			Name:      "snapshot_duration_seconds",
+			Help:      "Time to capture and persist a state machine snapshot.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 4, 8),
+		}),
+	}
+}
+
+// Collector returns a Prometheus collector for the node's Raft state,
+// apply and snapshot latencies and the sizes of its state machine and
+// metadata store. Raft metrics are left out for in-memory nodes.
+func (n *Node) Collector() prometheus.Collector { return collector{n} }
+
+type collector struct{ n *Node }
+
+func (c collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{descState, descTerm, descLastLogIndex, descCommitIndex, descAppliedIndex, descKeys, descBlobs, descBytes, descMetaEntries} {
+		ch <- d
+	}
+	c.n.fsm.metrics.apply.Describe(ch)
+	c.n.fsm.metrics.snapshot.Describe(ch)
+}
+
+func (c collector) Collect(ch chan<- prometheus.Metric) {
+	n := c.n
+	n.fsm.metrics.apply.Collect(ch)
+	n.fsm.metrics.snapshot.Collect(ch)
+	if n.raft != nil {
+		state := n.raft.State()
+		for _, s := range raftStates {
+			v := 0.0
+			if s == state {
+				v = 1
+			}
+			ch <- prometheus.MustNewConstMetric(descState, prometheus.GaugeValue, v, s.String())
+		}
+		stats := n.raft.Stats()
+		for d, key := range map[*prometheus.Desc]string{
+			descTerm:         "term",
+			descLastLogIndex: "last_log_index",
+			descCommitIndex:  "commit_index",
+			descAppliedIndex: "applied_index",
+		} {
+			ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, float64(statUint(stats, key)))
+		}
+	}
+	keys, blobs, size := n.fsm.stats()
+	ch <- prometheus.MustNewConstMetric(descKeys, prometheus.GaugeValue, float64(keys))
+	ch <- prometheus.MustNewConstMetric(descBlobs, prometheus.GaugeValue, float64(blobs))
+	ch <- prometheus.MustNewConstMetric(descBytes, prometheus.GaugeValue, float64(size))
+	live, deleted := n.Meta.Count()
+	ch <- prometheus.MustNewConstMetric(descMetaEntries, prometheus.GaugeValue, float64(live), "live")
+	ch <- prometheus.MustNewConstMetric(descMetaEntries, prometheus.GaugeValue, float64(deleted), "deleted")
+}
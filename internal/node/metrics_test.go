@@ -0,0 +1,91 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"dfs/internal/metastore"
+)
+
+// gather collects c through a pedantic registry and returns the metrics
+// by name.
+func gather(t *testing.T, c prometheus.Collector) map[string][]*dto.Metric {
+	t.Helper()
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(c); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	out := map[string][]*dto.Metric{}
+	for _, f := range families {
+		out[f.GetName()] = f.GetMetric()
+	}
+	return out
+}
+
+func TestMetrics(t *testing.T) {
+	n, err := New(idA, getFreePort(t), t.TempDir(), empty, true)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer n.raft.Shutdown()
+	if waitLeader(n) != n {
+		t.Fatalf("not leader")
+	}
+	n.Put("a", []byte("same"))
+	n.Put("b", []byte("same"))
+	n.Meta.Sync(&metastore.Entry{Path: "a", Version: 1})
+	n.Meta.Delete("b", 1)
+	if _, err := n.Snapshot(false); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+
+	m := gather(t, n.Collector())
+	gauge := func(name string) float64 {
+		if len(m[name]) != 1 {
+			t.Fatalf("%s: %v", name, m[name])
+		}
+		return m[name][0].GetGauge().GetValue()
+	}
+	if gauge("dfs_fsm_keys") != 2 || gauge("dfs_fsm_blobs") != 1 || gauge("dfs_fsm_bytes") != 4 {
+		t.Fatalf("unexpected fsm sizes: %v %v %v", m["dfs_fsm_keys"], m["dfs_fsm_blobs"], m["dfs_fsm_bytes"])
+	}
+	if gauge("dfs_raft_term") == 0 || gauge("dfs_raft_applied_index") == 0 || gauge("dfs_raft_commit_index") < gauge("dfs_raft_applied_index") {
+		t.Fatalf("unexpected raft indexes: %v", m)
+	}
+	for _, s := range m["dfs_raft_state"] {
+		want := 0.0
+		if s.GetLabel()[0].GetValue() == "Leader" {
+			want = 1
+		}
+		if s.GetGauge().GetValue() != want {
+			t.Fatalf("unexpected state: %v", s)
+		}
+	}
+	for _, e := range m["dfs_metastore_entries"] {
+		if e.GetGauge().GetValue() != 1 {
+			t.Fatalf("unexpected metastore entries: %v", e)
+		}
+	}
+	if c := m["dfs_raft_apply_duration_seconds"][0].GetHistogram().GetSampleCount(); c < 2 {
+		t.Fatalf("apply latency not recorded: %d", c)
+	}
+	if c := m["dfs_raft_snapshot_duration_seconds"][0].GetHistogram().GetSampleCount(); c != 1 {
+		t.Fatalf("snapshot duration not recorded: %d", c)
+	}
+}
+
+func TestMetricsInmem(t *testing.T) {
+	m := gather(t, NewInmem().Collector())
+	if _, ok := m["dfs_raft_term"]; ok {
+		t.Fatalf("raft metrics without raft")
+	}
+	if len(m["dfs_fsm_keys"]) != 1 {
+		t.Fatalf("missing fsm metrics: %v", m)
+	}
+}
@@ -2,165 +2,497 @@
 package node
 
 import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net"
-	"os"
 	"path/filepath"
-	"strings"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/raft"
 	raftboltdb "github.com/hashicorp/raft-boltdb/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
+	"dfs/internal/auth"
+	"dfs/internal/blobstore"
+	"dfs/internal/codec"
+	"dfs/internal/logging"
 	"dfs/internal/metastore"
+	"dfs/internal/namespace"
 )
 
 const (
-	networkTCP   = "tcp"
-	sepComma     = ","
-	emptyString  = ""
-	maxPool      = 3
-	dialTimeout  = 10 * time.Second
-	applyTimeout = 5 * time.Second
+	componentNode = "node"
+	componentRaft = "raft"
+	networkTCP    = "tcp"
+	sepComma      = ","
+	emptyString   = ""
+	maxPool       = 3
+	dialTimeout   = 10 * time.Second
+	applyTimeout  = 5 * time.Second
 )
 
 // Node wraps a Raft instance and its finite state machine store.
 type Node struct {
-	raft *raft.Raft
-	fsm  *fsm
-	Meta *metastore.Store
+	id       string
+	raft     *raft.Raft
+	logs     *raftboltdb.BoltStore
+	stable   *raftboltdb.BoltStore
+	snaps    *raft.FileSnapshotStore
+	snapMu   sync.Mutex    // serializes manual snapshots
+	done     chan struct{} // closed by Shutdown to stop background loops
+	stopOnce sync.Once
+	pilot    atomic.Pointer[autopilot]
+	fsm      *fsm
+	Meta     *metastore.Store
+	compress *codec.Policy
+	tls      func() *tls.Config              // dials peers over TLS when set
+	admins   atomic.Pointer[map[string]bool] // identities with implicit admin rights
+	tuning   Tuning
+	// gcEvery and keyEvery are the periods of StartGC and
+	// StartKeyRotation once started.
+	gcEvery  *interval
+	keyEvery *interval
+	// advertise is the Raft address given to peers when set.
+	advertise string
+	// logger is the logger given by WithLogger, which Raft and the node
+	// tag with their component names; log is the node's own.
+	logger *slog.Logger
+	log    *slog.Logger
 }
 
 // New creates a new Raft node bound to the given address. The peers
-// argument is a comma separated list of other Raft server addresses
-// that form the initial cluster configuration. If bootstrap is false
+// argument is a comma separated list of other Raft servers, each given as
+// id=address or as a bare address that doubles as the id, that form the
+// initial cluster configuration. If bootstrap is false
 // the node starts unbootstrapped and must be added to the cluster via
 // AddPeer.
-func New(id, bind, dataDir, peers string, bootstrap bool) (*Node, error) {
-	cfg := raft.DefaultConfig()
-	cfg.LocalID = raft.ServerID(id)
-	addr, err := net.ResolveTCPAddr(networkTCP, bind)
+func New(id, bind, dataDir, peers string, bootstrap bool, opts ...Option) (*Node, error) {
+	n, err := newNode(opts)
 	if err != nil {
 		return nil, err
 	}
-	transport, err := raft.NewTCPTransport(bind, addr, maxPool, dialTimeout, os.Stderr)
+	cfg := n.tuning.raftConfig(id)
+	advertise := bind
+	if n.advertise != "" {
+		advertise = n.advertise
+	}
+	addr, err := net.ResolveTCPAddr(networkTCP, advertise)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := raft.NewTCPTransportWithLogger(bind, addr, maxPool, dialTimeout, n.raftLogger())
 	if err != nil {
 		return nil, err
 	}
-	return newWithTransport(cfg, dataDir, peers, bootstrap, transport)
+	return n.start(cfg, dataDir, peers, bootstrap, transport)
 }
 
 // NewWithListener creates a new Raft node using an existing listener for all
 // incoming connections.
-func NewWithListener(id string, ln net.Listener, dataDir, peers string, bootstrap bool) (*Node, error) {
-	cfg := raft.DefaultConfig()
-	cfg.LocalID = raft.ServerID(id)
-	transport := raft.NewNetworkTransport(&streamLayer{ln}, maxPool, dialTimeout, os.Stderr)
-	return newWithTransport(cfg, dataDir, peers, bootstrap, transport)
-}
-
-func newWithTransport(cfg *raft.Config, dataDir, peers string, bootstrap bool, transport raft.Transport) (*Node, error) {
-	snap, err := raft.NewFileSnapshotStore(dataDir, 1, os.Stderr)
+func NewWithListener(id string, ln net.Listener, dataDir, peers string, bootstrap bool, opts ...Option) (*Node, error) {
+	n, err := newNode(opts)
 	if err != nil {
 		return nil, err
 	}
-	logDB, err := raftboltdb.NewBoltStore(filepath.Join(dataDir, "raft-log.db"))
-	if err != nil {
-		return nil, err
+	cfg := n.tuning.raftConfig(id)
+	transport := raft.NewNetworkTransportWithLogger(&streamLayer{Listener: ln, tls: n.tls, advertise: n.advertise}, maxPool, dialTimeout, n.raftLogger())
+	return n.start(cfg, dataDir, peers, bootstrap, transport)
+}
+
+func newNode(opts []Option) (*Node, error) {
+	meta := metastore.New()
+	n := &Node{fsm: newFSM(meta), Meta: meta, done: make(chan struct{}), logger: slog.Default()}
+	for _, opt := range opts {
+		if err := opt(n); err != nil {
+			return nil, err
+		}
 	}
-	stableDB, err := raftboltdb.NewBoltStore(filepath.Join(dataDir, "raft-stable.db"))
+	n.log = logging.Component(n.logger, componentNode)
+	n.fsm.log = n.log
+	return n, nil
+}
+
+// raftLogger returns the logger for Raft, its transport and snapshot
+// store.
+func (n *Node) raftLogger() hclog.Logger {
+	return logging.Hclog(logging.Component(n.logger, componentRaft))
+}
+
+func (n *Node) start(cfg *raft.Config, dataDir, peers string, bootstrap bool, transport raft.Transport) (*Node, error) {
+	var servers []raft.Server
+	if bootstrap {
+		var err error
+		local := raft.Server{ID: cfg.LocalID, Address: transport.LocalAddr()}
+		if servers, err = bootstrapServers(local, peers); err != nil {
+			return nil, err
+		}
+	}
+	cfg.Logger = n.raftLogger()
+	snap, logDB, stableDB, err := openStores(dataDir, string(cfg.LocalID), n.tuning.retain(), cfg.Logger)
 	if err != nil {
 		return nil, err
 	}
-	meta := metastore.New()
-	fsm := newFSM(meta)
-	r, err := raft.NewRaft(cfg, fsm, logDB, stableDB, snap, transport)
+	n.fsm.files = blobstore.NewSealed(filepath.Join(dataDir, filesDir), n.fsm.seal)
+	r, err := raft.NewRaft(cfg, n.fsm, logDB, stableDB, snap, transport)
 	if err != nil {
 		return nil, err
 	}
-	n := &Node{raft: r, fsm: fsm, Meta: meta}
+	n.id = string(cfg.LocalID)
+	n.raft = r
+	n.logs = logDB
+	n.stable = stableDB
+	n.snaps = snap
 	if bootstrap {
-		configuration := raft.Configuration{}
-		for _, p := range strings.Split(peers, sepComma) {
-			if p == emptyString {
-				continue
-			}
-			configuration.Servers = append(configuration.Servers, raft.Server{ID: raft.ServerID(p), Address: raft.ServerAddress(p)})
+		// A restarted node keeps its stored configuration.
+		err := r.BootstrapCluster(raft.Configuration{Servers: servers}).Error()
+		if err != nil && !errors.Is(err, raft.ErrCantBootstrap) {
+			r.Shutdown()
+			return nil, fmt.Errorf("bootstrap: %w", err)
 		}
-		configuration.Servers = append(configuration.Servers, raft.Server{ID: cfg.LocalID, Address: transport.LocalAddr()})
-		r.BootstrapCluster(configuration)
 	}
 	return n, nil
 }
 
+// Bootstrap forms a new cluster of voters from members, which must
+// include this node. It fails with raft.ErrCantBootstrap when the node
+// already has Raft state.
+func (n *Node) Bootstrap(members []Member) error {
+	var servers []raft.Server
+	for _, m := range members {
+		servers = append(servers, raft.Server{ID: raft.ServerID(m.ID), Address: raft.ServerAddress(m.Address)})
+	}
+	servers, err := uniqueServers(servers)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(servers, func(s raft.Server) bool { return s.ID == raft.ServerID(n.id) }) {
+		return fmt.Errorf("bootstrap: %s is not among the members", n.id)
+	}
+	if err := n.raft.BootstrapCluster(raft.Configuration{Servers: servers}).Error(); err != nil {
+		return fmt.Errorf("bootstrap: %w", err)
+	}
+	return nil
+}
+
+// openStores opens the snapshot store, keeping retain snapshots, and the
+// log and stable stores in dataDir after checking that it belongs to
+// node id.
+func openStores(dataDir, id string, retain int, logger hclog.Logger) (*raft.FileSnapshotStore, *raftboltdb.BoltStore, *raftboltdb.BoltStore, error) {
+	snap, err := raft.NewFileSnapshotStoreWithLogger(dataDir, retain, logger)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := persistID(dataDir, id); err != nil {
+		return nil, nil, nil, err
+	}
+	logDB, err := raftboltdb.NewBoltStore(filepath.Join(dataDir, "raft-log.db"))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	stableDB, err := raftboltdb.NewBoltStore(filepath.Join(dataDir, "raft-stable.db"))
+	if err != nil {
+		logDB.Close()
+		return nil, nil, nil, err
+	}
+	return snap, logDB, stableDB, nil
+}
+
 // NewInmem returns a Node backed by in-memory state without Raft.
 func NewInmem() *Node {
-	meta := metastore.New()
-	return &Node{fsm: newFSM(meta), Meta: meta}
+	n, _ := newNode(nil)
+	return n
 }
 
-// Put replicates a key/value pair through Raft.
+// Put replicates a key/value pair through Raft. Content already held by
+// the cluster is referenced by hash instead of being sent again.
 func (n *Node) Put(key string, data []byte) error {
+	return n.PutContext(context.Background(), key, data)
+}
+
+// PutContext is Put traced as a child of the span in ctx, if any.
+func (n *Node) PutContext(ctx context.Context, key string, data []byte) error {
+	return n.PutContentContext(ctx, key, sha256.Sum256(data), data)
+}
+
+// PutContent is Put with a precomputed sha256 hash of data. The value is
+// compressed with the codec configured for the prefix of the key within
+// its namespace.
+func (n *Node) PutContent(key string, hash [sha256.Size]byte, data []byte) error {
+	return n.PutContentContext(context.Background(), key, hash, data)
+}
+
+// PutContentContext is PutContent traced as a child of the span in ctx.
+func (n *Node) PutContentContext(ctx context.Context, key string, hash [sha256.Size]byte, data []byte) error {
+	_, userKey := namespace.Split(key)
+	return n.PutContentCodecContext(ctx, key, hash, data, n.compress.For(userKey))
+}
+
+// PutContentCodec is PutContent with an explicit codec. Small or
+// incompressible values are stored uncompressed regardless of codecName.
+func (n *Node) PutContentCodec(key string, hash [sha256.Size]byte, data []byte, codecName string) error {
+	return n.PutContentCodecContext(context.Background(), key, hash, data, codecName)
+}
+
+// PutContentCodecContext is PutContentCodec traced as a child of the span
+// in ctx.
+func (n *Node) PutContentCodecContext(ctx context.Context, key string, hash [sha256.Size]byte, data []byte, codecName string) (err error) {
+	ctx, span := tracer.Start(ctx, spanPut, trace.WithAttributes(attribute.Int(attrSize, len(data))))
+	defer func() { endSpan(span, err) }()
+	if n.HasContent(hash) {
+		span.SetAttributes(attribute.Bool(attrDedup, true))
+		if err := n.PutHashContext(ctx, key, hash); !errors.Is(err, ErrContentMissing) {
+			return err
+		}
+	}
+	codecName, enc, err := codec.Compress(codecName, data)
+	if err != nil {
+		return err
+	}
+	if codecName != codec.None {
+		span.SetAttributes(attribute.String(attrCodec, codecName))
+	}
 	if n.raft == nil {
 		n.fsm.mu.Lock()
-		n.fsm.data[key] = append([]byte(nil), data...)
-		n.fsm.mu.Unlock()
-		return nil
+		defer n.fsm.mu.Unlock()
+		return n.fsm.put(key, hash, codecName, append([]byte(nil), enc...), true)
+	}
+	sealed, err := n.fsm.seal.Seal(enc)
+	if err != nil {
+		return err
+	}
+	return n.apply(ctx, &command{Op: opPut, Key: []byte(key), Data: sealed, Hash: hash[:], Codec: codecName, Sealed: n.fsm.seal != nil})
+}
+
+// PutHash points key at content the cluster already stores without
+// sending the bytes. It returns ErrContentMissing if the content is
+// unknown.
+func (n *Node) PutHash(key string, hash [sha256.Size]byte) error {
+	return n.PutHashContext(context.Background(), key, hash)
+}
+
+// PutHashContext is PutHash traced as a child of the span in ctx.
+func (n *Node) PutHashContext(ctx context.Context, key string, hash [sha256.Size]byte) error {
+	if n.raft == nil {
+		n.fsm.mu.Lock()
+		defer n.fsm.mu.Unlock()
+		return n.fsm.put(key, hash, codec.None, nil, false)
 	}
-	c := &command{Op: opPut, Key: []byte(key), Data: data}
+	return n.apply(ctx, &command{Op: opPutHash, Key: []byte(key), Hash: hash[:]})
+}
+
+// HasContent reports whether content with the given hash is stored.
+func (n *Node) HasContent(hash [sha256.Size]byte) bool {
+	_, ok := n.fsm.content(hash)
+	return ok
+}
+
+// Codec returns the codec the content with the given hash is stored with.
+func (n *Node) Codec(hash [sha256.Size]byte) string {
+	c, _ := n.fsm.content(hash)
+	return c
+}
+
+// apply replicates c and returns the error from the log future or from
+// the state machine. The round trip is traced as a child of the span in
+// ctx, whose context travels with c to every server applying it.
+func (n *Node) apply(ctx context.Context, c *command) (err error) {
+	ctx, span := tracer.Start(ctx, spanApply, trace.WithAttributes(attribute.String(attrOp, c.Op.String())))
+	defer func() { endSpan(span, err) }()
+	inject(ctx, c)
 	b, err := json.Marshal(c)
 	if err != nil {
 		return err
 	}
+	start := time.Now()
 	f := n.raft.Apply(b, applyTimeout)
-	return f.Error()
+	err = f.Error()
+	n.fsm.metrics.apply.Observe(time.Since(start).Seconds())
+	if err != nil {
+		return err
+	}
+	span.SetAttributes(attribute.Int64(attrIndex, int64(f.Index())))
+	if err, ok := f.Response().(error); ok {
+		return err
+	}
+	return nil
 }
 
-// Get returns value if present.
+// Get returns value if present. A value that cannot be decoded is
+// reported as missing; use Lookup to tell the two apart.
 func (n *Node) Get(key string) ([]byte, bool) {
+	data, ok, err := n.fsm.Get(key)
+	return data, ok && err == nil
+}
+
+// Lookup returns the value for key and whether it exists. It fails with an
+// error wrapping ErrCorrupt when the stored value cannot be decoded.
+func (n *Node) Lookup(key string) ([]byte, bool, error) {
 	return n.fsm.Get(key)
 }
 
 // Delete removes key through Raft.
 func (n *Node) Delete(key string) error {
+	return n.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext is Delete traced as a child of the span in ctx.
+func (n *Node) DeleteContext(ctx context.Context, key string) error {
 	if n.raft == nil {
 		n.fsm.mu.Lock()
-		delete(n.fsm.data, key)
+		n.fsm.delete(key)
 		n.fsm.mu.Unlock()
 		return nil
 	}
-	c := &command{Op: opDelete, Key: []byte(key)}
-	b, err := json.Marshal(c)
-	if err != nil {
-		return err
-	}
-	f := n.raft.Apply(b, applyTimeout)
-	return f.Error()
+	return n.apply(ctx, &command{Op: opDelete, Key: []byte(key)})
 }
 
 // SyncMeta replicates metadata entry through Raft.
 func (n *Node) SyncMeta(e *metastore.Entry) error {
+	return n.SyncMetaContext(context.Background(), e)
+}
+
+// SyncMetaContext is SyncMeta traced as a child of the span in ctx.
+func (n *Node) SyncMetaContext(ctx context.Context, e *metastore.Entry) error {
 	if n.raft == nil {
 		n.Meta.Sync(e)
 		return nil
 	}
-	c := &command{Op: opMeta, Meta: *e}
-	b, err := json.Marshal(c)
-	if err != nil {
+	return n.apply(ctx, &command{Op: opMeta, Meta: *e})
+}
+
+// CreateNamespace registers ns through Raft. It returns ErrNamespaceExists
+// if ns is already registered.
+func (n *Node) CreateNamespace(ns string) error {
+	if err := namespace.Valid(ns); err != nil {
 		return err
 	}
-	f := n.raft.Apply(b, applyTimeout)
-	return f.Error()
+	if n.raft == nil {
+		n.fsm.mu.Lock()
+		defer n.fsm.mu.Unlock()
+		return n.fsm.createNamespace(ns)
+	}
+	return n.apply(context.Background(), &command{Op: opNSCreate, Key: []byte(ns)})
+}
+
+// DeleteNamespace removes ns with all of its keys, metadata and ACL rules.
+// It returns ErrNamespaceNotFound if ns is not registered.
+func (n *Node) DeleteNamespace(ns string) error {
+	if err := namespace.Valid(ns); err != nil {
+		return err
+	}
+	if n.raft == nil {
+		n.fsm.mu.Lock()
+		defer n.fsm.mu.Unlock()
+		return n.fsm.deleteNamespace(ns)
+	}
+	return n.apply(context.Background(), &command{Op: opNSDelete, Key: []byte(ns)})
+}
+
+// Namespaces returns the sorted names of the registered namespaces. The
+// default namespace is not included.
+func (n *Node) Namespaces() []string { return n.fsm.namespaces() }
+
+// HasNamespace reports whether ns exists.
+func (n *Node) HasNamespace(ns string) bool { return n.fsm.hasNamespace(ns) }
+
+// SetQuota adds or replaces the quota for q.Namespace and q.Prefix, or
+// removes it when both limits are zero. Usage is computed when the quota
+// is set; a quota below current usage only blocks further growth.
+func (n *Node) SetQuota(q Quota) error {
+	if q.MaxBytes < 0 || q.MaxObjects < 0 {
+		return errBadQuota
+	}
+	if n.raft == nil {
+		n.fsm.mu.Lock()
+		n.fsm.setQuota(q)
+		n.fsm.mu.Unlock()
+		return nil
+	}
+	return n.apply(context.Background(), &command{Op: opQuota, Quota: &q})
+}
+
+// Usage returns the quotas of namespace ns and their current usage.
+func (n *Node) Usage(ns string) []Usage { return n.fsm.usage(ns) }
+
+// Grant adds or replaces the ACL rule for r.Identity, r.Namespace and
+// r.Prefix.
+func (n *Node) Grant(r auth.Rule) error { return n.setRule(r, opGrant) }
+
+// Revoke removes the ACL rule for r.Identity, r.Namespace and r.Prefix.
+func (n *Node) Revoke(r auth.Rule) error { return n.setRule(r, opRevoke) }
+
+func (n *Node) setRule(r auth.Rule, o op) error {
+	if n.raft == nil {
+		n.fsm.mu.Lock()
+		n.fsm.setRule(r, o == opGrant)
+		n.fsm.mu.Unlock()
+		return nil
+	}
+	return n.apply(context.Background(), &command{Op: o, Rule: &r})
+}
+
+// ACL returns a copy of the replicated ACL rules.
+func (n *Node) ACL() []auth.Rule { return append([]auth.Rule(nil), n.fsm.rules()...) }
+
+// Authorize reports whether identity id holds at least p on path, a
+// storage key as produced by namespace.Key, either
+// through the replicated ACL or by being a configured admin.
+func (n *Node) Authorize(id, path string, p auth.Perm) bool {
+	if admins := n.admins.Load(); admins != nil && (*admins)[id] {
+		return true
+	}
+	return auth.Allowed(n.fsm.rules(), id, path, p)
+}
+
+// GC frees content no key references. The leader replicates the request
+// so all nodes free the same content; followers do nothing.
+func (n *Node) GC() error {
+	if n.raft == nil {
+		n.fsm.mu.Lock()
+		n.fsm.gc()
+		n.fsm.mu.Unlock()
+		return nil
+	}
+	if !n.IsLeader() {
+		return nil
+	}
+	return n.apply(context.Background(), &command{Op: opGC})
+}
+
+// Reseal writes a fresh snapshot so state is re-encrypted with the current
+// key, and compacts the whole log into it so no entry sealed with an older
+// key is left on disk. As with Snapshot(true), a follower that falls behind
+// afterwards must install the snapshot.
+func (n *Node) Reseal() error {
+	if n.raft == nil {
+		return nil
+	}
+	if _, err := n.Snapshot(true); !errors.Is(err, ErrNothingToSnapshot) {
+		return err
+	}
+	return nil
 }
 
 // StartGC runs periodic garbage collection for metadata and blobs.
 func (n *Node) StartGC(interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	go func() {
-		for range ticker.C {
-			n.Meta.GC()
+	n.gcEvery = newInterval(interval)
+	n.every(n.gcEvery, func() {
+		n.Meta.GC()
+		n.gcFiles()
+		if err := n.GC(); err != nil {
+			n.log.Warn("garbage collection", logging.Err(err))
 		}
-	}()
+	})
 }
 
 // IsLeader reports whether this node is the cluster leader.
@@ -170,14 +502,105 @@ func (n *Node) IsLeader() bool { return n.raft.State() == raft.Leader }
 func (n *Node) Leader() raft.ServerAddress { return n.raft.Leader() }
 
 // AddPeer adds a voting peer to the cluster. Only the leader can
-// perform membership changes.
+// perform membership changes. With autopilot running the peer joins as a
+// nonvoter marked for promotion in the replicated state, and whichever
+// server leads promotes it once it has been healthy for a while.
 func (n *Node) AddPeer(id, addr string) error {
+	if n.pilot.Load() != nil {
+		if err := n.setPending(id, true); err != nil {
+			return err
+		}
+		return n.raft.AddNonvoter(raft.ServerID(id), raft.ServerAddress(addr), 0, 0).Error()
+	}
 	f := n.raft.AddVoter(raft.ServerID(id), raft.ServerAddress(addr), 0, 0)
 	return f.Error()
 }
 
-// RemovePeer removes a peer from the cluster.
-func (n *Node) RemovePeer(id string) error {
-	f := n.raft.RemoveServer(raft.ServerID(id), 0, 0)
+// AddNonvoter adds a peer that receives the log and serves reads but does
+// not vote, so it does not slow down the write quorum. Autopilot never
+// promotes it.
+func (n *Node) AddNonvoter(id, addr string) error {
+	if err := n.setPending(id, false); err != nil {
+		return err
+	}
+	f := n.raft.AddNonvoter(raft.ServerID(id), raft.ServerAddress(addr), 0, 0)
 	return f.Error()
 }
+
+// Promote turns the nonvoter id into a voter.
+func (n *Node) Promote(id string) error {
+	s, err := n.server(id)
+	if err != nil {
+		return err
+	}
+	if err := n.raft.AddVoter(s.ID, s.Address, 0, 0).Error(); err != nil {
+		return err
+	}
+	return n.setPending(id, false)
+}
+
+// Demote turns the voter id into a nonvoter.
+func (n *Node) Demote(id string) error {
+	if _, err := n.server(id); err != nil {
+		return err
+	}
+	return n.raft.DemoteVoter(raft.ServerID(id), 0, 0).Error()
+}
+
+// RemovePeer removes a peer from the cluster and forgets its API
+// address and promotion mark. A leader removing itself steps down and
+// leaves them to be cleaned up when the server is added again.
+func (n *Node) RemovePeer(id string) error {
+	if err := n.raft.RemoveServer(raft.ServerID(id), 0, 0).Error(); err != nil {
+		return err
+	}
+	if id == n.id {
+		return nil
+	}
+	if err := n.setPending(id, false); err != nil {
+		return err
+	}
+	if n.APIAddress(id) == "" {
+		return nil
+	}
+	return n.SetAPIAddress(id, "")
+}
+
+// IsMember reports whether this node is in its latest known Raft
+// configuration, as it is after restarting a node that already joined.
+func (n *Node) IsMember() bool {
+	_, err := n.server(n.id)
+	return err == nil
+}
+
+// ErrUnknownPeer is returned when a server id is not in the Raft
+// configuration.
+var ErrUnknownPeer = errors.New("unknown peer")
+
+// TransferLeadership hands leadership to the voter with the given id, or
+// to the most up to date voter when id is empty. It returns once the
+// target has been elected. Only the leader can transfer leadership.
+func (n *Node) TransferLeadership(id string) error {
+	if id == emptyString {
+		return n.raft.LeadershipTransfer().Error()
+	}
+	s, err := n.server(id)
+	if err != nil {
+		return err
+	}
+	return n.raft.LeadershipTransferToServer(s.ID, s.Address).Error()
+}
+
+// server returns the entry for id in the Raft configuration.
+func (n *Node) server(id string) (raft.Server, error) {
+	f := n.raft.GetConfiguration()
+	if err := f.Error(); err != nil {
+		return raft.Server{}, err
+	}
+	for _, s := range f.Configuration().Servers {
+		if s.ID == raft.ServerID(id) {
+			return s, nil
+		}
+	}
+	return raft.Server{}, ErrUnknownPeer
+}
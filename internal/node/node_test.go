@@ -1,12 +1,23 @@
 package node
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/raft"
+
+	"dfs/internal/logging"
 )
 
 const (
@@ -218,3 +229,205 @@ func TestAddPeerNotLeader(t *testing.T) {
 		t.Fatalf("expected error from follower")
 	}
 }
+
+func TestTransferLeadership(t *testing.T) {
+	addr1 := getFreePort(t)
+	addr2 := getFreePort(t)
+	n1, err := New(addr1, addr1, t.TempDir(), addr2, true)
+	if err != nil {
+		t.Fatalf("n1: %v", err)
+	}
+	defer n1.raft.Shutdown()
+	n2, err := New(addr2, addr2, t.TempDir(), addr1, true)
+	if err != nil {
+		t.Fatalf("n2: %v", err)
+	}
+	defer n2.raft.Shutdown()
+	leader := waitLeader(n1, n2)
+	if leader == nil {
+		t.Fatalf("no leader")
+	}
+	follower := n2
+	if leader == n2 {
+		follower = n1
+	}
+	if err := leader.TransferLeadership("x"); !errors.Is(err, ErrUnknownPeer) {
+		t.Fatalf("expected ErrUnknownPeer, got %v", err)
+	}
+	if err := leader.TransferLeadership(follower.id); err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	if waitLeader(follower) != follower {
+		t.Fatalf("leadership not transferred")
+	}
+}
+
+func TestNonvoterPromoteDemote(t *testing.T) {
+	addr1 := getFreePort(t)
+	n1, err := New(idA, addr1, t.TempDir(), empty, true)
+	if err != nil {
+		t.Fatalf("n1: %v", err)
+	}
+	defer n1.raft.Shutdown()
+	addr2 := getFreePort(t)
+	n2, err := New(idB, addr2, t.TempDir(), empty, false)
+	if err != nil {
+		t.Fatalf("n2: %v", err)
+	}
+	defer n2.raft.Shutdown()
+	if waitLeader(n1) != n1 {
+		t.Fatalf("n1 not leader")
+	}
+	suffrage := func() string {
+		st, err := n1.Status()
+		if err != nil {
+			t.Fatalf("status: %v", err)
+		}
+		for _, m := range st.Members {
+			if m.ID == idB {
+				return m.Suffrage
+			}
+		}
+		return empty
+	}
+	if err := n1.AddNonvoter(idB, addr2); err != nil {
+		t.Fatalf("add nonvoter: %v", err)
+	}
+	if s := suffrage(); s != raft.Nonvoter.String() {
+		t.Fatalf("expected nonvoter, got %q", s)
+	}
+	if err := n1.Put("k", []byte("v")); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	deadline := time.Now().Add(time.Duration(timeout) * time.Second)
+	for time.Now().Before(deadline) {
+		if v, ok := n2.Get("k"); ok && string(v) == "v" {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if _, ok := n2.Get("k"); !ok {
+		t.Fatalf("nonvoter did not replicate value")
+	}
+	if err := n1.Promote(idB); err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	if s := suffrage(); s != raft.Voter.String() {
+		t.Fatalf("expected voter, got %q", s)
+	}
+	if err := n1.Demote(idB); err != nil {
+		t.Fatalf("demote: %v", err)
+	}
+	if s := suffrage(); s != raft.Nonvoter.String() {
+		t.Fatalf("expected nonvoter after demote, got %q", s)
+	}
+	if err := n1.Promote("x"); !errors.Is(err, ErrUnknownPeer) {
+		t.Fatalf("expected ErrUnknownPeer, got %v", err)
+	}
+}
+
+func TestBootstrap(t *testing.T) {
+	addr := getFreePort(t)
+	n, err := New(idA, addr, t.TempDir(), empty, false)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer n.raft.Shutdown()
+	if err := n.Bootstrap([]Member{{ID: idB, Address: addr}}); err == nil {
+		t.Fatalf("expected error without the local node")
+	}
+	if err := n.Bootstrap([]Member{{ID: idA, Address: addr}}); err != nil {
+		t.Fatalf("bootstrap: %v", err)
+	}
+	if waitLeader(n) != n {
+		t.Fatalf("no leader after bootstrap")
+	}
+	if err := n.Bootstrap([]Member{{ID: idA, Address: addr}}); !errors.Is(err, raft.ErrCantBootstrap) {
+		t.Fatalf("expected ErrCantBootstrap, got %v", err)
+	}
+}
+
+func TestTuning(t *testing.T) {
+	tuning := Tuning{HeartbeatTimeout: 2 * time.Second, ElectionTimeout: 3 * time.Second, TrailingLogs: 10, SnapshotRetain: 2}
+	cfg := tuning.raftConfig(idA)
+	def := raft.DefaultConfig()
+	if cfg.HeartbeatTimeout != 2*time.Second || cfg.ElectionTimeout != 3*time.Second || cfg.TrailingLogs != 10 {
+		t.Fatalf("tuning not applied: %+v", cfg)
+	}
+	if cfg.LeaderLeaseTimeout != def.LeaderLeaseTimeout || cfg.SnapshotThreshold != def.SnapshotThreshold {
+		t.Fatalf("defaults not kept: %+v", cfg)
+	}
+
+	dir := t.TempDir()
+	n, err := New(idA, getFreePort(t), dir, empty, true, WithTuning(tuning))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer n.raft.Shutdown()
+	if waitLeader(n) != n {
+		t.Fatalf("not leader")
+	}
+	for i := 0; i < 3; i++ {
+		if err := n.Put(fmt.Sprintf("k%d", i), []byte("v")); err != nil {
+			t.Fatalf("put: %v", err)
+		}
+		if err := n.raft.Snapshot().Error(); err != nil {
+			t.Fatalf("snapshot: %v", err)
+		}
+	}
+	snaps, err := os.ReadDir(filepath.Join(dir, "snapshots"))
+	if err != nil {
+		t.Fatalf("read snapshots: %v", err)
+	}
+	if len(snaps) != tuning.SnapshotRetain {
+		t.Fatalf("expected %d snapshots, got %d", tuning.SnapshotRetain, len(snaps))
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for the Raft goroutines to log to.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWithLogger(t *testing.T) {
+	var buf syncBuffer
+	l := logging.New(&buf, true, slog.LevelDebug).With(logging.KeyNode, idA)
+	n, err := New(idA, getFreePort(t), t.TempDir(), empty, true, WithLogger(l))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if waitLeader(n) != n {
+		t.Fatalf("not leader")
+	}
+	if err := n.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	components := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var r map[string]any
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("decode %q: %v", line, err)
+		}
+		if r[logging.KeyNode] != idA {
+			t.Fatalf("record without node id: %v", r)
+		}
+		c, _ := r[logging.KeyComponent].(string)
+		components[c] = true
+	}
+	if !components[componentRaft] {
+		t.Fatalf("no raft records in %q", buf.String())
+	}
+}
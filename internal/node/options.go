@@ -0,0 +1,133 @@
+package node
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"time"
+
+	"github.com/hashicorp/raft"
+
+	"dfs/internal/codec"
+	"dfs/internal/envelope"
+)
+
+// Option configures a Node before Raft starts.
+type Option func(*Node) error
+
+// WithCompression sets per-prefix compression rules mapping key prefixes
+// to codec names. The empty prefix sets the default codec.
+func WithCompression(rules map[string]string) Option {
+	return func(n *Node) error {
+		p, err := codec.NewPolicy(rules)
+		if err != nil {
+			return err
+		}
+		n.compress = p
+		return nil
+	}
+}
+
+// WithTLS makes NewWithListener dial peers over TLS using the
+// configuration returned by cfg, which is called for every connection.
+func WithTLS(cfg func() *tls.Config) Option {
+	return func(n *Node) error {
+		n.tls = cfg
+		return nil
+	}
+}
+
+// WithAdvertise makes the node give addr to its peers as its Raft
+// address instead of the address it listens on, for nodes behind NAT, in
+// containers or bound to an unspecified address.
+func WithAdvertise(addr string) Option {
+	return func(n *Node) error {
+		n.advertise = addr
+		return nil
+	}
+}
+
+// WithLogger makes the node and Raft log to l instead of slog.Default,
+// tagged with their component names.
+func WithLogger(l *slog.Logger) Option {
+	return func(n *Node) error {
+		n.logger = l
+		return nil
+	}
+}
+
+// WithAdmins gives the listed identities admin rights regardless of the
+// replicated ACL so a fresh cluster can be administered.
+func WithAdmins(ids ...string) Option {
+	return func(n *Node) error {
+		n.SetAdmins(ids...)
+		return nil
+	}
+}
+
+// WithSealer encrypts value payloads in the Raft log and snapshots with
+// the keys of s. Data is sealed with the key the cluster agreed on, see
+// StartKeyRotation, and with the current key of s until one is agreed.
+func WithSealer(s *envelope.Sealer) Option {
+	return func(n *Node) error {
+		if s == nil {
+			n.fsm.seal = nil
+			return nil
+		}
+		n.fsm.keyring = s.Keys()
+		n.fsm.seal = envelope.New(clusterKeys{n.fsm})
+		return nil
+	}
+}
+
+// Tuning overrides Raft timing and log compaction settings. Zero fields
+// keep the Raft defaults.
+type Tuning struct {
+	HeartbeatTimeout   time.Duration
+	ElectionTimeout    time.Duration
+	LeaderLeaseTimeout time.Duration
+	SnapshotInterval   time.Duration
+	SnapshotThreshold  uint64
+	TrailingLogs       uint64
+	MaxAppendEntries   int
+	SnapshotRetain     int // snapshots kept in the data directory
+}
+
+// WithTuning applies t to the Raft configuration and snapshot store.
+func WithTuning(t Tuning) Option {
+	return func(n *Node) error {
+		n.tuning = t
+		return nil
+	}
+}
+
+// raftConfig returns the Raft configuration for node id with the tuning
+// applied.
+func (t Tuning) raftConfig(id string) *raft.Config {
+	cfg := raft.DefaultConfig()
+	cfg.LocalID = raft.ServerID(id)
+	for dst, v := range map[*time.Duration]time.Duration{
+		&cfg.HeartbeatTimeout:   t.HeartbeatTimeout,
+		&cfg.ElectionTimeout:    t.ElectionTimeout,
+		&cfg.LeaderLeaseTimeout: t.LeaderLeaseTimeout,
+		&cfg.SnapshotInterval:   t.SnapshotInterval,
+	} {
+		if v > 0 {
+			*dst = v
+		}
+	}
+	if t.SnapshotThreshold > 0 {
+		cfg.SnapshotThreshold = t.SnapshotThreshold
+	}
+	if t.TrailingLogs > 0 {
+		cfg.TrailingLogs = t.TrailingLogs
+	}
+	if t.MaxAppendEntries > 0 {
+		cfg.MaxAppendEntries = t.MaxAppendEntries
+	}
+	return cfg
+}
+
+// retain returns the number of snapshots to keep.
+func (t Tuning) retain() int {
+	return max(t.SnapshotRetain, 1)
+}
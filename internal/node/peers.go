@@ -0,0 +1,88 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/raft"
+)
+
+const (
+	idFile  = "node-id"
+	peerSep = "="
+)
+
+var (
+	// ErrIDChanged is returned when a node is started with an id other
+	// than the one stored in its data directory.
+	ErrIDChanged = errors.New("node id differs from the id stored in the data directory")
+	// ErrPeerConflict is returned when the bootstrap configuration lists
+	// one id at two addresses or one address under two ids.
+	ErrPeerConflict = errors.New("conflicting peer ids or addresses")
+)
+
+// SplitPeer splits a peer given as "id=host:port". A bare address is also
+// used as the id.
+func SplitPeer(p string) (id, addr string) {
+	if id, addr, ok := strings.Cut(p, peerSep); ok {
+		return id, addr
+	}
+	return p, p
+}
+
+// persistID records id in dataDir on first start and checks it against
+// the stored id afterwards, so a node keeps its identity in the cluster.
+func persistID(dataDir, id string) error {
+	path := filepath.Join(dataDir, idFile)
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return os.WriteFile(path, []byte(id+"\n"), 0o600)
+	}
+	if err != nil {
+		return err
+	}
+	if stored := strings.TrimSpace(string(b)); stored != id {
+		return fmt.Errorf("%w: stored %q, configured %q", ErrIDChanged, stored, id)
+	}
+	return nil
+}
+
+// bootstrapServers builds the initial configuration from the comma
+// separated peers and the local server. The local server may be listed
+// among the peers with the same address.
+func bootstrapServers(local raft.Server, peers string) ([]raft.Server, error) {
+	servers := []raft.Server{local}
+	for _, p := range strings.Split(peers, sepComma) {
+		if p == emptyString {
+			continue
+		}
+		id, addr := SplitPeer(p)
+		servers = append(servers, raft.Server{ID: raft.ServerID(id), Address: raft.ServerAddress(addr)})
+	}
+	return uniqueServers(servers)
+}
+
+// uniqueServers drops exact duplicates from servers and checks that no id
+// or address is used twice.
+func uniqueServers(servers []raft.Server) ([]raft.Server, error) {
+	var out []raft.Server
+	ids := map[raft.ServerID]raft.ServerAddress{}
+	addrs := map[raft.ServerAddress]raft.ServerID{}
+	for _, s := range servers {
+		if a, ok := ids[s.ID]; ok {
+			if a == s.Address {
+				continue
+			}
+			return nil, fmt.Errorf("%w: %s at %s and %s", ErrPeerConflict, s.ID, a, s.Address)
+		}
+		if other, ok := addrs[s.Address]; ok {
+			return nil, fmt.Errorf("%w: %s used by %s and %s", ErrPeerConflict, s.Address, other, s.ID)
+		}
+		ids[s.ID], addrs[s.Address] = s.Address, s.ID
+		out = append(out, s)
+	}
+	return out, nil
+}
@@ -0,0 +1,46 @@
+package node
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/raft"
+)
+
+func TestSplitPeer(t *testing.T) {
+	if id, addr := SplitPeer("n2=h:1"); id != "n2" || addr != "h:1" {
+		t.Fatalf("split: %q %q", id, addr)
+	}
+	if id, addr := SplitPeer("h:1"); id != "h:1" || addr != "h:1" {
+		t.Fatalf("bare: %q %q", id, addr)
+	}
+}
+
+func TestBootstrapServers(t *testing.T) {
+	local := raft.Server{ID: idA, Address: "h1:1"}
+	servers, err := bootstrapServers(local, "n1=h1:1,n2=h2:1,h3:1")
+	if err != nil {
+		t.Fatalf("bootstrap: %v", err)
+	}
+	if len(servers) != 3 || servers[1].ID != idB || servers[2].ID != "h3:1" {
+		t.Fatalf("unexpected servers: %+v", servers)
+	}
+	for _, peers := range []string{"n1=h9:1", "n2=h2:1,n2=h3:1", "n2=h1:1", "n2=h2:1,n3=h2:1"} {
+		if _, err := bootstrapServers(local, peers); !errors.Is(err, ErrPeerConflict) {
+			t.Fatalf("%s: expected ErrPeerConflict, got %v", peers, err)
+		}
+	}
+}
+
+func TestPersistID(t *testing.T) {
+	dir := t.TempDir()
+	if err := persistID(dir, idA); err != nil {
+		t.Fatalf("first start: %v", err)
+	}
+	if err := persistID(dir, idA); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	if err := persistID(dir, idB); !errors.Is(err, ErrIDChanged) {
+		t.Fatalf("expected ErrIDChanged, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"dfs/internal/namespace"
+)
+
+// ErrQuotaExceeded is returned when a put would take a namespace or prefix
+// over its byte or object quota.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Quota limits the keys of Namespace starting with Prefix. A zero limit is
+// unlimited; a quota with both limits zero is removed when set.
+type Quota struct {
+	Namespace  string `json:"namespace,omitempty"`
+	Prefix     string `json:"prefix,omitempty"`
+	MaxBytes   int64  `json:"max_bytes,omitempty"`
+	MaxObjects int64  `json:"max_objects,omitempty"`
+}
+
+// Usage reports a quota together with what its keys currently use. Bytes
+// count the stored, possibly compressed, size of each key's value, so
+// content shared by several keys is charged to each of them.
+type Usage struct {
+	Quota
+	Bytes   int64
+	Objects int64
+}
+
+func (q *Quota) covers(key string) bool {
+	ns, k := namespace.Split(key)
+	return ns == q.Namespace && strings.HasPrefix(k, q.Prefix)
+}
+
+// setQuota adds, replaces or removes the quota for q's namespace and
+// prefix and computes its usage. The caller must hold the write lock.
+func (f *fsm) setQuota(q Quota) {
+	quotas := f.quotas[:0:0]
+	for _, u := range f.quotas {
+		if u.Namespace != q.Namespace || u.Prefix != q.Prefix {
+			quotas = append(quotas, u)
+		}
+	}
+	if q.MaxBytes > 0 || q.MaxObjects > 0 {
+		u := &Usage{Quota: q}
+		for k, h := range f.keys {
+			if q.covers(k) {
+				u.Objects++
+				u.Bytes += int64(len(f.blobs[h].data))
+			}
+		}
+		quotas = append(quotas, u)
+	}
+	f.quotas = quotas
+}
+
+// charge checks that pointing key at content of size bytes fits every
+// quota covering key and records the change. It runs before the put is
+// applied so a rejected put leaves state untouched. The caller must hold
+// the write lock.
+func (f *fsm) charge(key string, size int64) error {
+	var oldSize, newObj int64 = 0, 1
+	if h, ok := f.keys[key]; ok {
+		oldSize, newObj = int64(len(f.blobs[h].data)), 0
+	}
+	var hit []*Usage
+	for _, u := range f.quotas {
+		if !u.covers(key) {
+			continue
+		}
+		delta := size - oldSize
+		if u.MaxBytes > 0 && delta > 0 && u.Bytes+delta > u.MaxBytes {
+			return fmt.Errorf("%w: %q in namespace %q allows %d bytes", ErrQuotaExceeded, u.Prefix, u.Namespace, u.MaxBytes)
+		}
+		if u.MaxObjects > 0 && newObj > 0 && u.Objects+newObj > u.MaxObjects {
+			return fmt.Errorf("%w: %q in namespace %q allows %d objects", ErrQuotaExceeded, u.Prefix, u.Namespace, u.MaxObjects)
+		}
+		hit = append(hit, u)
+	}
+	for _, u := range hit {
+		u.Bytes += size - oldSize
+		u.Objects += newObj
+	}
+	return nil
+}
+
+// release returns the usage of key, which is about to be deleted, to the
+// quotas covering it. The caller must hold the write lock.
+func (f *fsm) release(key string, size int64) {
+	for _, u := range f.quotas {
+		if u.covers(key) {
+			u.Bytes -= size
+			u.Objects--
+		}
+	}
+}
+
+// usage returns copies of the quotas of ns sorted by prefix.
+func (f *fsm) usage(ns string) []Usage {
+	f.mu.RLock()
+	var res []Usage
+	for _, u := range f.quotas {
+		if u.Namespace == ns {
+			res = append(res, *u)
+		}
+	}
+	f.mu.RUnlock()
+	sort.Slice(res, func(i, j int) bool { return res[i].Prefix < res[j].Prefix })
+	return res
+}
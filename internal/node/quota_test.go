@@ -0,0 +1,62 @@
+package node
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestQuotaEnforcedAndRestored(t *testing.T) {
+	n := NewInmem()
+	if err := n.SetQuota(Quota{Prefix: "q/", MaxBytes: 10, MaxObjects: 2}); err != nil {
+		t.Fatalf("set quota: %v", err)
+	}
+	if err := n.Put("q/a", []byte("12345")); err != nil {
+		t.Fatalf("put a: %v", err)
+	}
+	if err := n.Put("q/b", []byte("123456")); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected byte quota error, got %v", err)
+	}
+	if _, ok := n.Get("q/b"); ok {
+		t.Fatalf("rejected put was applied")
+	}
+	if err := n.Put("q/b", []byte("1")); err != nil {
+		t.Fatalf("put b: %v", err)
+	}
+	if err := n.Put("q/c", []byte("1")); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected object quota error, got %v", err)
+	}
+	// Overwrites and keys outside the prefix are not new objects.
+	if err := n.Put("q/a", []byte("123")); err != nil {
+		t.Fatalf("overwrite a: %v", err)
+	}
+	if err := n.Put("other", []byte("123456789012")); err != nil {
+		t.Fatalf("put outside quota: %v", err)
+	}
+	if u := n.Usage(""); len(u) != 1 || u[0].Bytes != 4 || u[0].Objects != 2 {
+		t.Fatalf("usage: %+v", u)
+	}
+
+	s, err := n.fsm.Snapshot()
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	var sink memSink
+	if err := s.Persist(&sink); err != nil {
+		t.Fatalf("persist: %v", err)
+	}
+	n2 := NewInmem()
+	if err := n2.fsm.Restore(io.NopCloser(&sink)); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if u := n2.Usage(""); len(u) != 1 || u[0].Bytes != 4 || u[0].Objects != 2 {
+		t.Fatalf("usage after restore: %+v", u)
+	}
+	n2.Delete("q/b")
+	if err := n2.Put("q/c", []byte("1")); err != nil {
+		t.Fatalf("put after delete: %v", err)
+	}
+	if err := n2.SetQuota(Quota{Prefix: "q/"}); err != nil || len(n2.Usage("")) != 0 {
+		t.Fatalf("remove quota: %v %+v", err, n2.Usage(""))
+	}
+}
@@ -0,0 +1,116 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/raft"
+)
+
+// PeersFile is the file in the data directory that lists the servers of
+// the recovered cluster, in the format read by raft.ReadConfigJSON.
+const PeersFile = "peers.json"
+
+var errNoState = errors.New("recover: no raft state in the data directory")
+
+// RecoverSummary describes the local Raft state before and after Recover.
+type RecoverSummary struct {
+	SnapshotIndex uint64   // newest snapshot before recovery
+	LastLogIndex  uint64   // last log entry before recovery
+	AppliedIndex  uint64   // index covered by the recovery snapshot
+	Previous      []Member // configuration found in the local state
+	Recovered     []Member // configuration from PeersFile
+}
+
+// Recover replaces the cluster configuration stored in dataDir with the
+// servers listed in its PeersFile, for use when quorum is permanently
+// lost. The node must not be running. Every listed server must appear in
+// the local configuration and the list must include id. The local
+// snapshot and log are applied and compacted into a new snapshot, and
+// PeersFile is removed so the recovery runs once. Run it with the same
+// file on every surviving server before restarting them.
+func Recover(id, dataDir string, opts ...Option) (RecoverSummary, error) {
+	var sum RecoverSummary
+	path := filepath.Join(dataDir, PeersFile)
+	recovered, err := raft.ReadConfigJSON(path)
+	if err != nil {
+		return sum, fmt.Errorf("recover: %s: %w", PeersFile, err)
+	}
+	n, err := newNode(opts)
+	if err != nil {
+		return sum, err
+	}
+	snaps, logs, stable, err := openStores(dataDir, id, n.tuning.retain(), n.raftLogger())
+	if err != nil {
+		return sum, err
+	}
+	defer logs.Close()
+	defer stable.Close()
+	if ok, err := raft.HasExistingState(logs, stable, snaps); err != nil {
+		return sum, err
+	} else if !ok {
+		return sum, errNoState
+	}
+	cfg := n.tuning.raftConfig(id)
+	cfg.Logger = n.raftLogger()
+	_, trans := raft.NewInmemTransport(raft.ServerAddress(id))
+	previous, err := raft.GetConfiguration(cfg, n.fsm, logs, stable, snaps, trans)
+	if err != nil {
+		return sum, fmt.Errorf("recover: read configuration: %w", err)
+	}
+	sum.Previous, sum.Recovered = members(previous), members(recovered)
+	if err := checkRecovery(id, previous, recovered); err != nil {
+		return sum, err
+	}
+	if sum.SnapshotIndex, err = snapshotIndex(snaps); err != nil {
+		return sum, err
+	}
+	if sum.LastLogIndex, err = logs.LastIndex(); err != nil {
+		return sum, err
+	}
+	if err := raft.RecoverCluster(cfg, n.fsm, logs, stable, snaps, trans, recovered); err != nil {
+		return sum, fmt.Errorf("recover: %w", err)
+	}
+	if sum.AppliedIndex, err = snapshotIndex(snaps); err != nil {
+		return sum, err
+	}
+	return sum, os.Remove(path)
+}
+
+// checkRecovery validates the recovered configuration against the one
+// found in the local snapshot and logs.
+func checkRecovery(id string, previous, recovered raft.Configuration) error {
+	known := map[raft.ServerID]bool{}
+	for _, s := range previous.Servers {
+		known[s.ID] = true
+	}
+	self := false
+	for _, s := range recovered.Servers {
+		if !known[s.ID] {
+			return fmt.Errorf("recover: %w: %s is not in the local configuration", ErrUnknownPeer, s.ID)
+		}
+		self = self || s.ID == raft.ServerID(id)
+	}
+	if !self {
+		return fmt.Errorf("recover: %s does not list this node %s", PeersFile, id)
+	}
+	return nil
+}
+
+func snapshotIndex(snaps raft.SnapshotStore) (uint64, error) {
+	list, err := snaps.List()
+	if err != nil || len(list) == 0 {
+		return 0, err
+	}
+	return list[0].Index, nil
+}
+
+func members(c raft.Configuration) []Member {
+	var out []Member
+	for _, s := range c.Servers {
+		out = append(out, Member{ID: string(s.ID), Address: string(s.Address), Suffrage: s.Suffrage.String()})
+	}
+	return out
+}
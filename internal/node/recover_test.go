@@ -0,0 +1,96 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writePeers writes a peers.json listing the given id and address pairs.
+func writePeers(t *testing.T, dir string, servers ...[2]string) {
+	t.Helper()
+	b := []byte("[")
+	for i, s := range servers {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = fmt.Appendf(b, `{"id":%q,"address":%q}`, s[0], s[1])
+	}
+	b = append(b, ']')
+	if err := os.WriteFile(filepath.Join(dir, PeersFile), b, 0o600); err != nil {
+		t.Fatalf("write peers: %v", err)
+	}
+}
+
+// stop shuts n down and closes its stores so the data directory can be
+// opened again.
+func stop(t *testing.T, n *Node) {
+	t.Helper()
+	if err := n.raft.Shutdown().Error(); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	n.logs.Close()
+	n.stable.Close()
+}
+
+func TestRecover(t *testing.T) {
+	dir := t.TempDir()
+	addr1, addr2 := getFreePort(t), getFreePort(t)
+	n, err := New(idA, addr1, dir, empty, true)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if waitLeader(n) != n {
+		t.Fatalf("no leader")
+	}
+	if err := n.Put("k", []byte("v")); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	// n2 never starts: losing it leaves the two node cluster without
+	// quorum.
+	if err := n.AddPeer(idB, addr2); err != nil {
+		t.Fatalf("add peer: %v", err)
+	}
+	stop(t, n)
+
+	if _, err := Recover(idA, dir); err == nil {
+		t.Fatalf("expected error without %s", PeersFile)
+	}
+	writePeers(t, dir, [2]string{idA, addr1}, [2]string{"n9", addr2})
+	if _, err := Recover(idA, dir); !errors.Is(err, ErrUnknownPeer) {
+		t.Fatalf("expected ErrUnknownPeer, got %v", err)
+	}
+	writePeers(t, dir, [2]string{idA, addr1})
+	sum, err := Recover(idA, dir)
+	if err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	if len(sum.Previous) != 2 || len(sum.Recovered) != 1 || sum.AppliedIndex < sum.LastLogIndex || sum.AppliedIndex == 0 {
+		t.Fatalf("unexpected summary: %+v", sum)
+	}
+	if _, err := os.Stat(filepath.Join(dir, PeersFile)); !os.IsNotExist(err) {
+		t.Fatalf("%s not removed: %v", PeersFile, err)
+	}
+
+	n, err = New(idA, addr1, dir, empty, false)
+	if err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	defer n.raft.Shutdown()
+	deadline := time.Now().Add(time.Duration(timeout) * time.Second)
+	for !n.IsLeader() && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if !n.IsLeader() {
+		t.Fatalf("recovered node not elected")
+	}
+	if v, ok := n.Get("k"); !ok || string(v) != "v" {
+		t.Fatalf("data lost: %q %v", v, ok)
+	}
+	if err := n.Put("k2", []byte("v2")); err != nil {
+		t.Fatalf("put after recovery: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package node
+
+import (
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// SetAdmins replaces the identities with admin rights regardless of the
+// replicated ACL.
+func (n *Node) SetAdmins(ids ...string) {
+	admins := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		admins[id] = true
+	}
+	n.admins.Store(&admins)
+}
+
+// SetGCInterval changes the period of the garbage collection started by
+// StartGC.
+func (n *Node) SetGCInterval(d time.Duration) {
+	if n.gcEvery != nil {
+		n.gcEvery.set(d)
+	}
+}
+
+// SetKeyInterval changes the period of the key rotation check started by
+// StartKeyRotation.
+func (n *Node) SetKeyInterval(d time.Duration) {
+	if n.keyEvery != nil {
+		n.keyEvery.set(d)
+	}
+}
+
+// ReloadTuning applies the heartbeat and election timeouts, the snapshot
+// interval and threshold and the trailing logs of t to the running Raft
+// instance without affecting leadership. Zero fields restore the Raft
+// defaults. The other fields take effect on restart.
+func (n *Node) ReloadTuning(t Tuning) error {
+	n.snapMu.Lock()
+	defer n.snapMu.Unlock()
+	cfg := t.raftConfig(n.id)
+	return n.raft.ReloadConfig(raft.ReloadableConfig{
+		TrailingLogs:      cfg.TrailingLogs,
+		SnapshotInterval:  cfg.SnapshotInterval,
+		SnapshotThreshold: cfg.SnapshotThreshold,
+		HeartbeatTimeout:  cfg.HeartbeatTimeout,
+		ElectionTimeout:   cfg.ElectionTimeout,
+	})
+}
@@ -0,0 +1,56 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"dfs/internal/auth"
+)
+
+func TestReload(t *testing.T) {
+	n, err := New(idA, getFreePort(t), t.TempDir(), empty, true, WithAdmins("root"))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer n.Shutdown(context.Background())
+	if waitLeader(n) != n {
+		t.Fatalf("not leader")
+	}
+
+	if !n.Authorize("root", empty, auth.Admin) || n.Authorize("ops", empty, auth.Admin) {
+		t.Fatalf("unexpected admins before reload")
+	}
+	n.SetAdmins("ops")
+	if n.Authorize("root", empty, auth.Admin) || !n.Authorize("ops", empty, auth.Admin) {
+		t.Fatalf("unexpected admins after reload")
+	}
+
+	if err := n.ReloadTuning(Tuning{TrailingLogs: 5, HeartbeatTimeout: 2 * time.Second, ElectionTimeout: 2 * time.Second}); err != nil {
+		t.Fatalf("reload tuning: %v", err)
+	}
+	rc := n.raft.ReloadableConfig()
+	if rc.TrailingLogs != 5 || rc.HeartbeatTimeout != 2*time.Second || !n.IsLeader() {
+		t.Fatalf("unexpected raft config %+v, leader %v", rc, n.IsLeader())
+	}
+
+	// Garbage left by an overwrite is collected soon after the interval
+	// is shortened.
+	n.StartGC(time.Hour)
+	h := sha256.Sum256([]byte(dupData))
+	if err := n.Put(keyDupA, []byte(dupData)); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if err := n.Put(keyDupA, []byte("other")); err != nil {
+		t.Fatalf("overwrite: %v", err)
+	}
+	n.SetGCInterval(10 * time.Millisecond)
+	deadline := time.Now().Add(time.Duration(timeout) * time.Second)
+	for n.HasContent(h) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n.HasContent(h) {
+		t.Fatalf("gc did not run at the new interval")
+	}
+}
@@ -0,0 +1,81 @@
+package node
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/raft"
+
+	"dfs/internal/logging"
+)
+
+// Shutdown stops the node in order: the background loops stop, a leader
+// waits until its pending applies are committed and hands leadership to
+// another voter, Raft shuts down and the log and stable stores are
+// closed. When ctx is done first only the waiting for the hand-off is cut
+// short: Raft is still shut down and the stores closed, and ctx's error is
+// returned.
+func (n *Node) Shutdown(ctx context.Context) error {
+	n.stopOnce.Do(func() { close(n.done) })
+	if n.raft == nil {
+		return nil
+	}
+	var err error
+	if n.IsLeader() {
+		err = n.handOff(ctx)
+	}
+	// The stores are only closed once Raft has stopped using them.
+	return errors.Join(err, n.raft.Shutdown().Error(), n.logs.Close(), n.stable.Close())
+}
+
+// handOff waits until the leader's pending applies are committed and
+// hands leadership to another voter. It returns ctx's error when ctx is
+// done first.
+func (n *Node) handOff(ctx context.Context) error {
+	if err := wait(ctx, n.raft.Barrier(0)); err != nil {
+		return err
+	}
+	// A failed transfer is not fatal: the followers elect a new leader
+	// once heartbeats stop.
+	if !n.hasOtherVoter() {
+		return nil
+	}
+	n.log.Info("transferring leadership")
+	if err := wait(ctx, n.raft.LeadershipTransfer()); err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		n.log.Warn("leadership transfer", logging.Err(err))
+	}
+	return nil
+}
+
+// hasOtherVoter reports whether the configuration has a voter besides
+// this node to hand leadership to.
+func (n *Node) hasOtherVoter() bool {
+	f := n.raft.GetConfiguration()
+	if f.Error() != nil {
+		return false
+	}
+	for _, s := range f.Configuration().Servers {
+		if s.ID != raft.ServerID(n.id) && s.Suffrage == raft.Voter {
+			return true
+		}
+	}
+	return false
+}
+
+// wait returns the error of f, or ctx's error when ctx is done first.
+func wait(ctx context.Context, f raft.Future) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- f.Error() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
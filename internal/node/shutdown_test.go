@@ -0,0 +1,100 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestShutdownHandsOffLeadership(t *testing.T) {
+	var nodes []*Node
+	var addrs []string
+	for i := 0; i < 3; i++ {
+		addrs = append(addrs, getFreePort(t))
+	}
+	dirs := []string{t.TempDir(), t.TempDir(), t.TempDir()}
+	for i, addr := range addrs {
+		n, err := New(addr, addr, dirs[i], addrs[(i+1)%3]+sepComma+addrs[(i+2)%3], true)
+		if err != nil {
+			t.Fatalf("node %d: %v", i, err)
+		}
+		nodes = append(nodes, n)
+	}
+	leader := waitLeader(nodes...)
+	if leader == nil {
+		t.Fatalf("no leader")
+	}
+	var rest []*Node
+	for _, n := range nodes {
+		if n != leader {
+			rest = append(rest, n)
+			defer n.Shutdown(context.Background())
+		}
+	}
+	if err := leader.Put("k", []byte("v")); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+	if err := leader.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	select {
+	case <-leader.done:
+	default:
+		t.Fatalf("background loops not stopped")
+	}
+	next := waitLeader(rest...)
+	if next == nil {
+		t.Fatalf("no new leader")
+	}
+	if v, ok := next.Get("k"); !ok || string(v) != "v" {
+		t.Fatalf("new leader missing committed value: %q ok=%v", v, ok)
+	}
+
+	// The stores are closed, so the data directory can be opened again.
+	i := 0
+	for nodes[i] != leader {
+		i++
+	}
+	n, err := New(addrs[i], addrs[i], dirs[i], empty, false)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if err := n.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown reopened: %v", err)
+	}
+}
+
+func TestShutdownInmem(t *testing.T) {
+	n := NewInmem()
+	if err := n.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+}
+
+func TestShutdownClosesStoresAfterDeadline(t *testing.T) {
+	dir := t.TempDir()
+	addr := getFreePort(t)
+	n, err := New(idA, addr, dir, empty, true)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if waitLeader(n) != n {
+		t.Fatalf("no leader")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := n.Shutdown(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if _, err := n.logs.LastIndex(); err == nil {
+		t.Fatalf("log store left open")
+	}
+	reopened, err := New(idA, addr, dir, empty, false)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	reopened.Shutdown(context.Background())
+}
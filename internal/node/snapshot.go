@@ -0,0 +1,110 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/hashicorp/raft"
+
+	"dfs/internal/logging"
+)
+
+// ErrUnknownSnapshot is returned for a snapshot id not kept on disk.
+var ErrUnknownSnapshot = errors.New("unknown snapshot")
+
+// ErrNoSnapshot is returned when there is no snapshot to open.
+var ErrNoSnapshot = errors.New("no snapshot")
+
+// ErrNothingToSnapshot is returned by Snapshot before anything was
+// applied.
+var ErrNothingToSnapshot = raft.ErrNothingNewToSnapshot
+
+// SnapshotMeta describes a snapshot kept in the data directory.
+type SnapshotMeta struct {
+	ID    string
+	Index uint64
+	Term  uint64
+	Size  int64
+}
+
+func snapshotMeta(m *raft.SnapshotMeta) SnapshotMeta {
+	return SnapshotMeta{ID: m.ID, Index: m.Index, Term: m.Term, Size: m.Size}
+}
+
+// Snapshot snapshots the node's state and truncates its log, keeping the
+// configured number of trailing entries. With compact set no trailing
+// entries are kept, so a follower that falls behind must install the
+// snapshot. If the trailing entries setting cannot be restored afterwards
+// that error is logged and returned as well.
+func (n *Node) Snapshot(compact bool) (_ SnapshotMeta, err error) {
+	n.snapMu.Lock()
+	defer n.snapMu.Unlock()
+	if compact {
+		rc := n.raft.ReloadableConfig()
+		trailing := rc.TrailingLogs
+		rc.TrailingLogs = 0
+		if err := n.raft.ReloadConfig(rc); err != nil {
+			return SnapshotMeta{}, err
+		}
+		defer func() {
+			rc := n.raft.ReloadableConfig()
+			rc.TrailingLogs = trailing
+			if rerr := n.raft.ReloadConfig(rc); rerr != nil {
+				n.log.Error("restore trailing logs after compaction", "trailing_logs", trailing, logging.Err(rerr))
+				err = errors.Join(err, fmt.Errorf("restore trailing logs: %w", rerr))
+			}
+		}()
+	}
+	f := n.raft.Snapshot()
+	if err := f.Error(); err != nil {
+		return SnapshotMeta{}, err
+	}
+	meta, rc, err := f.Open()
+	if err != nil {
+		return SnapshotMeta{}, err
+	}
+	rc.Close()
+	return snapshotMeta(meta), nil
+}
+
+// Snapshots lists the snapshots kept in the data directory, newest
+// first.
+func (n *Node) Snapshots() ([]SnapshotMeta, error) {
+	list, err := n.snaps.List()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]SnapshotMeta, 0, len(list))
+	for _, m := range list {
+		out = append(out, snapshotMeta(m))
+	}
+	return out, nil
+}
+
+// OpenSnapshot opens the snapshot id, or the newest one when id is empty.
+// The caller must close the returned reader.
+func (n *Node) OpenSnapshot(id string) (SnapshotMeta, io.ReadCloser, error) {
+	list, err := n.Snapshots()
+	if err != nil {
+		return SnapshotMeta{}, nil, err
+	}
+	if len(list) == 0 {
+		return SnapshotMeta{}, nil, ErrNoSnapshot
+	}
+	if id == "" {
+		id = list[0].ID
+	}
+	found := false
+	for _, m := range list {
+		found = found || m.ID == id
+	}
+	if !found {
+		return SnapshotMeta{}, nil, fmt.Errorf("%w: %s", ErrUnknownSnapshot, id)
+	}
+	meta, rc, err := n.snaps.Open(id)
+	if err != nil {
+		return SnapshotMeta{}, nil, err
+	}
+	return snapshotMeta(meta), rc, nil
+}
@@ -0,0 +1,67 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	n, err := New(idA, getFreePort(t), t.TempDir(), empty, true, WithTuning(Tuning{SnapshotRetain: 2}))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer n.raft.Shutdown()
+	if waitLeader(n) != n {
+		t.Fatalf("not leader")
+	}
+	if _, _, err := n.OpenSnapshot(empty); !errors.Is(err, ErrNoSnapshot) {
+		t.Fatalf("expected no snapshot, got %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := n.Put(fmt.Sprintf("k%d", i), []byte("v")); err != nil {
+			t.Fatalf("put: %v", err)
+		}
+	}
+	first, err := n.Snapshot(false)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if first.Index == 0 || first.Size == 0 {
+		t.Fatalf("unexpected snapshot: %+v", first)
+	}
+	if err := n.Put("last", []byte("v")); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	second, err := n.Snapshot(true)
+	if err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	if first, err := n.logs.FirstIndex(); err != nil || first != 0 && first <= second.Index {
+		t.Fatalf("log not compacted: first index %d, snapshot %d: %v", first, second.Index, err)
+	}
+	if rc := n.raft.ReloadableConfig(); rc.TrailingLogs == 0 {
+		t.Fatalf("trailing logs not restored")
+	}
+
+	list, err := n.Snapshots()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list) != 2 || list[0] != second || list[1] != first {
+		t.Fatalf("unexpected snapshots: %+v", list)
+	}
+	meta, rc, err := n.OpenSnapshot(empty)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || meta != second || int64(len(data)) != meta.Size {
+		t.Fatalf("read %d bytes of %+v: %v", len(data), meta, err)
+	}
+	if _, _, err := n.OpenSnapshot("missing"); !errors.Is(err, ErrUnknownSnapshot) {
+		t.Fatalf("expected unknown snapshot, got %v", err)
+	}
+}
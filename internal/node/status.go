@@ -0,0 +1,76 @@
+package node
+
+import (
+	"errors"
+	"strconv"
+)
+
+var errNoRaft = errors.New("node has no raft instance")
+
+// Member is a server in the Raft configuration.
+type Member struct {
+	ID       string
+	Address  string
+	Suffrage string // Voter, Nonvoter or Staging
+	// APIAddress is the gRPC address the server advertised, if known.
+	APIAddress string
+}
+
+// Status describes this node's view of the cluster.
+type Status struct {
+	ID            string
+	State         string
+	Term          uint64
+	LastLogIndex  uint64
+	CommitIndex   uint64
+	AppliedIndex  uint64
+	ApplyLag      uint64 // committed entries not yet applied here
+	LastContact   string // time since the leader was last heard from
+	LeaderID      string
+	LeaderAddress string
+	// LeaderAPIAddress is the leader's advertised gRPC address, if known.
+	LeaderAPIAddress string
+	Members          []Member
+	// KeyIDs are the ids of the encryption keys this node holds.
+	KeyIDs []string
+}
+
+// Status reports the Raft configuration, leader and this node's log
+// positions as returned by raft.Stats.
+func (n *Node) Status() (Status, error) {
+	if n.raft == nil {
+		return Status{}, errNoRaft
+	}
+	f := n.raft.GetConfiguration()
+	if err := f.Error(); err != nil {
+		return Status{}, err
+	}
+	stats := n.raft.Stats()
+	addr, id := n.raft.LeaderWithID()
+	st := Status{
+		ID:            n.id,
+		State:         stats["state"],
+		Term:          statUint(stats, "term"),
+		LastLogIndex:  statUint(stats, "last_log_index"),
+		CommitIndex:   statUint(stats, "commit_index"),
+		AppliedIndex:  statUint(stats, "applied_index"),
+		LastContact:   stats["last_contact"],
+		LeaderID:      string(id),
+		LeaderAddress: string(addr),
+	}
+	st.LeaderAPIAddress = n.APIAddress(st.LeaderID)
+	if st.CommitIndex > st.AppliedIndex {
+		st.ApplyLag = st.CommitIndex - st.AppliedIndex
+	}
+	st.KeyIDs = n.keyIDs()
+	st.Members = members(f.Configuration())
+	for i := range st.Members {
+		st.Members[i].APIAddress = n.APIAddress(st.Members[i].ID)
+	}
+	return st, nil
+}
+
+func statUint(stats map[string]string, key string) uint64 {
+	v, _ := strconv.ParseUint(stats[key], 10, 64)
+	return v
+}
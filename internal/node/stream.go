@@ -1,6 +1,7 @@
 package node
 
 import (
+	"crypto/tls"
 	"net"
 	"time"
 
@@ -9,8 +10,29 @@ import (
 
 type streamLayer struct {
 	net.Listener
+	tls       func() *tls.Config
+	advertise string // address given to peers instead of the bound one
 }
 
+// Addr returns the advertised address, which Raft stores in the cluster
+// configuration, or the listener's address.
+func (s *streamLayer) Addr() net.Addr {
+	if s.advertise != "" {
+		return advertisedAddr(s.advertise)
+	}
+	return s.Listener.Addr()
+}
+
+// advertisedAddr is a net.Addr for an address that need not be local.
+type advertisedAddr string
+
+func (a advertisedAddr) Network() string { return networkTCP }
+func (a advertisedAddr) String() string  { return string(a) }
+
 func (s *streamLayer) Dial(address raft.ServerAddress, timeout time.Duration) (net.Conn, error) {
+	if s.tls != nil {
+		d := &net.Dialer{Timeout: timeout}
+		return tls.DialWithDialer(d, networkTCP, string(address), s.tls())
+	}
 	return net.DialTimeout(networkTCP, string(address), timeout)
 }
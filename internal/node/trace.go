@@ -0,0 +1,94 @@
+package node
+
+import (
+	"context"
+
+	"github.com/hashicorp/raft"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer starts the node's spans. It follows the global tracer provider,
+// so spans are dropped until the program installs one.
+var tracer = otel.Tracer("dfs/internal/node")
+
+const (
+	spanPut      = "node.put"
+	spanApply    = "node.apply"
+	spanFSMApply = "fsm.apply"
+)
+
+// Span attribute keys.
+const (
+	attrOp    = "dfs.op"
+	attrSize  = "dfs.size"
+	attrCodec = "dfs.codec"
+	attrDedup = "dfs.dedup"
+	attrIndex = "raft.index"
+)
+
+// traceFormat encodes the span context carried by replicated commands.
+// It is fixed rather than taken from the global propagator because the
+// entries outlive the process that wrote them.
+var traceFormat propagation.TraceContext
+
+var opNames = [...]string{
+	opPut:           "put",
+	opDelete:        "delete",
+	opMeta:          "meta",
+	opPutHash:       "put_hash",
+	opGC:            "gc",
+	opGrant:         "grant",
+	opRevoke:        "revoke",
+	opNSCreate:      "namespace_create",
+	opNSDelete:      "namespace_delete",
+	opQuota:         "quota",
+	opAPIAddr:       "api_address",
+	opKey:           "key",
+	opPendingAdd:    "pending_add",
+	opPendingRemove: "pending_remove",
+}
+
+func (o op) String() string {
+	if int(o) < len(opNames) {
+		return opNames[o]
+	}
+	return "unknown"
+}
+
+// inject records the span context of ctx in c so that every server
+// applying c can continue the trace.
+func inject(ctx context.Context, c *command) {
+	if !trace.SpanContextFromContext(ctx).IsSampled() {
+		return
+	}
+	carrier := propagation.MapCarrier{}
+	traceFormat.Inject(ctx, carrier)
+	c.Trace = carrier
+}
+
+// startApply starts the span of a server applying log when c carries a
+// trace. The returned span is a no-op otherwise.
+func (f *fsm) startApply(log *raft.Log, c *command) trace.Span {
+	if len(c.Trace) == 0 {
+		return trace.SpanFromContext(context.Background())
+	}
+	ctx := traceFormat.Extract(context.Background(), propagation.MapCarrier(c.Trace))
+	_, span := tracer.Start(ctx, spanFSMApply, trace.WithAttributes(
+		attribute.String(attrOp, c.Op.String()),
+		attribute.Int64(attrIndex, int64(log.Index)),
+	))
+	return span
+}
+
+// endSpan ends span, marking it failed with err when set.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,92 @@
+package node
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	recorder     = tracetest.NewSpanRecorder()
+	recorderOnce sync.Once
+)
+
+// traced returns a context with a new root span recorded along with its
+// descendants, and a function ending the root and returning the spans of
+// its trace by name. Tracers bind to the first global provider, so all
+// tests share one recorder.
+func traced(t *testing.T) (context.Context, func() map[string]sdktrace.ReadOnlySpan) {
+	t.Helper()
+	recorderOnce.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	})
+	ctx, root := otel.Tracer("test").Start(context.Background(), t.Name())
+	return ctx, func() map[string]sdktrace.ReadOnlySpan {
+		root.End()
+		out := map[string]sdktrace.ReadOnlySpan{}
+		for _, s := range recorder.Ended() {
+			if s.SpanContext().TraceID() == root.SpanContext().TraceID() {
+				out[s.Name()] = s
+			}
+		}
+		return out
+	}
+}
+
+func spanAttr(s sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, kv := range s.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func parentOf(s sdktrace.ReadOnlySpan) trace.SpanID { return s.Parent().SpanID() }
+
+func TestApplyTrace(t *testing.T) {
+	n, err := New(idA, getFreePort(t), t.TempDir(), empty, true)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer n.raft.Shutdown()
+	if waitLeader(n) != n {
+		t.Fatalf("not leader")
+	}
+	ctx, spans := traced(t)
+	if err := n.PutContext(ctx, "k", []byte("v")); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if err := n.PutContext(ctx, "k2", []byte("v")); err != nil {
+		t.Fatalf("put dedup: %v", err)
+	}
+	got := spans()
+
+	put, apply, fsmApply := got[spanPut], got[spanApply], got[spanFSMApply]
+	if put == nil || apply == nil || fsmApply == nil {
+		t.Fatalf("missing spans: %v", got)
+	}
+	if parentOf(apply) != put.SpanContext().SpanID() || parentOf(fsmApply) != apply.SpanContext().SpanID() {
+		t.Fatalf("spans not nested: put %v, apply parent %v, fsm parent %v", put.SpanContext().SpanID(), parentOf(apply), parentOf(fsmApply))
+	}
+	if spanAttr(apply, attrIndex).AsInt64() == 0 || spanAttr(apply, attrIndex) != spanAttr(fsmApply, attrIndex) {
+		t.Fatalf("unexpected indexes: %v %v", spanAttr(apply, attrIndex), spanAttr(fsmApply, attrIndex))
+	}
+	// The spans of the second put, recorded last, reference the content.
+	if !spanAttr(put, attrDedup).AsBool() || spanAttr(apply, attrOp).AsString() != opPutHash.String() {
+		t.Fatalf("unexpected dedup spans: %v %v", put.Attributes(), apply.Attributes())
+	}
+
+	// Untraced writes carry no span context into the log.
+	c := &command{Op: opDelete}
+	inject(context.Background(), c)
+	if c.Trace != nil {
+		t.Fatalf("untraced command carries %v", c.Trace)
+	}
+}
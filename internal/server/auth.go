@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"dfs/internal/auth"
+	pb "dfs/proto"
+)
+
+const (
+	errUnauthenticated = "unauthenticated"
+	errDenied          = "%s lacks %s on %q"
+	errBadRule         = "bad acl rule"
+	errBadPerm         = "bad permission %v"
+	adminPath          = ""
+)
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithAuth requires every call to be authenticated by a and checks the
+// caller against the node's ACL. Install Server.Authenticate and
+// Server.AuthenticateStream as the gRPC interceptors for it to take
+// effect.
+func WithAuth(a *auth.Authenticator) Option {
+	return func(s *Server) { s.auth = a }
+}
+
+// Authenticate is a gRPC unary interceptor that resolves the caller
+// identity and stores it in the request context. It passes calls through
+// unchanged when authentication is disabled.
+func (s *Server) Authenticate(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if s.auth == nil {
+		return handler(ctx, req)
+	}
+	id, ok := s.auth.Identify(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, errUnauthenticated)
+	}
+	return handler(auth.WithIdentity(ctx, id), req)
+}
+
+// AuthenticateStream is the streaming counterpart of Authenticate.
+func (s *Server) AuthenticateStream(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if s.auth == nil {
+		return handler(srv, ss)
+	}
+	id, ok := s.auth.Identify(ss.Context())
+	if !ok {
+		return status.Errorf(codes.Unauthenticated, errUnauthenticated)
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: auth.WithIdentity(ss.Context(), id)})
+}
+
+// contextStream replaces the context of a stream, to carry the caller
+// identity or the request id.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context { return s.ctx }
+
+// authorize checks that the caller holds p on path.
+func (s *Server) authorize(ctx context.Context, path string, p auth.Perm) error {
+	if s.auth == nil {
+		return nil
+	}
+	id, ok := auth.IdentityFrom(ctx)
+	if !ok {
+		return status.Errorf(codes.Unauthenticated, errUnauthenticated)
+	}
+	if !s.node.Authorize(id, path, p) {
+		return status.Errorf(codes.PermissionDenied, errDenied, id, p, path)
+	}
+	return nil
+}
+
+// allowed reports whether the caller may read path without failing the
+// request; it is used to filter listings.
+func (s *Server) allowed(ctx context.Context, path string) bool {
+	return s.authorize(ctx, path, auth.Read) == nil
+}
+
+// Grant adds or replaces an ACL rule granting read, write or admin. It
+// requires admin rights.
+func (s *Server) Grant(ctx context.Context, req *pb.GrantRequest) (*pb.GrantResponse, error) {
+	r, err := s.rule(ctx, req.Rule)
+	if err != nil {
+		return nil, err
+	}
+	if r.Perm == auth.None {
+		return nil, status.Errorf(codes.InvalidArgument, errBadPerm, req.Rule.Perm)
+	}
+	if err := s.node.Grant(r); err != nil {
+		return nil, applyErr(err)
+	}
+	return &pb.GrantResponse{}, nil
+}
+
+// Revoke removes an ACL rule. It requires admin rights.
+func (s *Server) Revoke(ctx context.Context, req *pb.RevokeRequest) (*pb.RevokeResponse, error) {
+	r, err := s.rule(ctx, req.Rule)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.node.Revoke(r); err != nil {
+		return nil, status.Errorf(codes.Internal, errInternal, err)
+	}
+	return &pb.RevokeResponse{}, nil
+}
+
+// rule validates an ACL change and converts the rule. It requires admin
+// rights in the rule's namespace.
+func (s *Server) rule(ctx context.Context, r *pb.ACLRule) (auth.Rule, error) {
+	if r == nil || r.Identity == "" {
+		return auth.Rule{}, status.Errorf(codes.InvalidArgument, errBadRule)
+	}
+	if r.Perm < pb.Permission_PERMISSION_NONE || r.Perm > pb.Permission_PERMISSION_ADMIN {
+		return auth.Rule{}, status.Errorf(codes.InvalidArgument, errBadPerm, r.Perm)
+	}
+	nsAdmin, err := s.scope(r.Namespace, adminPath)
+	if err != nil {
+		return auth.Rule{}, err
+	}
+	if err := s.authorize(ctx, nsAdmin, auth.Admin); err != nil {
+		return auth.Rule{}, err
+	}
+	if !s.node.IsLeader() {
+		return auth.Rule{}, status.Errorf(codes.FailedPrecondition, errNotLeader, s.leader())
+	}
+	return auth.Rule{Identity: r.Identity, Namespace: r.Namespace, Prefix: r.Prefix, Perm: auth.Perm(r.Perm)}, nil
+}
+
+// ListACL returns the replicated ACL rules of one namespace. It requires
+// admin rights in that namespace.
+func (s *Server) ListACL(ctx context.Context, req *pb.ListACLRequest) (*pb.ListACLResponse, error) {
+	nsAdmin, err := s.scope(req.Namespace, adminPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, nsAdmin, auth.Admin); err != nil {
+		return nil, err
+	}
+	resp := &pb.ListACLResponse{}
+	for _, r := range s.node.ACL() {
+		if r.Namespace == req.Namespace {
+			resp.Rules = append(resp.Rules, &pb.ACLRule{Identity: r.Identity, Namespace: r.Namespace, Prefix: r.Prefix, Perm: pb.Permission(r.Perm)})
+		}
+	}
+	return resp, nil
+}
+
+// GC frees unreferenced content across the cluster. It requires admin
+// rights and must be sent to the leader.
+func (s *Server) GC(ctx context.Context, req *pb.GCRequest) (*pb.GCResponse, error) {
+	if err := s.authorize(ctx, adminPath, auth.Admin); err != nil {
+		return nil, err
+	}
+	if !s.node.IsLeader() {
+		return nil, status.Errorf(codes.FailedPrecondition, errNotLeader, s.leader())
+	}
+	if err := s.node.GC(); err != nil {
+		return nil, status.Errorf(codes.Internal, errInternal, err)
+	}
+	s.node.Meta.GC()
+	return &pb.GCResponse{}, nil
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"dfs/internal/auth"
+	"dfs/internal/node"
+	pb "dfs/proto"
+)
+
+// ClusterStatus returns the Raft configuration and this node's state. Any
+// node can answer; ask each node for its own log positions. It requires
+// admin rights.
+func (s *Server) ClusterStatus(ctx context.Context, req *pb.ClusterStatusRequest) (*pb.ClusterStatusResponse, error) {
+	if err := s.authorize(ctx, adminPath, auth.Admin); err != nil {
+		return nil, err
+	}
+	st, err := s.node.Status()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, errInternal, err)
+	}
+	resp := &pb.ClusterStatusResponse{
+		Id:               st.ID,
+		State:            st.State,
+		Term:             st.Term,
+		LastLogIndex:     st.LastLogIndex,
+		CommitIndex:      st.CommitIndex,
+		AppliedIndex:     st.AppliedIndex,
+		ApplyLag:         st.ApplyLag,
+		LastContact:      st.LastContact,
+		LeaderId:         st.LeaderID,
+		LeaderAddress:    st.LeaderAddress,
+		LeaderApiAddress: st.LeaderAPIAddress,
+		KeyIds:           st.KeyIDs,
+	}
+	for _, m := range st.Members {
+		resp.Members = append(resp.Members, &pb.Member{Id: m.ID, Address: m.Address, Suffrage: m.Suffrage, ApiAddress: m.APIAddress})
+	}
+	for _, h := range s.node.Health() {
+		ph := &pb.ServerHealth{Id: h.ID, Healthy: h.Healthy, LastContact: h.LastContact.String(), TrailingLogs: h.TrailingLogs}
+		if !h.StableSince.IsZero() {
+			ph.StableSince = h.StableSince.Format(time.RFC3339)
+		}
+		resp.Health = append(resp.Health, ph)
+	}
+	return resp, nil
+}
+
+// TransferLeadership asks the leader to step down in favour of another
+// voter. It requires admin rights.
+func (s *Server) TransferLeadership(ctx context.Context, req *pb.TransferLeadershipRequest) (*pb.TransferLeadershipResponse, error) {
+	if err := s.authorize(ctx, adminPath, auth.Admin); err != nil {
+		return nil, err
+	}
+	if !s.node.IsLeader() {
+		return nil, status.Errorf(codes.FailedPrecondition, errNotLeader, s.leader())
+	}
+	if err := s.node.TransferLeadership(req.Id); err != nil {
+		return nil, peerErr(err)
+	}
+	return &pb.TransferLeadershipResponse{}, nil
+}
+
+// PromotePeer turns a nonvoter into a voter. It requires admin rights.
+func (s *Server) PromotePeer(ctx context.Context, req *pb.PromotePeerRequest) (*pb.PromotePeerResponse, error) {
+	if err := s.changeSuffrage(ctx, req.Id, s.node.Promote); err != nil {
+		return nil, err
+	}
+	return &pb.PromotePeerResponse{}, nil
+}
+
+// DemotePeer turns a voter into a nonvoter. It requires admin rights.
+func (s *Server) DemotePeer(ctx context.Context, req *pb.DemotePeerRequest) (*pb.DemotePeerResponse, error) {
+	if err := s.changeSuffrage(ctx, req.Id, s.node.Demote); err != nil {
+		return nil, err
+	}
+	return &pb.DemotePeerResponse{}, nil
+}
+
+func (s *Server) changeSuffrage(ctx context.Context, id string, change func(string) error) error {
+	if err := s.authorize(ctx, adminPath, auth.Admin); err != nil {
+		return err
+	}
+	if !s.node.IsLeader() {
+		return status.Errorf(codes.FailedPrecondition, errNotLeader, s.leader())
+	}
+	if err := change(id); err != nil {
+		return peerErr(err)
+	}
+	return nil
+}
+
+// SetAPIAddress records the gRPC address a server advertises. Servers
+// call it on the leader at startup. It requires admin rights.
+func (s *Server) SetAPIAddress(ctx context.Context, req *pb.SetAPIAddressRequest) (*pb.SetAPIAddressResponse, error) {
+	if err := s.authorize(ctx, adminPath, auth.Admin); err != nil {
+		return nil, err
+	}
+	if req.Id == "" {
+		return nil, status.Errorf(codes.InvalidArgument, errNoID)
+	}
+	if !s.node.IsLeader() {
+		return nil, status.Errorf(codes.FailedPrecondition, errNotLeader, s.leader())
+	}
+	if err := s.node.SetAPIAddress(req.Id, req.Address); err != nil {
+		return nil, status.Errorf(codes.Internal, errInternal, err)
+	}
+	return &pb.SetAPIAddressResponse{}, nil
+}
+
+// leader returns the address to retry on in "not leader" errors: the
+// leader's API address when known, else its Raft address, which also
+// serves gRPC when the node shares one listener.
+func (s *Server) leader() string {
+	if addr := s.node.LeaderAPIAddress(); addr != "" {
+		return addr
+	}
+	return string(s.node.Leader())
+}
+
+// peerErr converts a failed membership change to a status error.
+func peerErr(err error) error {
+	if errors.Is(err, node.ErrUnknownPeer) {
+		return status.Errorf(codes.NotFound, errInternal, err)
+	}
+	return status.Errorf(codes.Internal, errInternal, err)
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"dfs/internal/logging"
+)
+
+// metrics counts the RPCs served and their latencies by method.
+type metrics struct {
+	latency *prometheus.HistogramVec
+	errors  *prometheus.CounterVec
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "dfs",
+			Subsystem: "grpc",
+			Name:      "request_duration_seconds",
+			Help:      "Time to serve an RPC, by method. Streams are timed until they end.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dfs",
+			Subsystem: "grpc",
+			Name:      "errors_total",
+			Help:      "RPCs that failed, by method and status code.",
+		}, []string{"method", "code"}),
+	}
+}
+
+func (m *metrics) observe(fullMethod string, took time.Duration, err error) {
+	method := path.Base(fullMethod)
+	m.latency.WithLabelValues(method).Observe(took.Seconds())
+	if code := status.Code(err); code != codes.OK {
+		m.errors.WithLabelValues(method, code.String()).Inc()
+	}
+}
+
+// Collector returns a Prometheus collector for the RPCs seen by
+// Server.Observe and Server.ObserveStream.
+func (s *Server) Collector() prometheus.Collector { return s.metrics }
+
+func (m *metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.latency.Describe(ch)
+	m.errors.Describe(ch)
+}
+
+func (m *metrics) Collect(ch chan<- prometheus.Metric) {
+	m.latency.Collect(ch)
+	m.errors.Collect(ch)
+}
+
+// RequestIDHeader is the metadata key of the request id. A caller may
+// set it to correlate its own logs; otherwise the server generates one.
+// Either way it is returned in the response header.
+const RequestIDHeader = "x-request-id"
+
+// maxRequestID bounds the length of a caller's request id.
+const maxRequestID = 64
+
+// requestID returns ctx carrying the caller's request id, or a new one,
+// and the id. The id is also recorded on the call's span, if any.
+func requestID(ctx context.Context) (context.Context, string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	id := logging.NewRequestID()
+	if ids := md.Get(RequestIDHeader); len(ids) > 0 && ids[0] != "" && len(ids[0]) <= maxRequestID {
+		id = ids[0]
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String(logging.KeyRequestID, id))
+	return logging.WithRequestID(ctx, id), id
+}
+
+// Observe is a gRPC unary interceptor that tags the call with a request
+// id, records its latency and outcome and logs it. Install it ahead of
+// Authenticate so rejected calls are counted too.
+func (s *Server) Observe(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	ctx, id := requestID(ctx)
+	grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, id))
+	resp, err := handler(ctx, req)
+	s.observe(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+// ObserveStream is the streaming counterpart of Observe.
+func (s *Server) ObserveStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx, id := requestID(ss.Context())
+	ss.SetHeader(metadata.Pairs(RequestIDHeader, id))
+	err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	s.observe(ctx, info.FullMethod, start, err)
+	return err
+}
+
+// observe records a finished call. Calls failing with a server-side
+// error are logged at error level, the others at debug.
+func (s *Server) observe(ctx context.Context, fullMethod string, start time.Time, err error) {
+	took := time.Since(start)
+	s.metrics.observe(fullMethod, took, err)
+	code := status.Code(err)
+	level := slog.LevelDebug
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss:
+		level = slog.LevelError
+	}
+	attrs := []any{"method", path.Base(fullMethod), "code", code.String(), "duration", took}
+	if err != nil {
+		attrs = append(attrs, logging.Err(err))
+	}
+	s.log.Log(ctx, level, "rpc", attrs...)
+}
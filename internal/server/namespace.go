@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"dfs/internal/auth"
+	"dfs/internal/namespace"
+	"dfs/internal/node"
+	pb "dfs/proto"
+)
+
+const (
+	errBadKey       = "bad key: %v"
+	errBadNamespace = "bad namespace: %v"
+	errNoNamespace  = "namespace %q not found"
+	errNSExists     = "namespace %q already exists"
+)
+
+// scope checks that ns exists and returns the storage key for key in it.
+func (s *Server) scope(ns, key string) (string, error) {
+	if err := namespace.ValidKey(key); err != nil {
+		return "", status.Errorf(codes.InvalidArgument, errBadKey, err)
+	}
+	if !s.node.HasNamespace(ns) {
+		return "", status.Errorf(codes.NotFound, errNoNamespace, ns)
+	}
+	return namespace.Key(ns, key), nil
+}
+
+// CreateNamespace registers a namespace. It requires cluster admin rights.
+func (s *Server) CreateNamespace(ctx context.Context, req *pb.CreateNamespaceRequest) (*pb.CreateNamespaceResponse, error) {
+	if err := s.namespaceAdmin(ctx, req.Name); err != nil {
+		return nil, err
+	}
+	if err := s.node.CreateNamespace(req.Name); err != nil {
+		if errors.Is(err, node.ErrNamespaceExists) {
+			return nil, status.Errorf(codes.AlreadyExists, errNSExists, req.Name)
+		}
+		return nil, status.Errorf(codes.Internal, errInternal, err)
+	}
+	return &pb.CreateNamespaceResponse{}, nil
+}
+
+// DeleteNamespace removes a namespace and everything stored in it. It
+// requires cluster admin rights.
+func (s *Server) DeleteNamespace(ctx context.Context, req *pb.DeleteNamespaceRequest) (*pb.DeleteNamespaceResponse, error) {
+	if err := s.namespaceAdmin(ctx, req.Name); err != nil {
+		return nil, err
+	}
+	if err := s.node.DeleteNamespace(req.Name); err != nil {
+		if errors.Is(err, node.ErrNamespaceNotFound) {
+			return nil, status.Errorf(codes.NotFound, errNoNamespace, req.Name)
+		}
+		return nil, status.Errorf(codes.Internal, errInternal, err)
+	}
+	return &pb.DeleteNamespaceResponse{}, nil
+}
+
+// namespaceAdmin validates a namespace change request.
+func (s *Server) namespaceAdmin(ctx context.Context, ns string) error {
+	if err := s.authorize(ctx, adminPath, auth.Admin); err != nil {
+		return err
+	}
+	if err := namespace.Valid(ns); err != nil {
+		return status.Errorf(codes.InvalidArgument, errBadNamespace, err)
+	}
+	if !s.node.IsLeader() {
+		return status.Errorf(codes.FailedPrecondition, errNotLeader, s.leader())
+	}
+	return nil
+}
+
+// ListNamespaces returns the registered namespaces the caller holds any
+// rights in. The default namespace is implied and not listed.
+func (s *Server) ListNamespaces(ctx context.Context, req *pb.ListNamespacesRequest) (*pb.ListNamespacesResponse, error) {
+	resp := &pb.ListNamespacesResponse{}
+	for _, ns := range s.node.Namespaces() {
+		if s.visible(ctx, ns) {
+			resp.Names = append(resp.Names, ns)
+		}
+	}
+	return resp, nil
+}
+
+// visible reports whether the caller is a cluster admin or holds a rule in
+// ns.
+func (s *Server) visible(ctx context.Context, ns string) bool {
+	if s.authorize(ctx, adminPath, auth.Admin) == nil {
+		return true
+	}
+	id, ok := auth.IdentityFrom(ctx)
+	if !ok {
+		return false
+	}
+	for _, r := range s.node.ACL() {
+		if r.Namespace == ns && (r.Identity == id || r.Identity == auth.Anyone) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"dfs/internal/auth"
+	"dfs/internal/node"
+	pb "dfs/proto"
+)
+
+const errBadQuota = "bad quota"
+
+// SetQuota adds, replaces or removes a quota. It requires cluster admin
+// rights so namespace admins cannot raise their own limits.
+func (s *Server) SetQuota(ctx context.Context, req *pb.SetQuotaRequest) (*pb.SetQuotaResponse, error) {
+	if err := s.authorize(ctx, adminPath, auth.Admin); err != nil {
+		return nil, err
+	}
+	q := req.GetQuota()
+	if q == nil || q.MaxBytes < 0 || q.MaxObjects < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, errBadQuota)
+	}
+	if _, err := s.scope(q.Namespace, q.Prefix); err != nil {
+		return nil, err
+	}
+	if !s.node.IsLeader() {
+		return nil, status.Errorf(codes.FailedPrecondition, errNotLeader, s.leader())
+	}
+	err := s.node.SetQuota(node.Quota{Namespace: q.Namespace, Prefix: q.Prefix, MaxBytes: q.MaxBytes, MaxObjects: q.MaxObjects})
+	if err != nil {
+		return nil, applyErr(err)
+	}
+	return &pb.SetQuotaResponse{}, nil
+}
+
+// GetUsage reports the quotas of a namespace and their usage. It requires
+// admin rights in the namespace.
+func (s *Server) GetUsage(ctx context.Context, req *pb.GetUsageRequest) (*pb.GetUsageResponse, error) {
+	nsAdmin, err := s.scope(req.Namespace, adminPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, nsAdmin, auth.Admin); err != nil {
+		return nil, err
+	}
+	resp := &pb.GetUsageResponse{}
+	for _, u := range s.node.Usage(req.Namespace) {
+		resp.Usage = append(resp.Usage, &pb.QuotaUsage{
+			Quota:   &pb.Quota{Namespace: u.Namespace, Prefix: u.Prefix, MaxBytes: u.MaxBytes, MaxObjects: u.MaxObjects},
+			Bytes:   u.Bytes,
+			Objects: u.Objects,
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"dfs/internal/auth"
+	"dfs/internal/config"
+	pb "dfs/proto"
+)
+
+const errNoReload = "reload not supported by this server"
+
+// ReloadFunc reads the node's configuration again, applies the settings
+// that do not need a restart and returns the settings that changed.
+type ReloadFunc func() ([]config.Change, error)
+
+// WithReload serves the Reload RPC with f.
+func WithReload(f ReloadFunc) Option {
+	return func(s *Server) { s.reload = f }
+}
+
+// Reload applies the answering node's reloadable settings. It requires
+// admin rights.
+func (s *Server) Reload(ctx context.Context, req *pb.ReloadRequest) (*pb.ReloadResponse, error) {
+	if err := s.authorize(ctx, adminPath, auth.Admin); err != nil {
+		return nil, err
+	}
+	if s.reload == nil {
+		return nil, status.Errorf(codes.Unimplemented, errNoReload)
+	}
+	changes, err := s.reload()
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, errInternal, err)
+	}
+	resp := &pb.ReloadResponse{}
+	for _, c := range changes {
+		resp.Changes = append(resp.Changes, &pb.ConfigChange{Key: c.Key, OldValue: c.Old, NewValue: c.New, Applied: c.Applied})
+	}
+	return resp, nil
+}
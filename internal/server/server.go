@@ -3,47 +3,161 @@ package server
 
 import (
 	"context"
+	"crypto/sha256"
+	"errors"
+	"log/slog"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"dfs/internal/auth"
+	"dfs/internal/codec"
+	"dfs/internal/logging"
 	"dfs/internal/metastore"
+	"dfs/internal/namespace"
 	"dfs/internal/node"
 	pb "dfs/proto"
 )
 
+const componentServer = "server"
+
 const (
 	errNotLeader = "not leader: %s"
 	errInternal  = "%v"
 	errNotFound  = "not found"
 	errBadMeta   = "bad metadata"
+	errBadHash   = "bad hash"
+	errBadCodec  = "bad codec: %v"
+	errNoID      = "missing server id"
 )
 
 // Server implements the FileService gRPC interface. Each instance
 // serves requests for a single Raft node.
 type Server struct {
 	pb.UnimplementedFileServiceServer
-	node *node.Node
+	node    *node.Node
+	auth    *auth.Authenticator
+	reload  ReloadFunc
+	metrics *metrics
+	log     *slog.Logger
+}
+
+func New(n *node.Node, opts ...Option) *Server {
+	s := &Server{node: n, metrics: newMetrics(), log: slog.Default()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.log = logging.Component(s.log, componentServer)
+	return s
 }
 
-func New(n *node.Node) *Server { return &Server{node: n} }
+// WithLogger makes the server log to l instead of slog.Default.
+func WithLogger(l *slog.Logger) Option {
+	return func(s *Server) { s.log = l }
+}
 
-// Put stores a key/value pair. Writes must go through the leader
-// in order to be replicated via Raft.
+// Put stores a key/value pair and records its hash and labels in the
+// metadata store. Writes must go through the leader in order to be
+// replicated via Raft.
 func (s *Server) Put(ctx context.Context, req *pb.PutRequest) (*pb.PutResponse, error) {
+	key, err := s.scope(req.Namespace, req.Key)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, key, auth.Write); err != nil {
+		return nil, err
+	}
 	if !s.node.IsLeader() {
-		return nil, status.Errorf(codes.FailedPrecondition, errNotLeader, s.node.Leader())
+		return nil, status.Errorf(codes.FailedPrecondition, errNotLeader, s.leader())
 	}
-	if err := s.node.Put(req.Key, req.Data); err != nil {
-		return nil, status.Errorf(codes.Internal, errInternal, err)
+	hash := sha256.Sum256(req.Data)
+	if req.Codec != codec.None {
+		if err := codec.Valid(req.Codec); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, errBadCodec, err)
+		}
+		err = s.node.PutContentCodecContext(ctx, key, hash, req.Data, req.Codec)
+	} else {
+		err = s.node.PutContentContext(ctx, key, hash, req.Data)
+	}
+	if err != nil {
+		return nil, applyErr(err)
+	}
+	if err := s.syncPut(ctx, key, hash, req.Labels); err != nil {
+		return nil, err
+	}
+	return &pb.PutResponse{}, nil
+}
+
+// PutByHash stores key as a reference to existing content so clients can
+// skip sending bytes the cluster already holds.
+func (s *Server) PutByHash(ctx context.Context, req *pb.PutByHashRequest) (*pb.PutResponse, error) {
+	key, err := s.scope(req.Namespace, req.Key)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, key, auth.Write); err != nil {
+		return nil, err
+	}
+	if !s.node.IsLeader() {
+		return nil, status.Errorf(codes.FailedPrecondition, errNotLeader, s.leader())
+	}
+	if len(req.Hash) != sha256.Size {
+		return nil, status.Errorf(codes.InvalidArgument, errBadHash)
+	}
+	var hash [sha256.Size]byte
+	copy(hash[:], req.Hash)
+	if err := s.node.PutHashContext(ctx, key, hash); err != nil {
+		if errors.Is(err, node.ErrContentMissing) {
+			return nil, status.Errorf(codes.NotFound, errNotFound)
+		}
+		return nil, applyErr(err)
+	}
+	if err := s.syncPut(ctx, key, hash, req.Labels); err != nil {
+		return nil, err
 	}
 	return &pb.PutResponse{}, nil
 }
 
+// applyErr converts an error replicating a write to a status error. The
+// namespace of the write may have been deleted since it was checked.
+func applyErr(err error) error {
+	if errors.Is(err, node.ErrQuotaExceeded) {
+		return status.Errorf(codes.ResourceExhausted, errInternal, err)
+	}
+	if errors.Is(err, node.ErrNamespaceNotFound) {
+		return status.Errorf(codes.NotFound, errInternal, err)
+	}
+	return status.Errorf(codes.Internal, errInternal, err)
+}
+
+// syncPut records a new metadata version for key after a put.
+func (s *Server) syncPut(ctx context.Context, key string, hash [sha256.Size]byte, labels map[string]string) error {
+	var ver uint64
+	if e, ok := s.node.Meta.Get(key); ok {
+		ver = e.Version
+	}
+	ver++
+	e := &metastore.Entry{Path: key, Version: ver, Hash: hash, Labels: labels, Codec: s.node.Codec(hash)}
+	if err := s.node.SyncMetaContext(ctx, e); err != nil {
+		return applyErr(err)
+	}
+	return nil
+}
+
 // Get returns the value for a key. Reads are served from the local
 // state machine and therefore can be handled by any node.
 func (s *Server) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetResponse, error) {
-	data, ok := s.node.Get(req.Key)
+	key, err := s.scope(req.Namespace, req.Key)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, key, auth.Read); err != nil {
+		return nil, err
+	}
+	data, ok, err := s.node.Lookup(key)
+	if err != nil {
+		return nil, status.Errorf(codes.DataLoss, errInternal, err)
+	}
 	if !ok {
 		return nil, status.Errorf(codes.NotFound, errNotFound)
 	}
@@ -52,36 +166,56 @@ func (s *Server) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetResponse,
 
 // Delete removes a key/value pair and its metadata.
 func (s *Server) Delete(ctx context.Context, req *pb.DeleteRequest) (*pb.DeleteResponse, error) {
+	key, err := s.scope(req.Namespace, req.Key)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, key, auth.Write); err != nil {
+		return nil, err
+	}
 	if !s.node.IsLeader() {
-		return nil, status.Errorf(codes.FailedPrecondition, errNotLeader, s.node.Leader())
+		return nil, status.Errorf(codes.FailedPrecondition, errNotLeader, s.leader())
 	}
 	var ver uint64
-	if e, ok := s.node.Meta.Get(req.Key); ok {
+	if e, ok := s.node.Meta.Get(key); ok {
 		ver = e.Version
 	}
 	ver++
-	if err := s.node.Delete(req.Key); err != nil {
-		return nil, status.Errorf(codes.Internal, errInternal, err)
+	if err := s.node.DeleteContext(ctx, key); err != nil {
+		return nil, applyErr(err)
+	}
+	if err := s.node.SyncMetaContext(ctx, &metastore.Entry{Path: key, Version: ver, Deleted: true}); err != nil {
+		return nil, applyErr(err)
 	}
-	s.node.Meta.Delete(req.Key, ver)
 	return &pb.DeleteResponse{}, nil
 }
 
-// AddPeer adds a node to the cluster.
+// AddPeer adds a voter or nonvoter to the cluster. It requires admin
+// rights.
 func (s *Server) AddPeer(ctx context.Context, req *pb.AddPeerRequest) (*pb.AddPeerResponse, error) {
+	if err := s.authorize(ctx, adminPath, auth.Admin); err != nil {
+		return nil, err
+	}
 	if !s.node.IsLeader() {
-		return nil, status.Errorf(codes.FailedPrecondition, errNotLeader, s.node.Leader())
+		return nil, status.Errorf(codes.FailedPrecondition, errNotLeader, s.leader())
+	}
+	add := s.node.AddPeer
+	if req.Nonvoter {
+		add = s.node.AddNonvoter
 	}
-	if err := s.node.AddPeer(req.Id, req.Address); err != nil {
+	if err := add(req.Id, req.Address); err != nil {
 		return nil, status.Errorf(codes.Internal, errInternal, err)
 	}
 	return &pb.AddPeerResponse{}, nil
 }
 
-// RemovePeer removes a node from the cluster.
+// RemovePeer removes a node from the cluster. It requires admin rights.
 func (s *Server) RemovePeer(ctx context.Context, req *pb.RemovePeerRequest) (*pb.RemovePeerResponse, error) {
+	if err := s.authorize(ctx, adminPath, auth.Admin); err != nil {
+		return nil, err
+	}
 	if !s.node.IsLeader() {
-		return nil, status.Errorf(codes.FailedPrecondition, errNotLeader, s.node.Leader())
+		return nil, status.Errorf(codes.FailedPrecondition, errNotLeader, s.leader())
 	}
 	if err := s.node.RemovePeer(req.Id); err != nil {
 		return nil, status.Errorf(codes.Internal, errInternal, err)
@@ -89,24 +223,94 @@ func (s *Server) RemovePeer(ctx context.Context, req *pb.RemovePeerRequest) (*pb
 	return &pb.RemovePeerResponse{}, nil
 }
 
-// SyncMetadata syncs file metadata to local store.
+// SyncMetadata replicates file metadata through Raft.
 func (s *Server) SyncMetadata(ctx context.Context, req *pb.SyncMetadataRequest) (*pb.SyncMetadataResponse, error) {
 	m := req.GetMeta()
 	if m == nil {
 		return nil, status.Errorf(codes.InvalidArgument, errBadMeta)
 	}
+	path, err := s.scope(req.Namespace, m.Path)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, path, auth.Write); err != nil {
+		return nil, err
+	}
+	if !s.node.IsLeader() {
+		return nil, status.Errorf(codes.FailedPrecondition, errNotLeader, s.leader())
+	}
 	var hash [32]byte
 	copy(hash[:], m.Hash)
 	reps := make([]metastore.ReplicaID, len(m.Replicas))
 	for i, r := range m.Replicas {
 		reps[i] = metastore.ReplicaID(r)
 	}
-	s.node.Meta.Sync(&metastore.Entry{
-		Path:     m.Path,
+	err = s.node.SyncMetaContext(ctx, &metastore.Entry{
+		Path:     path,
 		Version:  m.Version,
 		Hash:     hash,
 		Replicas: reps,
 		Deleted:  m.Deleted,
+		Labels:   m.Labels,
+		Codec:    m.Codec,
 	})
+	if err != nil {
+		return nil, applyErr(err)
+	}
 	return &pb.SyncMetadataResponse{}, nil
 }
+
+// Find looks up metadata of one namespace through the secondary indexes.
+// The page token is the last path of the previous page. Entries the caller
+// may not read are left out.
+func (s *Server) Find(ctx context.Context, req *pb.FindRequest) (*pb.FindResponse, error) {
+	prefix, err := s.scope(req.Namespace, req.PathPrefix)
+	if err != nil {
+		return nil, err
+	}
+	q := metastore.Query{PathPrefix: prefix, Limit: int(req.Limit)}
+	if req.PageToken != "" {
+		q.After = namespace.Key(req.Namespace, req.PageToken)
+	}
+	if req.Namespace == namespace.Default {
+		q.Exclude = namespace.Scoped
+	}
+	if len(req.Hash) > 0 {
+		if len(req.Hash) != sha256.Size {
+			return nil, status.Errorf(codes.InvalidArgument, errBadHash)
+		}
+		var h [sha256.Size]byte
+		copy(h[:], req.Hash)
+		q.Hash = &h
+	}
+	for _, m := range req.Labels {
+		q.Labels = append(q.Labels, metastore.LabelMatch{Key: m.Key, Value: m.Value, Prefix: m.Prefix})
+	}
+	entries, next := s.node.Meta.Find(q)
+	_, next = namespace.Split(next)
+	resp := &pb.FindResponse{NextPageToken: next}
+	for i := range entries {
+		if s.allowed(ctx, entries[i].Path) {
+			m := toProtoMeta(&entries[i])
+			_, m.Path = namespace.Split(m.Path)
+			resp.Entries = append(resp.Entries, m)
+		}
+	}
+	return resp, nil
+}
+
+func toProtoMeta(e *metastore.Entry) *pb.Metadata {
+	reps := make([]uint64, len(e.Replicas))
+	for i, r := range e.Replicas {
+		reps[i] = uint64(r)
+	}
+	return &pb.Metadata{
+		Path:     e.Path,
+		Version:  e.Version,
+		Hash:     append([]byte(nil), e.Hash[:]...),
+		Replicas: reps,
+		Deleted:  e.Deleted,
+		Labels:   e.Labels,
+		Codec:    e.Codec,
+	}
+}
@@ -1,17 +1,36 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
 	"net"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 
+	"dfs/internal/auth"
+	"dfs/internal/config"
+	"dfs/internal/logging"
 	"dfs/internal/metastore"
+	"dfs/internal/namespace"
 	"dfs/internal/node"
 	pb "dfs/proto"
 )
@@ -21,6 +40,7 @@ const (
 	idA     = "n1"
 	idB     = "n2"
 	empty   = ""
+	apiAddr = "api.example:13001"
 )
 
 func dialer(l *bufconn.Listener) func(context.Context, string) (net.Conn, error) {
@@ -29,11 +49,11 @@ func dialer(l *bufconn.Listener) func(context.Context, string) (net.Conn, error)
 	}
 }
 
-func startGRPC(t *testing.T, n *node.Node) (pb.FileServiceClient, func()) {
+func startGRPC(t *testing.T, n *node.Node, opts ...Option) (pb.FileServiceClient, func()) {
 	t.Helper()
 	lis := bufconn.Listen(bufSize)
 	srv := grpc.NewServer()
-	pb.RegisterFileServiceServer(srv, New(n))
+	pb.RegisterFileServiceServer(srv, New(n, opts...))
 	go srv.Serve(lis)
 	ctx := context.Background()
 	conn, err := grpc.DialContext(ctx, "buf", grpc.WithContextDialer(dialer(lis)), grpc.WithInsecure())
@@ -99,26 +119,51 @@ func TestServerPutGet(t *testing.T) {
 }
 
 func TestServerDelete(t *testing.T) {
-	addr := freeAddr(t)
-	n, err := node.New("n1", addr, t.TempDir(), "", true)
+	addr1 := freeAddr(t)
+	addr2 := freeAddr(t)
+	n1, err := node.New(idA, addr1, t.TempDir(), empty, true)
 	if err != nil {
-		t.Fatalf("new node: %v", err)
+		t.Fatalf("n1: %v", err)
 	}
-	if waitLeader(n) == nil {
+	if waitLeader(n1) == nil {
 		t.Fatalf("node not leader")
 	}
-	client, cleanup := startGRPC(t, n)
+	n2, err := node.New(idB, addr2, t.TempDir(), empty, false)
+	if err != nil {
+		t.Fatalf("n2: %v", err)
+	}
+	if err := n1.AddPeer(idB, addr2); err != nil {
+		t.Fatalf("add peer: %v", err)
+	}
+	client, cleanup := startGRPC(t, n1)
 	defer cleanup()
 	ctx := context.Background()
 	if _, err := client.Put(ctx, &pb.PutRequest{Key: "foo", Data: []byte("bar")}); err != nil {
 		t.Fatalf("put: %v", err)
 	}
+	found := func() bool {
+		entries, _ := n2.Meta.Find(metastore.Query{PathPrefix: "foo"})
+		return len(entries) > 0
+	}
+	waitFound := func(want bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for found() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("follower finds the key: %v, want %v", !want, want)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	waitFound(true)
 	if _, err := client.Delete(ctx, &pb.DeleteRequest{Key: "foo"}); err != nil {
 		t.Fatalf("delete: %v", err)
 	}
 	if _, err := client.Get(ctx, &pb.GetRequest{Key: "foo"}); status.Code(err) != codes.NotFound {
 		t.Fatalf("expected not found, got %v", err)
 	}
+	// The tombstone is replicated, so followers stop finding the path.
+	waitFound(false)
 }
 
 func TestServerPutNotLeader(t *testing.T) {
@@ -146,8 +191,25 @@ func TestServerPutNotLeader(t *testing.T) {
 	client, cleanup := startGRPC(t, follower)
 	defer cleanup()
 	ctx := context.Background()
-	if _, err := client.Put(ctx, &pb.PutRequest{Key: "k", Data: []byte("v")}); status.Code(err) != codes.FailedPrecondition {
-		t.Fatalf("expected FailedPrecondition, got %v", err)
+	_, err = client.Put(ctx, &pb.PutRequest{Key: "k", Data: []byte("v")})
+	if status.Code(err) != codes.FailedPrecondition || !strings.Contains(err.Error(), string(leader.Leader())) {
+		t.Fatalf("expected FailedPrecondition naming the leader, got %v", err)
+	}
+
+	// Once the leader's API address is known, clients are sent there.
+	if err := leader.SetAPIAddress(leader.ID(), apiAddr); err != nil {
+		t.Fatalf("set api address: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for follower.LeaderAPIAddress() != apiAddr && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	_, err = client.Put(ctx, &pb.PutRequest{Key: "k", Data: []byte("v")})
+	if status.Code(err) != codes.FailedPrecondition || !strings.Contains(err.Error(), apiAddr) {
+		t.Fatalf("expected FailedPrecondition naming the api address, got %v", err)
+	}
+	if _, err := client.SetAPIAddress(ctx, &pb.SetAPIAddressRequest{Id: follower.ID(), Address: apiAddr}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition from follower, got %v", err)
 	}
 }
 
@@ -179,7 +241,13 @@ func TestServerAddRemovePeer(t *testing.T) {
 }
 
 func TestServerSyncMetadata(t *testing.T) {
-	n := &node.Node{Meta: metastore.New()}
+	n, err := node.New(idA, freeAddr(t), t.TempDir(), empty, true)
+	if err != nil {
+		t.Fatalf("new node: %v", err)
+	}
+	if waitLeader(n) == nil {
+		t.Fatalf("node not leader")
+	}
 	client, cleanup := startGRPC(t, n)
 	defer cleanup()
 	ctx := context.Background()
@@ -204,3 +272,553 @@ func TestServerSyncMetadata(t *testing.T) {
 		t.Fatalf("expected deleted")
 	}
 }
+
+func TestServerFind(t *testing.T) {
+	addr := freeAddr(t)
+	n, err := node.New(idA, addr, t.TempDir(), empty, true)
+	if err != nil {
+		t.Fatalf("new node: %v", err)
+	}
+	if waitLeader(n) == nil {
+		t.Fatalf("node not leader")
+	}
+	client, cleanup := startGRPC(t, n)
+	defer cleanup()
+	ctx := context.Background()
+	labels := map[string]string{"build": "123"}
+	for _, k := range []string{"a", "b"} {
+		if _, err := client.Put(ctx, &pb.PutRequest{Key: k, Data: []byte("same"), Labels: labels}); err != nil {
+			t.Fatalf("put %s: %v", k, err)
+		}
+	}
+	if _, err := client.Put(ctx, &pb.PutRequest{Key: "c", Data: []byte("other")}); err != nil {
+		t.Fatalf("put c: %v", err)
+	}
+	h := sha256.Sum256([]byte("same"))
+	resp, err := client.Find(ctx, &pb.FindRequest{Hash: h[:], Limit: 1})
+	if err != nil || len(resp.Entries) != 1 || resp.Entries[0].Path != "a" || resp.NextPageToken != "a" {
+		t.Fatalf("find page1: %v %+v", err, resp)
+	}
+	resp, err = client.Find(ctx, &pb.FindRequest{Hash: h[:], Limit: 1, PageToken: resp.NextPageToken})
+	if err != nil || len(resp.Entries) != 1 || resp.Entries[0].Path != "b" || resp.NextPageToken != empty {
+		t.Fatalf("find page2: %v %+v", err, resp)
+	}
+	resp, err = client.Find(ctx, &pb.FindRequest{Labels: []*pb.LabelMatch{{Key: "build", Value: "123"}}})
+	if err != nil || len(resp.Entries) != 2 || resp.Entries[0].Labels["build"] != "123" {
+		t.Fatalf("find labels: %v %+v", err, resp)
+	}
+	if _, err := client.Find(ctx, &pb.FindRequest{Hash: []byte("x")}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestServerPutByHash(t *testing.T) {
+	addr := freeAddr(t)
+	n, err := node.New(idA, addr, t.TempDir(), empty, true)
+	if err != nil {
+		t.Fatalf("new node: %v", err)
+	}
+	if waitLeader(n) == nil {
+		t.Fatalf("node not leader")
+	}
+	client, cleanup := startGRPC(t, n)
+	defer cleanup()
+	ctx := context.Background()
+	h := sha256.Sum256([]byte("shared"))
+	if _, err := client.PutByHash(ctx, &pb.PutByHashRequest{Key: "b", Hash: h[:]}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+	if _, err := client.Put(ctx, &pb.PutRequest{Key: "a", Data: []byte("shared")}); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if _, err := client.PutByHash(ctx, &pb.PutByHashRequest{Key: "b", Hash: h[:]}); err != nil {
+		t.Fatalf("put by hash: %v", err)
+	}
+	resp, err := client.Get(ctx, &pb.GetRequest{Key: "b"})
+	if err != nil || string(resp.Data) != "shared" {
+		t.Fatalf("get: %v resp=%v", err, resp)
+	}
+	if e, ok := n.Meta.Get("b"); !ok || e.Hash != h {
+		t.Fatalf("metadata not recorded: %+v", e)
+	}
+}
+
+func TestServerPutCodec(t *testing.T) {
+	addr := freeAddr(t)
+	n, err := node.New(idA, addr, t.TempDir(), empty, true)
+	if err != nil {
+		t.Fatalf("new node: %v", err)
+	}
+	if waitLeader(n) == nil {
+		t.Fatalf("node not leader")
+	}
+	client, cleanup := startGRPC(t, n)
+	defer cleanup()
+	ctx := context.Background()
+	data := []byte(strings.Repeat("{\"k\":\"v\"}", 100))
+	if _, err := client.Put(ctx, &pb.PutRequest{Key: "j", Data: data, Codec: "flate"}); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	resp, err := client.Get(ctx, &pb.GetRequest{Key: "j"})
+	if err != nil || string(resp.Data) != string(data) {
+		t.Fatalf("get: %v", err)
+	}
+	if e, ok := n.Meta.Get("j"); !ok || e.Codec != "flate" {
+		t.Fatalf("codec not recorded: %+v", e)
+	}
+	if _, err := client.Put(ctx, &pb.PutRequest{Key: "j", Data: data, Codec: "zstd"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestServerAuthACL(t *testing.T) {
+	addr := freeAddr(t)
+	n, err := node.New(idA, addr, t.TempDir(), empty, true, node.WithAdmins("root"))
+	if err != nil {
+		t.Fatalf("new node: %v", err)
+	}
+	if waitLeader(n) == nil {
+		t.Fatalf("node not leader")
+	}
+	srv := New(n, WithAuth(auth.NewAuthenticator(map[string]string{"tr": "root", "ta": "alice"})))
+	lis := bufconn.Listen(bufSize)
+	gs := grpc.NewServer(grpc.UnaryInterceptor(srv.Authenticate), grpc.StreamInterceptor(srv.AuthenticateStream))
+	pb.RegisterFileServiceServer(gs, srv)
+	go gs.Serve(lis)
+	defer gs.Stop()
+	dial := func(token string) pb.FileServiceClient {
+		opts := []grpc.DialOption{grpc.WithContextDialer(dialer(lis)), grpc.WithInsecure()}
+		if token != empty {
+			opts = append(opts, grpc.WithPerRPCCredentials(auth.Token(token, false)))
+		}
+		conn, err := grpc.DialContext(context.Background(), "buf", opts...)
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return pb.NewFileServiceClient(conn)
+	}
+	ctx := context.Background()
+	anon, root, alice := dial(empty), dial("tr"), dial("ta")
+
+	if _, err := anon.Get(ctx, &pb.GetRequest{Key: "a/x"}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated, got %v", err)
+	}
+	if _, err := alice.Put(ctx, &pb.PutRequest{Key: "a/x", Data: []byte("v")}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied, got %v", err)
+	}
+	if _, err := alice.Grant(ctx, &pb.GrantRequest{Rule: &pb.ACLRule{Identity: "alice", Prefix: "", Perm: pb.Permission_PERMISSION_ADMIN}}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied for grant, got %v", err)
+	}
+	for _, p := range []pb.Permission{pb.Permission_PERMISSION_NONE, pb.Permission_PERMISSION_ADMIN + 1, -1} {
+		bad := &pb.ACLRule{Identity: "alice", Prefix: "a/", Perm: p}
+		if _, err := root.Grant(ctx, &pb.GrantRequest{Rule: bad}); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expected InvalidArgument for %v, got %v", p, err)
+		}
+	}
+	rule := &pb.ACLRule{Identity: "alice", Prefix: "a/", Perm: pb.Permission_PERMISSION_WRITE}
+	if _, err := root.Grant(ctx, &pb.GrantRequest{Rule: rule}); err != nil {
+		t.Fatalf("grant: %v", err)
+	}
+	if _, err := alice.Put(ctx, &pb.PutRequest{Key: "a/x", Data: []byte("v")}); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if _, err := alice.Put(ctx, &pb.PutRequest{Key: "b/x", Data: []byte("v")}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied outside prefix, got %v", err)
+	}
+	if _, err := alice.AddPeer(ctx, &pb.AddPeerRequest{Id: idB, Address: addr}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied for AddPeer, got %v", err)
+	}
+	if _, err := alice.GC(ctx, &pb.GCRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied for GC, got %v", err)
+	}
+	if _, err := root.GC(ctx, &pb.GCRequest{}); err != nil {
+		t.Fatalf("gc: %v", err)
+	}
+	if _, err := alice.Reload(ctx, &pb.ReloadRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied for Reload, got %v", err)
+	}
+	for client, code := range map[pb.FileServiceClient]codes.Code{anon: codes.Unauthenticated, alice: codes.PermissionDenied, root: codes.NotFound} {
+		stream, err := client.ExportSnapshot(ctx, &pb.ExportSnapshotRequest{Id: "missing"})
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		if status.Code(err) != code {
+			t.Fatalf("expected %v for export, got %v", code, err)
+		}
+	}
+	resp, err := root.ListACL(ctx, &pb.ListACLRequest{})
+	if err != nil || len(resp.Rules) != 1 || resp.Rules[0].Prefix != "a/" {
+		t.Fatalf("list acl: %v %+v", err, resp)
+	}
+	if _, err := root.Revoke(ctx, &pb.RevokeRequest{Rule: rule}); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, err := alice.Get(ctx, &pb.GetRequest{Key: "a/x"}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied after revoke, got %v", err)
+	}
+}
+
+func TestServerNamespaces(t *testing.T) {
+	addr := freeAddr(t)
+	n, err := node.New(idA, addr, t.TempDir(), empty, true)
+	if err != nil {
+		t.Fatalf("new node: %v", err)
+	}
+	if waitLeader(n) == nil {
+		t.Fatalf("node not leader")
+	}
+	client, cleanup := startGRPC(t, n)
+	defer cleanup()
+	ctx := context.Background()
+	const team = "team"
+
+	if _, err := client.Put(ctx, &pb.PutRequest{Namespace: team, Key: "k", Data: []byte("v")}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound for missing namespace, got %v", err)
+	}
+	if _, err := client.CreateNamespace(ctx, &pb.CreateNamespaceRequest{Name: team}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := client.CreateNamespace(ctx, &pb.CreateNamespaceRequest{Name: team}); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("expected AlreadyExists, got %v", err)
+	}
+	if _, err := client.CreateNamespace(ctx, &pb.CreateNamespaceRequest{Name: "a/b"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+	if _, err := client.Put(ctx, &pb.PutRequest{Key: "k", Data: []byte("default")}); err != nil {
+		t.Fatalf("put default: %v", err)
+	}
+	if _, err := client.Put(ctx, &pb.PutRequest{Namespace: team, Key: "k", Data: []byte("team")}); err != nil {
+		t.Fatalf("put team: %v", err)
+	}
+	if _, err := client.Put(ctx, &pb.PutRequest{Key: "bad\x00key", Data: []byte("v")}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for key, got %v", err)
+	}
+	for ns, want := range map[string]string{"": "default", team: "team"} {
+		resp, err := client.Get(ctx, &pb.GetRequest{Namespace: ns, Key: "k"})
+		if err != nil || string(resp.Data) != want {
+			t.Fatalf("get %q: %v %q", ns, err, resp.GetData())
+		}
+		found, err := client.Find(ctx, &pb.FindRequest{Namespace: ns})
+		if err != nil || len(found.Entries) != 1 || found.Entries[0].Path != "k" {
+			t.Fatalf("find %q: %v %+v", ns, err, found)
+		}
+	}
+	list, err := client.ListNamespaces(ctx, &pb.ListNamespacesRequest{})
+	if err != nil || len(list.Names) != 1 || list.Names[0] != team {
+		t.Fatalf("list: %v %+v", err, list)
+	}
+	if _, err := client.DeleteNamespace(ctx, &pb.DeleteNamespaceRequest{Name: team}); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok := n.Get(namespace.Key(team, "k")); ok {
+		t.Fatalf("namespace key survived delete")
+	}
+	if resp, err := client.Get(ctx, &pb.GetRequest{Key: "k"}); err != nil || string(resp.Data) != "default" {
+		t.Fatalf("default key after delete: %v", err)
+	}
+}
+
+func TestServerQuota(t *testing.T) {
+	addr := freeAddr(t)
+	n, err := node.New(idA, addr, t.TempDir(), empty, true)
+	if err != nil {
+		t.Fatalf("new node: %v", err)
+	}
+	if waitLeader(n) == nil {
+		t.Fatalf("node not leader")
+	}
+	client, cleanup := startGRPC(t, n)
+	defer cleanup()
+	ctx := context.Background()
+	const team = "team"
+	if _, err := client.CreateNamespace(ctx, &pb.CreateNamespaceRequest{Name: team}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	q := &pb.Quota{Namespace: team, MaxObjects: 1}
+	if _, err := client.SetQuota(ctx, &pb.SetQuotaRequest{Quota: q}); err != nil {
+		t.Fatalf("set quota: %v", err)
+	}
+	if _, err := client.Put(ctx, &pb.PutRequest{Namespace: team, Key: "a", Data: []byte("v")}); err != nil {
+		t.Fatalf("put a: %v", err)
+	}
+	if _, err := client.Put(ctx, &pb.PutRequest{Namespace: team, Key: "b", Data: []byte("v")}); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+	if _, err := client.Put(ctx, &pb.PutRequest{Key: "b", Data: []byte("v")}); err != nil {
+		t.Fatalf("put in default namespace: %v", err)
+	}
+	resp, err := client.GetUsage(ctx, &pb.GetUsageRequest{Namespace: team})
+	if err != nil || len(resp.Usage) != 1 || resp.Usage[0].Objects != 1 || resp.Usage[0].Bytes != 1 {
+		t.Fatalf("usage: %v %+v", err, resp)
+	}
+}
+
+func TestServerClusterStatus(t *testing.T) {
+	addr := freeAddr(t)
+	n, err := node.New(idA, addr, t.TempDir(), empty, true)
+	if err != nil {
+		t.Fatalf("new node: %v", err)
+	}
+	if waitLeader(n) == nil {
+		t.Fatalf("node not leader")
+	}
+	client, cleanup := startGRPC(t, n)
+	defer cleanup()
+	ctx := context.Background()
+	if _, err := client.Put(ctx, &pb.PutRequest{Key: "k", Data: []byte("v")}); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	st, err := client.ClusterStatus(ctx, &pb.ClusterStatusRequest{})
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if st.Id != idA || st.State != "Leader" || st.LeaderId != idA || st.Term == 0 || st.AppliedIndex == 0 {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+	if len(st.Members) != 1 || st.Members[0].Id != idA || st.Members[0].Suffrage != "Voter" {
+		t.Fatalf("unexpected members: %+v", st.Members)
+	}
+
+	if _, err := client.SetAPIAddress(ctx, &pb.SetAPIAddressRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument without id, got %v", err)
+	}
+	if _, err := client.SetAPIAddress(ctx, &pb.SetAPIAddressRequest{Id: idA, Address: apiAddr}); err != nil {
+		t.Fatalf("set api address: %v", err)
+	}
+	st, err = client.ClusterStatus(ctx, &pb.ClusterStatusRequest{})
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if st.LeaderApiAddress != apiAddr || st.Members[0].ApiAddress != apiAddr {
+		t.Fatalf("unexpected api addresses: %+v", st)
+	}
+}
+
+func TestServerSnapshot(t *testing.T) {
+	n, err := node.New(idA, freeAddr(t), t.TempDir(), empty, true)
+	if err != nil {
+		t.Fatalf("new node: %v", err)
+	}
+	if waitLeader(n) == nil {
+		t.Fatalf("node not leader")
+	}
+	client, cleanup := startGRPC(t, n)
+	defer cleanup()
+	ctx := context.Background()
+	if _, err := client.Put(ctx, &pb.PutRequest{Key: "k", Data: bytes.Repeat([]byte("v"), 3*snapshotChunk)}); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	created, err := client.Snapshot(ctx, &pb.SnapshotRequest{})
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if created.Snapshot.Index == 0 || len(created.Snapshots) != 1 || created.Snapshots[0].Id != created.Snapshot.Id {
+		t.Fatalf("unexpected snapshot: %+v", created)
+	}
+	list, err := client.ListSnapshots(ctx, &pb.ListSnapshotsRequest{})
+	if err != nil || len(list.Snapshots) != 1 {
+		t.Fatalf("list: %+v, %v", list, err)
+	}
+
+	stream, err := client.ExportSnapshot(ctx, &pb.ExportSnapshotRequest{})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	var meta *pb.SnapshotMeta
+	var size int64
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("recv: %v", err)
+		}
+		if chunk.Meta != nil {
+			meta = chunk.Meta
+		}
+		size += int64(len(chunk.Data))
+	}
+	if meta == nil || meta.Id != created.Snapshot.Id || size != meta.Size {
+		t.Fatalf("exported %d bytes of %+v", size, meta)
+	}
+
+	stream, err = client.ExportSnapshot(ctx, &pb.ExportSnapshotRequest{Id: "missing"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestServerReload(t *testing.T) {
+	n := node.NewInmem()
+	client, cleanup := startGRPC(t, n)
+	ctx := context.Background()
+	if _, err := client.Reload(ctx, &pb.ReloadRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Fatalf("expected Unimplemented without a reload func, got %v", err)
+	}
+	cleanup()
+
+	fail := false
+	reload := func() ([]config.Change, error) {
+		if fail {
+			return nil, errors.New("bad config")
+		}
+		return []config.Change{{Key: "gc_interval", Old: "10m0s", New: "1h0m0s", Applied: true}, {Key: "port", Old: "13000", New: "14000"}}, nil
+	}
+	client, cleanup = startGRPC(t, n, WithReload(reload))
+	defer cleanup()
+	resp, err := client.Reload(ctx, &pb.ReloadRequest{})
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if len(resp.Changes) != 2 || !resp.Changes[0].Applied || resp.Changes[0].NewValue != "1h0m0s" || resp.Changes[1].Applied {
+		t.Fatalf("unexpected changes: %+v", resp.Changes)
+	}
+	fail = true
+	if _, err := client.Reload(ctx, &pb.ReloadRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition, got %v", err)
+	}
+}
+
+func TestServerObserve(t *testing.T) {
+	n, err := node.New(idA, freeAddr(t), t.TempDir(), empty, true)
+	if err != nil {
+		t.Fatalf("new node: %v", err)
+	}
+	if waitLeader(n) == nil {
+		t.Fatalf("node not leader")
+	}
+	var logs bytes.Buffer
+	srv := New(n, WithLogger(logging.New(&logs, true, slog.LevelDebug)))
+	lis := bufconn.Listen(bufSize)
+	gs := grpc.NewServer(grpc.UnaryInterceptor(srv.Observe), grpc.StreamInterceptor(srv.ObserveStream))
+	pb.RegisterFileServiceServer(gs, srv)
+	go gs.Serve(lis)
+	defer gs.Stop()
+	conn, err := grpc.DialContext(context.Background(), "buf", grpc.WithContextDialer(dialer(lis)), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewFileServiceClient(conn)
+	ctx := context.Background()
+	var header metadata.MD
+	if _, err := client.Put(ctx, &pb.PutRequest{Key: "k", Data: []byte("v")}, grpc.Header(&header)); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	generated := header.Get(RequestIDHeader)
+	if len(generated) != 1 || generated[0] == empty {
+		t.Fatalf("no request id in header: %v", header)
+	}
+	client.Get(metadata.AppendToOutgoingContext(ctx, RequestIDHeader, "caller-id"), &pb.GetRequest{Key: "missing"}, grpc.Header(&header))
+	if got := header.Get(RequestIDHeader); len(got) != 1 || got[0] != "caller-id" {
+		t.Fatalf("caller request id not kept: %v", header)
+	}
+	client.Get(ctx, &pb.GetRequest{Key: "missing"})
+	if stream, err := client.ExportSnapshot(ctx, &pb.ExportSnapshotRequest{Id: "missing"}); err == nil {
+		stream.Recv()
+	}
+
+	if got := testutil.ToFloat64(srv.metrics.errors.WithLabelValues("Get", codes.NotFound.String())); got != 2 {
+		t.Fatalf("get errors: %v", got)
+	}
+	if got := testutil.ToFloat64(srv.metrics.errors.WithLabelValues("ExportSnapshot", codes.NotFound.String())); got != 1 {
+		t.Fatalf("export errors: %v", got)
+	}
+	if got := testutil.CollectAndCount(srv.Collector(), "dfs_grpc_request_duration_seconds"); got != 3 {
+		t.Fatalf("timed methods: %d", got)
+	}
+	if got := testutil.CollectAndCount(srv.Collector(), "dfs_grpc_errors_total"); got != 2 {
+		t.Fatalf("error series: %d", got)
+	}
+	if problems, err := testutil.CollectAndLint(srv.Collector()); err != nil || len(problems) > 0 {
+		t.Fatalf("lint: %v %v", problems, err)
+	}
+
+	gs.Stop()
+	var ids []any
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var r map[string]any
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("decode %q: %v", line, err)
+		}
+		if r[logging.KeyComponent] != componentServer || r["msg"] != "rpc" {
+			t.Fatalf("unexpected record: %v", r)
+		}
+		ids = append(ids, r[logging.KeyRequestID])
+	}
+	if len(ids) != 4 || ids[0] != generated[0] || ids[1] != "caller-id" || ids[3] == nil {
+		t.Fatalf("unexpected request ids: %v", ids)
+	}
+}
+
+var (
+	recorder     = tracetest.NewSpanRecorder()
+	recorderOnce sync.Once
+)
+
+func TestServerTrace(t *testing.T) {
+	// Tracers bind to the first global provider, so it is set once.
+	recorderOnce.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	})
+	n, err := node.New(idA, freeAddr(t), t.TempDir(), empty, true)
+	if err != nil {
+		t.Fatalf("new node: %v", err)
+	}
+	if waitLeader(n) == nil {
+		t.Fatalf("node not leader")
+	}
+	srv := New(n)
+	lis := bufconn.Listen(bufSize)
+	gs := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithPropagators(propagation.TraceContext{}))),
+		grpc.UnaryInterceptor(srv.Observe),
+	)
+	pb.RegisterFileServiceServer(gs, srv)
+	go gs.Serve(lis)
+	defer gs.Stop()
+	conn, err := grpc.DialContext(context.Background(), "buf", grpc.WithContextDialer(dialer(lis)), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	// A caller passes its span context as W3C trace context metadata.
+	const (
+		traceID = "0af7651916cd43dd8448eb211c80319c"
+		spanID  = "b7ad6b7169203331"
+	)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "traceparent", "00-"+traceID+"-"+spanID+"-01", RequestIDHeader, "traced")
+	if _, err := pb.NewFileServiceClient(conn).Put(ctx, &pb.PutRequest{Key: "k", Data: []byte("v")}); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	gs.Stop()
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range recorder.Ended() {
+		if s.SpanContext().TraceID().String() == traceID {
+			spans[s.Name()] = s
+		}
+	}
+	rpc, put := spans["dfs.FileService/Put"], spans["node.put"]
+	if rpc == nil || put == nil || spans["fsm.apply"] == nil {
+		t.Fatalf("missing spans: %v", spans)
+	}
+	if rpc.Parent().SpanID().String() != spanID || put.Parent().SpanID() != rpc.SpanContext().SpanID() {
+		t.Fatalf("spans not nested: rpc parent %v, put parent %v", rpc.Parent().SpanID(), put.Parent().SpanID())
+	}
+	var id string
+	for _, kv := range rpc.Attributes() {
+		if kv.Key == logging.KeyRequestID {
+			id = kv.Value.AsString()
+		}
+	}
+	if id != "traced" {
+		t.Fatalf("request id %q on the rpc span", id)
+	}
+}
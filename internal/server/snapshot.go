@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"dfs/internal/auth"
+	"dfs/internal/node"
+	pb "dfs/proto"
+)
+
+// snapshotChunk is the size of the data sent per ExportSnapshot message.
+const snapshotChunk = 64 << 10
+
+// Snapshot snapshots the answering node's state and truncates its log.
+// Any node can answer. It requires admin rights.
+func (s *Server) Snapshot(ctx context.Context, req *pb.SnapshotRequest) (*pb.SnapshotResponse, error) {
+	if err := s.authorize(ctx, adminPath, auth.Admin); err != nil {
+		return nil, err
+	}
+	meta, err := s.node.Snapshot(req.Compact)
+	if err != nil {
+		return nil, snapshotErr(err)
+	}
+	list, err := s.node.Snapshots()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, errInternal, err)
+	}
+	return &pb.SnapshotResponse{Snapshot: snapshotMeta(meta), Snapshots: snapshotMetas(list)}, nil
+}
+
+// ListSnapshots lists the snapshots kept by the answering node. It
+// requires admin rights.
+func (s *Server) ListSnapshots(ctx context.Context, req *pb.ListSnapshotsRequest) (*pb.ListSnapshotsResponse, error) {
+	if err := s.authorize(ctx, adminPath, auth.Admin); err != nil {
+		return nil, err
+	}
+	list, err := s.node.Snapshots()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, errInternal, err)
+	}
+	return &pb.ListSnapshotsResponse{Snapshots: snapshotMetas(list)}, nil
+}
+
+// ExportSnapshot streams a snapshot file of the answering node. It
+// requires admin rights.
+func (s *Server) ExportSnapshot(req *pb.ExportSnapshotRequest, stream pb.FileService_ExportSnapshotServer) error {
+	if err := s.authorize(stream.Context(), adminPath, auth.Admin); err != nil {
+		return err
+	}
+	meta, rc, err := s.node.OpenSnapshot(req.Id)
+	if err != nil {
+		return snapshotErr(err)
+	}
+	defer rc.Close()
+	chunk := &pb.SnapshotChunk{Meta: snapshotMeta(meta)}
+	buf := make([]byte, snapshotChunk)
+	for {
+		n, err := io.ReadFull(rc, buf)
+		if n > 0 || chunk.Meta != nil {
+			chunk.Data = buf[:n]
+			if err := stream.Send(chunk); err != nil {
+				return err
+			}
+			chunk = &pb.SnapshotChunk{}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, errInternal, err)
+		}
+	}
+}
+
+func snapshotMeta(m node.SnapshotMeta) *pb.SnapshotMeta {
+	return &pb.SnapshotMeta{Id: m.ID, Index: m.Index, Term: m.Term, Size: m.Size}
+}
+
+func snapshotMetas(list []node.SnapshotMeta) []*pb.SnapshotMeta {
+	out := make([]*pb.SnapshotMeta, 0, len(list))
+	for _, m := range list {
+		out = append(out, snapshotMeta(m))
+	}
+	return out
+}
+
+// snapshotErr converts a failed snapshot operation to a status error.
+func snapshotErr(err error) error {
+	switch {
+	case errors.Is(err, node.ErrNothingToSnapshot):
+		return status.Errorf(codes.FailedPrecondition, errInternal, err)
+	case errors.Is(err, node.ErrUnknownSnapshot), errors.Is(err, node.ErrNoSnapshot):
+		return status.Errorf(codes.NotFound, errInternal, err)
+	}
+	return status.Errorf(codes.Internal, errInternal, err)
+}
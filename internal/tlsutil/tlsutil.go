@@ -0,0 +1,351 @@
+// Package tlsutil builds TLS configurations for the gRPC API and the Raft
+// transport from certificate files that are reloaded when they change.
+package tlsutil
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/soheilhy/cmux"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	// ProtoGRPC is the ALPN protocol negotiated by gRPC clients.
+	ProtoGRPC = "h2"
+	// ProtoRaft is the ALPN protocol negotiated by Raft peers.
+	ProtoRaft = "dfs-raft"
+
+	checkEvery       = time.Second
+	handshakeTimeout = 10 * time.Second
+)
+
+var (
+	errNoCA       = errors.New("tlsutil: no certificates found in CA file")
+	errNotTLS     = errors.New("tlsutil: connection is not TLS")
+	errClientSide = errors.New("tlsutil: terminated credentials are server only")
+	errServerSide = errors.New("tlsutil: client credentials are client only")
+)
+
+// Reloader holds a certificate pair and CA pool loaded from files. The
+// files are re-read at most once per second when their modification time
+// changes, so rotated certificates are picked up without a restart.
+type Reloader struct {
+	certFile, keyFile, caFile string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	mod     time.Time
+	checked time.Time
+}
+
+// NewReloader loads the certificate, key and CA files.
+func NewReloader(certFile, keyFile, caFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile, caFile: caFile}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Reloader) load() error {
+	p, err := r.read()
+	if err != nil {
+		return err
+	}
+	p.Apply()
+	return nil
+}
+
+// read loads the files without putting them in use.
+func (r *Reloader) read() (*Pending, error) {
+	mod, err := r.modTime()
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return nil, err
+	}
+	ca, err := os.ReadFile(r.caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errNoCA
+	}
+	return &Pending{r: r, cert: &cert, pool: pool, mod: mod}, nil
+}
+
+// Pending is certificate material read from the files but not yet in use.
+type Pending struct {
+	r    *Reloader
+	cert *tls.Certificate
+	pool *x509.CertPool
+	mod  time.Time
+}
+
+// Apply puts the material in use unless newer files were loaded since it
+// was read.
+func (p *Pending) Apply() {
+	r := p.r
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cert != nil && r.mod.After(p.mod) {
+		return
+	}
+	r.cert, r.pool, r.mod, r.checked = p.cert, p.pool, p.mod, time.Now()
+}
+
+// modTime returns the latest modification time of the three files.
+func (r *Reloader) modTime() (time.Time, error) {
+	var latest time.Time
+	for _, f := range []string{r.certFile, r.keyFile, r.caFile} {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if fi.ModTime().After(latest) {
+			latest = fi.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// current returns the loaded material, reloading it first if the files
+// changed. Reload errors keep the previous material in use.
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.RLock()
+	cert, pool, mod, checked := r.cert, r.pool, r.mod, r.checked
+	r.mu.RUnlock()
+	if time.Since(checked) < checkEvery {
+		return cert, pool
+	}
+	r.mu.Lock()
+	r.checked = time.Now()
+	r.mu.Unlock()
+	if m, err := r.modTime(); err == nil && !m.Equal(mod) && r.load() == nil {
+		r.mu.RLock()
+		cert, pool = r.cert, r.pool
+		r.mu.RUnlock()
+	}
+	return cert, pool
+}
+
+// Reload re-reads the files now if they changed since they were loaded
+// and reports whether they did. Unlike the periodic check it returns the
+// error of a failed reload; the previous material stays in use.
+func (r *Reloader) Reload() (bool, error) {
+	p, err := r.Check()
+	if p == nil || err != nil {
+		return false, err
+	}
+	p.Apply()
+	return true, nil
+}
+
+// Check re-reads the files now if they changed since they were loaded and
+// returns the new material without putting it in use, or nil when they
+// did not change. The caller applies it once its other settings are
+// known to be valid.
+func (r *Reloader) Check() (*Pending, error) {
+	r.mu.RLock()
+	mod := r.mod
+	r.mu.RUnlock()
+	m, err := r.modTime()
+	if err != nil {
+		return nil, err
+	}
+	if m.Equal(mod) {
+		return nil, nil
+	}
+	return r.read()
+}
+
+// ServerConfig returns the listener configuration. Client certificates are
+// verified when presented; Raft connections are additionally required to
+// present one by RequirePeerCert.
+func (r *Reloader) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{ProtoGRPC, ProtoRaft},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool := r.current()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				NextProtos:   []string{ProtoGRPC, ProtoRaft},
+				Certificates: []tls.Certificate{*cert},
+				ClientCAs:    pool,
+				ClientAuth:   tls.VerifyClientCertIfGiven,
+			}, nil
+		},
+	}
+}
+
+// ClientConfig returns a configuration for dialing a peer with this node's
+// certificate. serverName may be empty to verify against the dialed host.
+func (r *Reloader) ClientConfig(serverName string, protos ...string) *tls.Config {
+	cert, pool := r.current()
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		ServerName:   serverName,
+		NextProtos:   protos,
+		Certificates: []tls.Certificate{*cert},
+		RootCAs:      pool,
+	}
+}
+
+// ClientCredentials returns gRPC transport credentials that build the
+// ClientConfig for serverName anew on every handshake, so long-lived
+// clients present rotated certificates and trust a rotated CA.
+func (r *Reloader) ClientCredentials(serverName string) credentials.TransportCredentials {
+	return &clientCreds{r: r, serverName: serverName}
+}
+
+type clientCreds struct {
+	r          *Reloader
+	serverName string
+}
+
+func (c *clientCreds) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return credentials.NewTLS(c.r.ClientConfig(c.serverName)).ClientHandshake(ctx, authority, conn)
+}
+
+func (*clientCreds) ServerHandshake(net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errServerSide
+}
+
+func (c *clientCreds) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "tls", SecurityVersion: "1.2", ServerName: c.serverName}
+}
+
+func (c *clientCreds) Clone() credentials.TransportCredentials {
+	cp := *c
+	return &cp
+}
+
+func (c *clientCreds) OverrideServerName(name string) error {
+	c.serverName = name
+	return nil
+}
+
+// RequirePeerCert wraps l so only connections that presented a verified
+// client certificate are accepted. Others are closed. Each handshake runs
+// in its own goroutine, bounded by handshakeTimeout, so a client that
+// stalls holds up only its own connection.
+func RequirePeerCert(l net.Listener) net.Listener {
+	p := &peerListener{Listener: l, conns: make(chan net.Conn), done: make(chan struct{})}
+	go p.serve()
+	return p
+}
+
+type peerListener struct {
+	net.Listener
+	conns chan net.Conn // connections that completed the handshake
+
+	once sync.Once
+	done chan struct{} // closed when the listener fails or is closed
+	err  error         // set before done is closed
+}
+
+// serve accepts connections until the underlying listener fails.
+func (l *peerListener) serve() {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			l.stop(err)
+			return
+		}
+		go l.verify(c)
+	}
+}
+
+// verify hands c to Accept once it has presented a verified certificate.
+func (l *peerListener) verify(c net.Conn) {
+	if st, ok := connState(c); !ok || len(st.VerifiedChains) == 0 {
+		c.Close()
+		return
+	}
+	select {
+	case l.conns <- c:
+	case <-l.done:
+		c.Close()
+	}
+}
+
+func (l *peerListener) stop(err error) {
+	l.once.Do(func() {
+		l.err = err
+		close(l.done)
+	})
+}
+
+func (l *peerListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+		return nil, l.err
+	}
+}
+
+func (l *peerListener) Close() error {
+	l.stop(net.ErrClosed)
+	return l.Listener.Close()
+}
+
+// connState returns the TLS state of c, looking through cmux wrappers. It
+// completes the handshake first if the matcher did not read from c.
+func connState(c net.Conn) (tls.ConnectionState, bool) {
+	if mc, ok := c.(*cmux.MuxConn); ok {
+		c = mc.Conn
+	}
+	tc, ok := c.(*tls.Conn)
+	if !ok {
+		return tls.ConnectionState{}, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), handshakeTimeout)
+	defer cancel()
+	if err := tc.HandshakeContext(ctx); err != nil {
+		return tls.ConnectionState{}, false
+	}
+	return tc.ConnectionState(), true
+}
+
+// Terminated returns gRPC transport credentials for connections whose TLS
+// handshake was already performed by a tls listener in front of cmux. It
+// exposes the peer certificates to handlers as credentials.TLSInfo.
+func Terminated() credentials.TransportCredentials { return terminated{} }
+
+type terminated struct{}
+
+func (terminated) ClientHandshake(ctx context.Context, _ string, c net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errClientSide
+}
+
+func (terminated) ServerHandshake(c net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	st, ok := connState(c)
+	if !ok {
+		return nil, nil, errNotTLS
+	}
+	return c, credentials.TLSInfo{
+		State:          st,
+		CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.PrivacyAndIntegrity},
+	}, nil
+}
+
+func (terminated) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "tls", SecurityVersion: "1.2"}
+}
+
+func (t terminated) Clone() credentials.TransportCredentials { return t }
+
+func (terminated) OverrideServerName(string) error { return nil }
@@ -0,0 +1,261 @@
+package tlsutil
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/soheilhy/cmux"
+)
+
+const (
+	host     = "127.0.0.1"
+	certName = "node.pem"
+	keyName  = "node.key"
+	caName   = "ca.pem"
+)
+
+// writeCerts writes a fresh CA and a node certificate signed by it into dir
+// and returns the three file paths.
+func writeCerts(t *testing.T, dir, cn string) (string, string, string) {
+	t.Helper()
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("ca: %v", err)
+	}
+	caCert, _ := x509.ParseCertificate(caDER)
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP(host)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("cert: %v", err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	certPath, keyPath, caPath := filepath.Join(dir, certName), filepath.Join(dir, keyName), filepath.Join(dir, caName)
+	write := func(path, typ string, b []byte) {
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: b}), 0o600); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	write(certPath, "CERTIFICATE", der)
+	write(keyPath, "EC PRIVATE KEY", keyDER)
+	write(caPath, "CERTIFICATE", caDER)
+	return certPath, keyPath, caPath
+}
+
+func TestRequirePeerCert(t *testing.T) {
+	certFile, keyFile, caFile := writeCerts(t, t.TempDir(), "n1")
+	r, err := NewReloader(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatalf("reloader: %v", err)
+	}
+	ln, err := tls.Listen("tcp", host+":0", r.ServerConfig())
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	mux := cmux.New(ln)
+	peers := RequirePeerCert(mux.Match(cmux.Any()))
+	go mux.Serve()
+	defer ln.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		for {
+			c, err := peers.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- c
+		}
+	}()
+
+	// A client that never sends its hello must not hold up the others.
+	stalled, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial stalled: %v", err)
+	}
+	defer stalled.Close()
+	dialer := &net.Dialer{Timeout: 2 * time.Second}
+
+	anon := r.ClientConfig("", ProtoRaft)
+	anon.Certificates = nil
+	c, err := tls.DialWithDialer(dialer, "tcp", ln.Addr().String(), anon)
+	if err != nil {
+		t.Fatalf("dial anonymous: %v", err)
+	}
+	c.Write([]byte("x"))
+	select {
+	case <-accepted:
+		t.Fatalf("connection without client certificate accepted")
+	case <-time.After(200 * time.Millisecond):
+	}
+	c.Close()
+
+	c, err = tls.DialWithDialer(dialer, "tcp", ln.Addr().String(), r.ClientConfig("", ProtoRaft))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer c.Close()
+	if c.ConnectionState().NegotiatedProtocol != ProtoRaft {
+		t.Fatalf("unexpected ALPN %q", c.ConnectionState().NegotiatedProtocol)
+	}
+	c.Write([]byte("x"))
+	select {
+	case ac := <-accepted:
+		if _, _, err := Terminated().ServerHandshake(ac); err != nil {
+			t.Fatalf("handshake: %v", err)
+		}
+		ac.Close()
+	case <-time.After(2 * time.Second):
+		t.Fatalf("peer connection not accepted")
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, caFile := writeCerts(t, dir, "old")
+	r, err := NewReloader(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatalf("reloader: %v", err)
+	}
+	writeCerts(t, dir, "new")
+	future := time.Now().Add(time.Minute)
+	for _, f := range []string{certFile, keyFile, caFile} {
+		os.Chtimes(f, future, future)
+	}
+	r.mu.Lock()
+	r.checked = time.Time{}
+	r.mu.Unlock()
+	cert, _ := r.current()
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil || leaf.Subject.CommonName != "new" {
+		t.Fatalf("certificate not reloaded: %v %v", err, leaf.Subject)
+	}
+}
+
+func TestReloadNow(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, caFile := writeCerts(t, dir, "old")
+	r, err := NewReloader(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatalf("reloader: %v", err)
+	}
+	if changed, err := r.Reload(); changed || err != nil {
+		t.Fatalf("unchanged files reloaded: %v %v", changed, err)
+	}
+	writeCerts(t, dir, "new")
+	future := time.Now().Add(time.Minute)
+	for _, f := range []string{certFile, keyFile, caFile} {
+		os.Chtimes(f, future, future)
+	}
+	p, err := r.Check()
+	if p == nil || err != nil {
+		t.Fatalf("changed files not read: %v %v", p, err)
+	}
+	if leaf, _ := x509.ParseCertificate(r.cert.Certificate[0]); leaf.Subject.CommonName != "old" {
+		t.Fatalf("checked certificate applied early: %v", leaf.Subject)
+	}
+	if changed, err := r.Reload(); !changed || err != nil {
+		t.Fatalf("changed files not reloaded: %v %v", changed, err)
+	}
+	leaf, err := x509.ParseCertificate(r.cert.Certificate[0])
+	if err != nil || leaf.Subject.CommonName != "new" {
+		t.Fatalf("certificate not reloaded: %v %v", err, leaf.Subject)
+	}
+	os.WriteFile(keyFile, []byte("garbage"), 0o600)
+	os.Chtimes(keyFile, future.Add(time.Minute), future.Add(time.Minute))
+	if _, err := r.Reload(); err == nil {
+		t.Fatalf("expected error for a broken key file")
+	}
+}
+
+func TestClientCredentials(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, caFile := writeCerts(t, dir, "old")
+	r, err := NewReloader(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatalf("reloader: %v", err)
+	}
+	ln, err := tls.Listen("tcp", host+":0", r.ServerConfig())
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	peers := make(chan string, 1)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			tc := c.(*tls.Conn)
+			if tc.Handshake() == nil && len(tc.ConnectionState().PeerCertificates) > 0 {
+				peers <- tc.ConnectionState().PeerCertificates[0].Subject.CommonName
+			}
+			c.Close()
+		}
+	}()
+	creds := r.ClientCredentials("")
+	handshake := func() string {
+		t.Helper()
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		defer c.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if _, _, err := creds.ClientHandshake(ctx, ln.Addr().String(), c); err != nil {
+			t.Fatalf("handshake: %v", err)
+		}
+		select {
+		case cn := <-peers:
+			return cn
+		case <-time.After(2 * time.Second):
+			t.Fatalf("handshake not seen by the server")
+		}
+		return ""
+	}
+	if cn := handshake(); cn != "old" {
+		t.Fatalf("unexpected client certificate %q", cn)
+	}
+	writeCerts(t, dir, "new")
+	future := time.Now().Add(time.Minute)
+	for _, f := range []string{certFile, keyFile, caFile} {
+		os.Chtimes(f, future, future)
+	}
+	if changed, err := r.Reload(); !changed || err != nil {
+		t.Fatalf("reload: %v %v", changed, err)
+	}
+	if cn := handshake(); cn != "new" {
+		t.Fatalf("reloaded certificate not presented: %q", cn)
+	}
+}